@@ -1,16 +1,21 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
 	"log"
 	"net"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/rachanaanugandula/kube-pfs/pkg/mds"
 	"github.com/rachanaanugandula/kube-pfs/pkg/metrics"
 	protogen "github.com/rachanaanugandula/kube-pfs/pkg/proto/gen"
+	"github.com/rachanaanugandula/kube-pfs/pkg/raft"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 )
 
 func main() {
@@ -19,24 +24,150 @@ func main() {
 		metricsAddr = flag.String("metrics-listen", ":9101", "metrics listen address")
 		boltPath    = flag.String("bolt-path", "./data/mds.db", "BoltDB path")
 		ostIDsRaw   = flag.String("ost-ids", "ost-0,ost-1,ost-2", "comma-separated OST IDs")
+		replicas    = flag.Uint("replicas", 1, "copies of each chunk for new files (at most the number of OSTs)")
+		hbInterval  = flag.Duration("heartbeat-interval", 5*time.Second, "how often registered OSTs heartbeat")
+		hbTimeout   = flag.Duration("heartbeat-timeout", 15*time.Second, "mark an OST down after this long without a heartbeat")
+		placement   = flag.String("placement", mds.PlacementRoundRobin, "stripe placement policy: round-robin, free-space or latency")
+		healthPoll  = flag.Duration("health-poll-interval", 10*time.Second, "how often to sample GetHealth on registered OSTs")
+		gcInterval  = flag.Duration("gc-interval", 30*time.Second, "how often to delete the chunks of unlinked files from the OSTs")
+		cacheSize   = flag.Int("cache-entries", 0, "inodes and directory entries to keep in memory; 0 loads the whole namespace at startup")
+		lockShards  = flag.Int("lock-shards", 64, "number of locks the namespace is sharded over by inode ID")
+		commitBatch = flag.Int("commit-max-batch", 256, "most metadata writes to group-commit in one bolt transaction")
+		commitDelay = flag.Duration("commit-max-delay", time.Millisecond, "how long a metadata commit waits for writes already on their way to share it; negative commits at once")
+		journalPath = flag.String("journal-path", "./data/mds.journal", "append-only metadata journal; empty disables journaling")
+		snapDir     = flag.String("snapshot-dir", "", "directory for periodic database snapshots used by --restore")
+		snapEvery   = flag.Duration("snapshot-interval", time.Hour, "how often to write a snapshot to --snapshot-dir")
+		snapKeep    = flag.Int("snapshot-keep", 24, "how many snapshots to keep in --snapshot-dir; the journal is trimmed to the oldest")
+		restoreFrom = flag.String("restore", "", "restore the snapshot at this path plus --journal-path into --restore-out and exit")
+		restoreOut  = flag.String("restore-out", "", "with --restore, where to write the restored database")
+		restoreSeq  = flag.Uint64("restore-to-seq", 0, "with --restore, stop after this journal sequence number")
+		restoreTime = flag.String("restore-to-time", "", "with --restore, stop at this RFC 3339 time")
+		replyCache  = flag.Int("reply-cache-entries", 65536, "responses to mutating requests with a request ID to keep for client retries")
+		raftID      = flag.String("raft-id", "", "this MDS's ID in --raft-peers; empty runs a standalone MDS")
+		raftPeers   = flag.String("raft-peers", "", "comma-separated id=host:port of every replicated MDS, including this one")
+		raftElect   = flag.Duration("raft-election-timeout", time.Second, "how long a follower waits for the leader before standing for election")
+		raftBeat    = flag.Duration("raft-heartbeat-interval", 100*time.Millisecond, "how often the leader heartbeats followers")
+		raftCompact = flag.Uint64("raft-compact-every", 1024, "how many applied raft entries build up before they are dropped from the raft log")
+		shardID     = flag.String("shard-id", "", "this MDS's ID in --shards; empty runs an MDS that holds the whole namespace")
+		shardList   = flag.String("shards", "", "comma-separated id=host:port of every MDS shard in map order, the one holding the root first")
+		shardTxnTTL = flag.Duration("shard-txn-timeout", 10*time.Second, "how long a shard holds a prepared cross-shard change before asking its coordinator")
+		shardSweep  = flag.Duration("shard-resolve-interval", 5*time.Second, "how often to settle cross-shard changes left unfinished")
 	)
+	pools := map[string][]string{}
+	flag.Func("pool", "define an OST pool as name or name=ost-a,ost-b (repeatable)", func(v string) error {
+		name, members, _ := strings.Cut(v, "=")
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return fmt.Errorf("pool name is empty")
+		}
+		pools[name] = append(pools[name], splitCSV(members)...)
+		return nil
+	})
 	flag.Parse()
 
+	if *restoreFrom != "" {
+		cfg := mds.RestoreConfig{SnapshotPath: *restoreFrom, JournalPath: *journalPath, OutPath: *restoreOut, ToSeq: *restoreSeq}
+		if *restoreTime != "" {
+			t, err := time.Parse(time.RFC3339, *restoreTime)
+			if err != nil {
+				log.Fatalf("parse --restore-to-time: %v", err)
+			}
+			cfg.ToTime = t
+		}
+		res, err := mds.Restore(cfg)
+		if err != nil {
+			log.Fatalf("restore: %v", err)
+		}
+		log.Printf("restored %s to journal seq %d (%d records replayed); start the mds on it with a new --journal-path", *restoreOut, res.Seq, res.Replayed)
+		return
+	}
+
 	if err := os.MkdirAll("./data", 0755); err != nil {
 		log.Fatalf("create data dir: %v", err)
 	}
 
+	policy, err := mds.NewPlacementPolicy(*placement)
+	if err != nil {
+		log.Fatalf("parse --placement: %v", err)
+	}
+	var replication *mds.ReplicationConfig
+	if *raftID != "" {
+		peers, err := parsePeers(*raftPeers)
+		if err != nil {
+			log.Fatalf("parse --raft-peers: %v", err)
+		}
+		replication = &mds.ReplicationConfig{
+			NodeID:            *raftID,
+			Peers:             peers,
+			ElectionTimeout:   *raftElect,
+			HeartbeatInterval: *raftBeat,
+			CompactEvery:      *raftCompact,
+		}
+	}
+	var sharding *mds.ShardingConfig
+	if *shardID != "" {
+		peers, err := parsePeers(*shardList)
+		if err != nil {
+			log.Fatalf("parse --shards: %v", err)
+		}
+		sharding = &mds.ShardingConfig{
+			ShardID: *shardID,
+			Dial: func(address string) (protogen.ShardServiceClient, error) {
+				conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+				if err != nil {
+					return nil, err
+				}
+				return protogen.NewShardServiceClient(conn), nil
+			},
+			TxnTimeout: *shardTxnTTL,
+		}
+		for _, p := range peers {
+			sharding.Shards = append(sharding.Shards, mds.Shard{ID: p.ID, Address: p.Address})
+		}
+	}
 	svc, err := mds.NewService(mds.Config{
-		BoltPath:        *boltPath,
-		OSTIDs:          splitCSV(*ostIDsRaw),
-		DefaultMode:     0644,
-		DefaultStripeSz: 1024 * 1024,
+		BoltPath:          *boltPath,
+		OSTIDs:            splitCSV(*ostIDsRaw),
+		DefaultMode:       0644,
+		DefaultStripeSz:   1024 * 1024,
+		ReplicaCount:      uint32(*replicas),
+		HeartbeatInterval: *hbInterval,
+		HeartbeatTimeout:  *hbTimeout,
+		Placement:         policy,
+		Pools:             pools,
+		CacheEntries:      *cacheSize,
+		LockShards:        *lockShards,
+		CommitMaxBatch:    *commitBatch,
+		CommitMaxDelay:    *commitDelay,
+		JournalPath:       *journalPath,
+		Replication:       replication,
+		ReplyCacheEntries: *replyCache,
+		Sharding:          sharding,
 	})
 	if err != nil {
 		log.Fatalf("init mds service: %v", err)
 	}
 	defer svc.Close()
 
+	dialOST := func(address string) (protogen.ObjectStorageServiceClient, error) {
+		conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			return nil, err
+		}
+		return protogen.NewObjectStorageServiceClient(conn), nil
+	}
+	poller := mds.NewHealthPoller(svc, dialOST, mds.HealthPollConfig{Interval: *healthPoll})
+	go poller.Run(context.Background())
+	log.Printf("mds placing stripes with the %s policy", *placement)
+	gc := mds.NewGarbageCollector(svc, dialOST, mds.GCConfig{Interval: *gcInterval})
+	go gc.Run(context.Background())
+	if *snapDir != "" {
+		if err := os.MkdirAll(*snapDir, 0755); err != nil {
+			log.Fatalf("create snapshot dir: %v", err)
+		}
+		go svc.RunSnapshots(context.Background(), mds.SnapshotConfig{Dir: *snapDir, Interval: *snapEvery, Keep: *snapKeep})
+	}
+
 	_ = metrics.StartServer(*metricsAddr)
 	log.Printf("mds metrics listening on %s", *metricsAddr)
 
@@ -47,6 +178,15 @@ func main() {
 
 	grpcServer := grpc.NewServer()
 	protogen.RegisterMetadataServiceServer(grpcServer, svc)
+	if node := svc.Raft(); node != nil {
+		protogen.RegisterRaftServiceServer(grpcServer, node)
+		log.Printf("mds replicating as %s in a group of %d", *raftID, len(replication.Peers))
+	}
+	if shardSvc := svc.ShardService(); shardSvc != nil {
+		protogen.RegisterShardServiceServer(grpcServer, shardSvc)
+		go svc.RunShardResolver(context.Background(), *shardSweep)
+		log.Printf("mds serving as shard %s of %d", *shardID, len(sharding.Shards))
+	}
 
 	log.Printf("mds listening on %s", *listenAddr)
 	if err := grpcServer.Serve(lis); err != nil {
@@ -54,6 +194,19 @@ func main() {
 	}
 }
 
+// parsePeers reads id=host:port pairs, for --raft-peers and --shards.
+func parsePeers(v string) ([]raft.Peer, error) {
+	var peers []raft.Peer
+	for _, p := range splitCSV(v) {
+		id, addr, ok := strings.Cut(p, "=")
+		if !ok || id == "" || addr == "" {
+			return nil, fmt.Errorf("peer %q is not id=host:port", p)
+		}
+		peers = append(peers, raft.Peer{ID: id, Address: addr})
+	}
+	return peers, nil
+}
+
 func splitCSV(v string) []string {
 	parts := strings.Split(v, ",")
 	out := make([]string, 0, len(parts))
@@ -1,14 +1,21 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
 	"log"
 	"net"
+	"os"
+	"strings"
+	"time"
 
+	"github.com/rachanaanugandula/kube-pfs/pkg/client"
 	"github.com/rachanaanugandula/kube-pfs/pkg/metrics"
 	"github.com/rachanaanugandula/kube-pfs/pkg/ost"
 	protogen "github.com/rachanaanugandula/kube-pfs/pkg/proto/gen"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 )
 
 func main() {
@@ -17,14 +24,82 @@ func main() {
 		metricsAddr = flag.String("metrics-listen", ":9102", "metrics listen address")
 		ostID       = flag.String("ost-id", "ost-0", "OST node ID")
 		dataDir     = flag.String("data-dir", "./data/ost", "OST data directory")
+		scrubRate   = flag.Int64("scrub-rate", 32*1024*1024, "scrubber read rate in bytes/sec (0 disables scrubbing)")
+		scrubEvery  = flag.Duration("scrub-interval", time.Hour, "pause between scrub passes")
+		syncFlag    = flag.String("sync", "full", "block write durability: none, data or full")
+		maxBlock    = flag.Uint64("max-block-bytes", 8<<30, "largest block a write may grow; writes reaching past it are rejected")
+		mdsAddr     = flag.String("mds-addr", "", "comma-separated MDS addresses to register and heartbeat with, one per replicated MDS or MDS shard (empty disables)")
+		advertise   = flag.String("advertise-addr", "", "address the MDS hands out for this OST (default: hostname and --listen port)")
+		pool        = flag.String("pool", "", "MDS pool to join when registering, e.g. fast or capacity")
+		sweep       = flag.Bool("sweep", false, "reconcile --data-dir against the MDS at --mds-addr, remove orphaned file directories and exit")
+		sweepDryRun = flag.Bool("sweep-dry-run", false, "with --sweep, only report orphans")
+		sweepMinAge = flag.Duration("sweep-min-age", time.Hour, "with --sweep, skip file directories modified more recently")
+		trashDir    = flag.String("sweep-trash-dir", "", "with --sweep, move orphans here instead of deleting them")
+		trashKeep   = flag.Duration("sweep-trash-retention", 7*24*time.Hour, "with --sweep, delete trashed orphans older than this")
 	)
 	flag.Parse()
 
-	svc, err := ost.NewService(*ostID, *dataDir)
+	syncMode, err := ost.ParseSyncMode(*syncFlag)
+	if err != nil {
+		log.Fatalf("parse --sync: %v", err)
+	}
+	svc, err := ost.NewServiceWithConfig(ost.Config{OSTID: *ostID, DataDir: *dataDir, SyncMode: syncMode, MaxBlockBytes: *maxBlock})
 	if err != nil {
 		log.Fatalf("init ost service: %v", err)
 	}
 
+	if *sweep {
+		if *mdsAddr == "" {
+			log.Fatalf("--sweep needs --mds-addr")
+		}
+		// InodesExist is served by the leader of a replicated MDS, or by the
+		// shard holding each inode of a sharded one.
+		mds, err := client.DialShardedMDS(strings.Split(*mdsAddr, ","), client.MDSConfig{})
+		if err != nil {
+			log.Fatalf("dial mds %s: %v", *mdsAddr, err)
+		}
+		defer mds.Close()
+		sweeper := ost.NewSweeper(svc, mds, ost.SweepConfig{
+			MinAge:         *sweepMinAge,
+			DryRun:         *sweepDryRun,
+			TrashDir:       *trashDir,
+			TrashRetention: *trashKeep,
+		})
+		report, err := sweeper.SweepOnce(context.Background())
+		for _, o := range report.Orphans {
+			fmt.Printf("orphan %s blocks=%d bytes=%d\n", o.FileID, o.Blocks, o.Bytes)
+		}
+		fmt.Printf("scanned=%d orphans=%d orphan_bytes=%d removed=%d trash_purged=%d dry_run=%t\n",
+			report.Scanned, len(report.Orphans), report.OrphanBytes(), report.Removed, report.TrashPurged, *sweepDryRun)
+		if err != nil {
+			log.Fatalf("sweep: %v", err)
+		}
+		return
+	}
+
+	if *scrubRate > 0 {
+		scrubber := ost.NewScrubber(svc, ost.ScrubConfig{RateBytesPerSec: *scrubRate, Interval: *scrubEvery})
+		go scrubber.Run(context.Background())
+		log.Printf("ost(%s) scrubbing at %d bytes/sec every %s", *ostID, *scrubRate, *scrubEvery)
+	}
+
+	if *mdsAddr != "" {
+		addr, err := advertiseAddr(*advertise, *listenAddr)
+		if err != nil {
+			log.Fatalf("resolve --advertise-addr: %v", err)
+		}
+		// Each MDS in a replicated group tracks OST membership on its own.
+		for _, target := range strings.Split(*mdsAddr, ",") {
+			conn, err := grpc.NewClient(strings.TrimSpace(target), grpc.WithTransportCredentials(insecure.NewCredentials()))
+			if err != nil {
+				log.Fatalf("dial mds %s: %v", target, err)
+			}
+			defer conn.Close()
+			hb := ost.NewHeartbeater(svc, protogen.NewMetadataServiceClient(conn), ost.HeartbeatConfig{Address: addr, Pool: *pool})
+			go hb.Run(context.Background())
+		}
+	}
+
 	_ = metrics.StartServer(*metricsAddr)
 	log.Printf("ost metrics listening on %s", *metricsAddr)
 
@@ -41,3 +116,19 @@ func main() {
 		log.Fatalf("serve ost: %v", err)
 	}
 }
+
+func advertiseAddr(advertise, listen string) (string, error) {
+	if advertise != "" {
+		return advertise, nil
+	}
+	host, port, err := net.SplitHostPort(listen)
+	if err != nil {
+		return "", err
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		if host, err = os.Hostname(); err != nil {
+			return "", err
+		}
+	}
+	return net.JoinHostPort(host, port), nil
+}
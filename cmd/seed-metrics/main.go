@@ -6,17 +6,17 @@ import (
 	"fmt"
 	"log"
 	"math/rand"
+	"strings"
 	"time"
 
+	"github.com/rachanaanugandula/kube-pfs/pkg/client"
 	protogen "github.com/rachanaanugandula/kube-pfs/pkg/proto/gen"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
 func main() {
 	var (
-		mdsAddr    = flag.String("mds", "127.0.0.1:50051", "metadata service address")
-		ostAddr    = flag.String("ost", "127.0.0.1:50061", "object storage service address")
+		mdsAddr    = flag.String("mds", "127.0.0.1:50051", "comma-separated metadata service addresses, one per replicated MDS")
+		ostAddrs   = flag.String("osts", "ost-0=127.0.0.1:50061", "comma-separated OST id=address pairs")
 		iterations = flag.Int("n", 15, "number of synthetic operations")
 	)
 	flag.Parse()
@@ -24,46 +24,33 @@ func main() {
 	if *iterations <= 0 {
 		log.Fatalf("-n must be > 0")
 	}
+	osts, err := parseOSTAddrs(*ostAddrs)
+	if err != nil {
+		log.Fatalf("parse -osts: %v", err)
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 45*time.Second)
 	defer cancel()
 
-	mdsConn, err := grpc.DialContext(ctx, *mdsAddr, grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock())
-	if err != nil {
-		log.Fatalf("connect mds: %v", err)
-	}
-	defer mdsConn.Close()
-
-	ostConn, err := grpc.DialContext(ctx, *ostAddr, grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock())
+	pfs, err := client.Dial(strings.Split(*mdsAddr, ","), osts)
 	if err != nil {
-		log.Fatalf("connect ost: %v", err)
+		log.Fatalf("connect: %v", err)
 	}
-	defer ostConn.Close()
-
-	mdsClient := protogen.NewMetadataServiceClient(mdsConn)
-	ostClient := protogen.NewObjectStorageServiceClient(ostConn)
+	defer pfs.Close()
+	mdsClient := pfs.MDS()
 
 	randSrc := rand.New(rand.NewSource(time.Now().UnixNano()))
 
 	for i := 0; i < *iterations; i++ {
 		name := fmt.Sprintf("seed-%d-%d.bin", time.Now().UnixNano(), i)
 
-		createRes, err := mdsClient.Create(ctx, &protogen.CreateRequest{
-			ParentInodeId: "root",
-			Name:          name,
-			IsDir:         false,
-			Mode:          0644,
-		})
+		f, err := pfs.Create(ctx, "root", name, 0644)
 		if err != nil {
 			log.Fatalf("mds create failed at iteration %d: %v", i, err)
 		}
-		inode := createRes.GetInode()
-		if inode == nil {
-			log.Fatalf("mds create returned nil inode at iteration %d", i)
-		}
 
 		_, _ = mdsClient.Lookup(ctx, &protogen.LookupRequest{ParentInodeId: "root", Name: name})
-		_, _ = mdsClient.Stat(ctx, &protogen.StatRequest{InodeId: inode.GetInodeId()})
+		_, _ = mdsClient.Stat(ctx, &protogen.StatRequest{InodeId: f.InodeID()})
 		_, _ = mdsClient.ListDir(ctx, &protogen.ListDirRequest{InodeId: "root"})
 
 		payload := make([]byte, 8192+randSrc.Intn(8192))
@@ -71,24 +58,39 @@ func main() {
 			payload[j] = byte(randSrc.Intn(255))
 		}
 
-		_, err = ostClient.WriteBlock(ctx, &protogen.WriteBlockRequest{
-			Block: &protogen.BlockRef{FileId: inode.GetInodeId(), ChunkId: 0, OstId: "ost-0"},
-			Data:  payload,
-		})
-		if err != nil {
-			log.Fatalf("ost write failed at iteration %d: %v", i, err)
+		if _, err := f.WriteAt(ctx, payload, 0); err != nil {
+			log.Fatalf("write failed at iteration %d: %v", i, err)
 		}
-
-		_, err = ostClient.ReadBlock(ctx, &protogen.ReadBlockRequest{
-			Block: &protogen.BlockRef{FileId: inode.GetInodeId(), ChunkId: 0, OstId: "ost-0"},
-		})
-		if err != nil {
-			log.Fatalf("ost read failed at iteration %d: %v", i, err)
+		if err := f.Sync(ctx); err != nil {
+			log.Fatalf("sync failed at iteration %d: %v", i, err)
+		}
+		if _, err := f.ReadAt(ctx, make([]byte, len(payload)), 0); err != nil {
+			log.Fatalf("read failed at iteration %d: %v", i, err)
 		}
 
-		_, _ = ostClient.DeleteBlock(ctx, &protogen.DeleteBlockRequest{Block: &protogen.BlockRef{FileId: inode.GetInodeId(), ChunkId: 0, OstId: "ost-0"}})
+		// Truncating to zero drops every chunk on the OSTs before the unlink.
+		_ = f.Truncate(ctx, 0)
 		_, _ = mdsClient.Unlink(ctx, &protogen.UnlinkRequest{ParentInodeId: "root", Name: name})
 	}
 
 	fmt.Printf("seeded metrics with %d synthetic metadata/data operations\n", *iterations)
 }
+
+func parseOSTAddrs(v string) (map[string]string, error) {
+	out := map[string]string{}
+	for _, pair := range strings.Split(v, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		id, addr, ok := strings.Cut(pair, "=")
+		if !ok || id == "" || addr == "" {
+			return nil, fmt.Errorf("expected id=address, got %q", pair)
+		}
+		out[id] = addr
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("at least one OST is required")
+	}
+	return out, nil
+}
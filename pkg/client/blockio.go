@@ -0,0 +1,82 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"hash/crc32"
+	"io"
+
+	protogen "github.com/rachanaanugandula/kube-pfs/pkg/proto/gen"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// Blocks above streamThreshold go over the streaming RPCs so chunk size is
+	// not capped by the gRPC message limit.
+	streamThreshold = 1024 * 1024
+	streamFrameSize = 1024 * 1024
+)
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// putBlock writes data in place at offset within a block, cutting the block
+// off after it when truncate is set. The CRC32C sent alongside lets the OST
+// reject bytes that were damaged in flight.
+func putBlock(ctx context.Context, ost protogen.ObjectStorageServiceClient, ref *protogen.BlockRef, offset uint64, data []byte, truncate bool) error {
+	sum := proto.Uint32(crc32.Checksum(data, crc32cTable))
+	if len(data) <= streamThreshold {
+		_, err := ost.WriteBlock(ctx, &protogen.WriteBlockRequest{Block: ref, Data: data, Offset: offset, Truncate: truncate, Crc32C: sum})
+		return err
+	}
+	stream, err := ost.WriteBlockStream(ctx)
+	if err != nil {
+		return err
+	}
+	for sent := 0; sent < len(data); sent += streamFrameSize {
+		end := min(sent+streamFrameSize, len(data))
+		msg := &protogen.WriteBlockStreamRequest{Data: data[sent:end]}
+		if sent == 0 {
+			msg.Block = ref
+			msg.Offset = offset
+			msg.Truncate = truncate
+			msg.Crc32C = sum
+		}
+		if err := stream.Send(msg); err != nil {
+			// The real cause is reported by CloseAndRecv.
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return err
+		}
+	}
+	_, err = stream.CloseAndRecv()
+	return err
+}
+
+// getBlock reads length bytes at offset from a block; length 0 means to the
+// end of the block.
+func getBlock(ctx context.Context, ost protogen.ObjectStorageServiceClient, ref *protogen.BlockRef, offset, length uint64) ([]byte, error) {
+	if length > 0 && length <= streamThreshold {
+		res, err := ost.ReadBlock(ctx, &protogen.ReadBlockRequest{Block: ref, Offset: offset, Length: length})
+		if err != nil {
+			return nil, err
+		}
+		return res.GetData(), nil
+	}
+	stream, err := ost.ReadBlockStream(ctx, &protogen.ReadBlockRequest{Block: ref, Offset: offset, Length: length})
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	for {
+		res, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return buf.Bytes(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(res.GetData())
+	}
+}
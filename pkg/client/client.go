@@ -0,0 +1,117 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	protogen "github.com/rachanaanugandula/kube-pfs/pkg/proto/gen"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Client resolves files through the MDS and moves their bytes to and from
+// the OSTs named in each file's stripe layout.
+type Client struct {
+	mds   protogen.MetadataServiceClient
+	osts  map[string]protogen.ObjectStorageServiceClient
+	conns []*grpc.ClientConn
+	// mdsClient is the MDS client Dial opened, closed with the conns.
+	mdsClient *ShardedMDSClient
+}
+
+func New(mds protogen.MetadataServiceClient, osts map[string]protogen.ObjectStorageServiceClient) *Client {
+	c := &Client{mds: mds, osts: map[string]protogen.ObjectStorageServiceClient{}}
+	for id, ost := range osts {
+		c.osts[id] = ost
+	}
+	return c
+}
+
+// Dial connects to the MDS, or every member of a replicated MDS group, in
+// mdsAddrs and to every OST in ostAddrs (OST ID -> address). Metadata calls
+// follow the MDS leader and are retried as MDSClient describes; on a sharded
+// MDS they go to the shard holding what they name.
+func Dial(mdsAddrs []string, ostAddrs map[string]string, opts ...grpc.DialOption) (*Client, error) {
+	if len(opts) == 0 {
+		opts = []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	}
+	c := &Client{osts: map[string]protogen.ObjectStorageServiceClient{}}
+	mds, err := DialShardedMDS(mdsAddrs, MDSConfig{}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	c.mds, c.mdsClient = mds, mds
+	for id, addr := range ostAddrs {
+		conn, err := grpc.NewClient(addr, opts...)
+		if err != nil {
+			_ = c.Close()
+			return nil, fmt.Errorf("dial ost %s at %s: %w", id, addr, err)
+		}
+		c.conns = append(c.conns, conn)
+		c.osts[id] = protogen.NewObjectStorageServiceClient(conn)
+	}
+	return c, nil
+}
+
+// Close releases connections opened by Dial. Clients built with New do not
+// own their connections and Close is a no-op for them.
+func (c *Client) Close() error {
+	var errs []error
+	if c.mdsClient != nil {
+		errs = append(errs, c.mdsClient.Close())
+		c.mdsClient = nil
+	}
+	for _, conn := range c.conns {
+		errs = append(errs, conn.Close())
+	}
+	c.conns = nil
+	return errors.Join(errs...)
+}
+
+func (c *Client) MDS() protogen.MetadataServiceClient {
+	return c.mds
+}
+
+func (c *Client) Create(ctx context.Context, parentInodeID, name string, mode uint64) (*File, error) {
+	res, err := c.mds.Create(ctx, &protogen.CreateRequest{ParentInodeId: parentInodeID, Name: name, Mode: mode})
+	if err != nil {
+		return nil, fmt.Errorf("create %s: %w", name, err)
+	}
+	return newFile(c, res.GetInode()), nil
+}
+
+func (c *Client) Open(ctx context.Context, parentInodeID, name string) (*File, error) {
+	res, err := c.mds.Lookup(ctx, &protogen.LookupRequest{ParentInodeId: parentInodeID, Name: name})
+	if err != nil {
+		return nil, fmt.Errorf("lookup %s: %w", name, err)
+	}
+	if res.GetInode().GetIsDir() {
+		return nil, fmt.Errorf("open %s: is a directory", name)
+	}
+	return newFile(c, res.GetInode()), nil
+}
+
+func (c *Client) ost(ostID string) (protogen.ObjectStorageServiceClient, error) {
+	ost, ok := c.osts[ostID]
+	if !ok {
+		return nil, fmt.Errorf("no connection for ost %q", ostID)
+	}
+	return ost, nil
+}
+
+// fanOut runs fn for 0..n-1 concurrently and joins every error it returns.
+func fanOut(n int, fn func(i int) error) error {
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = fn(i)
+		}(i)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
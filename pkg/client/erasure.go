@@ -0,0 +1,279 @@
+package client
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/rachanaanugandula/kube-pfs/pkg/erasure"
+	"github.com/rachanaanugandula/kube-pfs/pkg/layout"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Erasure-coded files are written a whole stripe group at a time: every cell
+// of each group a write touches is rewritten. A write that fails part way
+// leaves cells of two versions of the group, which each pass their own
+// checksums, so every cell starts with a header holding the generation of
+// the group it belongs to, and a group is only rebuilt from cells of one
+// generation.
+
+// cellHeaderSize is the length of the generation at the start of each cell.
+const cellHeaderSize = 8
+
+// groupGeneration numbers a new version of a group. Writes to a file are
+// serialised by its handle, so the clock is enough to tell the later of two
+// versions apart.
+func groupGeneration() uint64 {
+	return uint64(time.Now().UnixNano())
+}
+
+// withCellHeader returns the cell as stored: gen followed by data.
+func withCellHeader(gen uint64, data []byte) []byte {
+	cell := make([]byte, cellHeaderSize+len(data))
+	binary.BigEndian.PutUint64(cell, gen)
+	copy(cell[cellHeaderSize:], data)
+	return cell
+}
+
+// splitCellHeader returns the generation and data of a stored cell.
+func splitCellHeader(cell []byte) (uint64, []byte, error) {
+	if len(cell) < cellHeaderSize {
+		return 0, nil, fmt.Errorf("cell is %d bytes, shorter than its header", len(cell))
+	}
+	return binary.BigEndian.Uint64(cell), cell[cellHeaderSize:], nil
+}
+
+func (f *File) code() (*erasure.Code, error) {
+	ec := f.inode.GetStripeLayout().GetErasureCode()
+	return erasure.New(int(ec.GetDataChunks()), int(ec.GetParityChunks()))
+}
+
+// writeGroups writes p at off, reading back the rest of any group it only
+// partly covers so the group's parity can be recomputed.
+func (f *File) writeGroups(ctx context.Context, p []byte, off uint64) error {
+	if len(p) == 0 {
+		return nil
+	}
+	groupBytes := layout.GroupBytes(f.inode.GetStripeLayout())
+	end := off + uint64(len(p))
+	size := max(f.size, end)
+	first, last := off/groupBytes, (end-1)/groupBytes
+	return fanOut(int(last-first+1), func(i int) error {
+		group := first + uint64(i)
+		start := group * groupBytes
+		lo, hi := max(off, start), min(end, start+groupBytes)
+		buf := make([]byte, groupBytes)
+		if (lo > start || hi < start+groupBytes) && start < f.size {
+			if err := f.readGroup(ctx, group, buf); err != nil {
+				return err
+			}
+		}
+		copy(buf[lo-start:], p[lo-off:hi-off])
+		return f.writeGroup(ctx, group, buf, size)
+	})
+}
+
+// writeGroup encodes buf, the file bytes of one group, and writes every data
+// and parity cell of it. Each data cell is cut to the part of it below size;
+// parity cells are as long as the first, longest, data cell.
+func (f *File) writeGroup(ctx context.Context, group uint64, buf []byte, size uint64) error {
+	code, err := f.code()
+	if err != nil {
+		return err
+	}
+	l := f.inode.GetStripeLayout()
+	stripe := uint64(l.GetStripeSizeBytes())
+	start := group * layout.GroupBytes(l)
+	if size < start+uint64(len(buf)) {
+		clear(buf[size-start:])
+	}
+	refs := layout.GroupRefs(f.inode.GetInodeId(), l, group)
+	dataCells := int(l.GetErasureCode().GetDataChunks())
+
+	cells := make([][]byte, len(refs))
+	shards := make([][]byte, len(refs))
+	cellLen := min(stripe, size-start)
+	for i := 0; i < dataCells; i++ {
+		cellStart := start + uint64(i)*stripe
+		n := uint64(0)
+		if size > cellStart {
+			n = min(stripe, size-cellStart)
+		}
+		cells[i] = buf[uint64(i)*stripe : uint64(i)*stripe+n]
+		// Bytes past a short cell are already zero in buf, which is the
+		// padding the parity is computed over.
+		shards[i] = buf[uint64(i)*stripe : uint64(i)*stripe+cellLen]
+	}
+	if err := code.Encode(shards); err != nil {
+		return fmt.Errorf("encode group %d: %w", group, err)
+	}
+	copy(cells[dataCells:], shards[dataCells:])
+
+	gen := groupGeneration()
+	return fanOut(len(refs), func(j int) error {
+		ref := refs[j]
+		ost, err := f.client.ost(ref.GetOstId())
+		if err != nil {
+			return err
+		}
+		if err := putBlock(ctx, ost, ref, 0, withCellHeader(gen, cells[j]), true); err != nil {
+			return fmt.Errorf("write cell %d on %s: %w", ref.GetChunkId(), ref.GetOstId(), err)
+		}
+		return nil
+	})
+}
+
+// readGroupRange fills dst with the file bytes at off, which all lie inside
+// group. It reads only the data cells it needs and falls back to a degraded
+// read of the whole group if any of them is missing or unreadable. Like a
+// read of a plain striped file, it returns what each data cell holds without
+// comparing their generations.
+func (f *File) readGroupRange(ctx context.Context, group uint64, off uint64, dst []byte) error {
+	l := f.inode.GetStripeLayout()
+	refs := layout.GroupRefs(f.inode.GetInodeId(), l, group)
+	dataCells := uint64(l.GetErasureCode().GetDataChunks())
+	extents := layout.Extents(l, off, uint64(len(dst)))
+	err := fanOut(len(extents), func(i int) error {
+		ext := extents[i]
+		ref := refs[ext.ChunkID%dataCells]
+		ost, err := f.client.ost(ref.GetOstId())
+		if err != nil {
+			return err
+		}
+		data, err := getBlock(ctx, ost, ref, cellHeaderSize+ext.ChunkOffset, ext.Length)
+		if err != nil {
+			return err
+		}
+		n := copy(dst[ext.BufOffset:ext.BufOffset+ext.Length], data)
+		clear(dst[ext.BufOffset+uint64(n) : ext.BufOffset+ext.Length])
+		return nil
+	})
+	if err == nil {
+		return nil
+	}
+
+	groupBytes := layout.GroupBytes(l)
+	buf := make([]byte, groupBytes)
+	if err := f.readGroup(ctx, group, buf); err != nil {
+		return err
+	}
+	copy(dst, buf[off-group*groupBytes:])
+	return nil
+}
+
+// readGroup fills buf with the file bytes of one group. If a data cell cannot
+// be read, or the data cells are of different generations, the parity cells
+// are fetched too and the group is rebuilt from data-many cells of its
+// newest generation that has that many. A group that was never written is a
+// hole and reads back as zeros.
+func (f *File) readGroup(ctx context.Context, group uint64, buf []byte) error {
+	l := f.inode.GetStripeLayout()
+	stripe := uint64(l.GetStripeSizeBytes())
+	dataCells := int(l.GetErasureCode().GetDataChunks())
+	refs := layout.GroupRefs(f.inode.GetInodeId(), l, group)
+
+	cells := make([][]byte, len(refs))
+	gens := make([]uint64, len(refs))
+	errs := make([]error, len(refs))
+	readCells := func(from, to int) {
+		_ = fanOut(to-from, func(i int) error {
+			j := from + i
+			ref := refs[j]
+			ost, err := f.client.ost(ref.GetOstId())
+			if err == nil {
+				var cell []byte
+				if cell, err = getBlock(ctx, ost, ref, 0, cellHeaderSize+stripe); err == nil {
+					gens[j], cells[j], err = splitCellHeader(cell)
+				}
+			}
+			errs[j] = err
+			return nil
+		})
+	}
+	readCells(0, dataCells)
+	if errors.Join(errs...) == nil && !slices.ContainsFunc(gens[:dataCells], func(g uint64) bool { return g != gens[0] }) {
+		for i, cell := range cells[:dataCells] {
+			copy(buf[uint64(i)*stripe:(uint64(i)+1)*stripe], cell)
+		}
+		return nil
+	}
+	readCells(dataCells, len(refs))
+
+	var failed []error
+	present, absent := 0, 0
+	perGen := map[uint64]int{}
+	for j, err := range errs {
+		switch {
+		case err == nil:
+			present++
+			perGen[gens[j]]++
+		case status.Code(err) == codes.NotFound:
+			absent++
+		default:
+			failed = append(failed, fmt.Errorf("read cell %d on %s: %w", refs[j].GetChunkId(), refs[j].GetOstId(), err))
+		}
+	}
+	// Groups are written whole, so data-many OSTs without a cell mean the
+	// group was never written, even if the rest did not answer.
+	if present == 0 && absent >= dataCells {
+		clear(buf)
+		return nil
+	}
+	if present < dataCells {
+		return fmt.Errorf("group %d: only %d of %d cells readable, need %d: %w",
+			group, present, len(refs), dataCells, errors.Join(failed...))
+	}
+	gen, found := uint64(0), false
+	for g, n := range perGen {
+		if n >= dataCells && (!found || g > gen) {
+			gen, found = g, true
+		}
+	}
+	if !found {
+		return fmt.Errorf("group %d: its %d readable cells are split across %d generations by an interrupted write, none with the %d needed to rebuild it",
+			group, present, len(perGen), dataCells)
+	}
+
+	shards := make([][]byte, len(refs))
+	cellLen := 0
+	for j, err := range errs {
+		if err == nil && gens[j] == gen {
+			// An empty cell is still present, so it must not read as nil.
+			shards[j] = append([]byte{}, cells[j]...)
+			cellLen = max(cellLen, len(cells[j]))
+		}
+	}
+	for j, shard := range shards {
+		if shard != nil && len(shard) < cellLen {
+			padded := make([]byte, cellLen)
+			copy(padded, shard)
+			shards[j] = padded
+		}
+	}
+	code, err := f.code()
+	if err != nil {
+		return err
+	}
+	if err := code.Reconstruct(shards); err != nil {
+		return fmt.Errorf("reconstruct group %d: %w", group, err)
+	}
+	clear(buf)
+	for i := 0; i < dataCells; i++ {
+		copy(buf[uint64(i)*stripe:(uint64(i)+1)*stripe], shards[i])
+	}
+	return nil
+}
+
+// rewriteGroup re-encodes one group at the current size, which drops any
+// bytes a truncate left past EOF from its cells and parity.
+func (f *File) rewriteGroup(ctx context.Context, group uint64) error {
+	buf := make([]byte, layout.GroupBytes(f.inode.GetStripeLayout()))
+	if err := f.readGroup(ctx, group, buf); err != nil {
+		return err
+	}
+	return f.writeGroup(ctx, group, buf, f.size)
+}
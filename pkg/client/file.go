@@ -0,0 +1,267 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/rachanaanugandula/kube-pfs/pkg/layout"
+	protogen "github.com/rachanaanugandula/kube-pfs/pkg/proto/gen"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// File is an open handle on a striped file. Writes go straight to the OSTs;
+// the new size is pushed to the MDS on Sync or Close.
+type File struct {
+	client *Client
+
+	mu    sync.Mutex
+	inode *protogen.Inode
+	size  uint64
+	dirty bool
+}
+
+func newFile(c *Client, inode *protogen.Inode) *File {
+	return &File{client: c, inode: inode, size: inode.GetSizeBytes()}
+}
+
+func (f *File) InodeID() string {
+	return f.inode.GetInodeId()
+}
+
+func (f *File) Layout() *protogen.StripeLayout {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.inode.GetStripeLayout()
+}
+
+func (f *File) Size() uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.size
+}
+
+func (f *File) WriteAt(ctx context.Context, p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	l := f.inode.GetStripeLayout()
+	var err error
+	if layout.IsErasureCoded(l) {
+		err = f.writeGroups(ctx, p, uint64(off))
+	} else {
+		extents := layout.Extents(l, uint64(off), uint64(len(p)))
+		err = fanOut(len(extents), func(i int) error {
+			ext := extents[i]
+			return f.writeChunk(ctx, ext, p[ext.BufOffset:ext.BufOffset+ext.Length])
+		})
+	}
+	if err != nil {
+		return 0, err
+	}
+	if end := uint64(off) + uint64(len(p)); end > f.size {
+		f.size = end
+	}
+	f.dirty = true
+	return len(p), nil
+}
+
+func (f *File) ReadAt(ctx context.Context, p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if uint64(off) >= f.size {
+		return 0, io.EOF
+	}
+	n := uint64(len(p))
+	if remaining := f.size - uint64(off); n > remaining {
+		n = remaining
+	}
+	l := f.inode.GetStripeLayout()
+	var err error
+	if layout.IsErasureCoded(l) {
+		groupBytes := layout.GroupBytes(l)
+		first, last := uint64(off)/groupBytes, (uint64(off)+n-1)/groupBytes
+		err = fanOut(int(last-first+1), func(i int) error {
+			group := first + uint64(i)
+			lo, hi := max(uint64(off), group*groupBytes), min(uint64(off)+n, (group+1)*groupBytes)
+			return f.readGroupRange(ctx, group, lo, p[lo-uint64(off):hi-uint64(off)])
+		})
+	} else {
+		extents := layout.Extents(l, uint64(off), n)
+		err = fanOut(len(extents), func(i int) error {
+			ext := extents[i]
+			return f.readChunk(ctx, ext, p[ext.BufOffset:ext.BufOffset+ext.Length])
+		})
+	}
+	if err != nil {
+		return 0, err
+	}
+	if n < uint64(len(p)) {
+		return int(n), io.EOF
+	}
+	return int(n), nil
+}
+
+// Truncate sets the file size on the MDS, deletes chunks past the new EOF and
+// trims the chunk that now holds EOF so a later extend reads back zeros. For
+// erasure-coded files the stripe group holding EOF is re-encoded instead.
+func (f *File) Truncate(ctx context.Context, size int64) error {
+	if size < 0 {
+		return errors.New("negative size")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// The MDS computes the chunks to drop from its own size, so it has to
+	// know about every byte we wrote first.
+	if err := f.syncLocked(ctx); err != nil {
+		return err
+	}
+	oldSize := f.size
+	res, err := f.client.mds.SetAttr(ctx, &protogen.SetAttrRequest{
+		InodeId:   f.inode.GetInodeId(),
+		Mask:      uint32(protogen.SetAttrMask_SETATTR_SIZE),
+		SizeBytes: uint64(size),
+	})
+	if err != nil {
+		return fmt.Errorf("setattr size: %w", err)
+	}
+	f.inode = res.GetInode()
+	f.size = uint64(size)
+
+	blocks := res.GetTruncatedBlocks()
+	if err := fanOut(len(blocks), func(i int) error {
+		return f.deleteBlock(ctx, blocks[i])
+	}); err != nil {
+		return err
+	}
+
+	l := f.inode.GetStripeLayout()
+	if layout.IsErasureCoded(l) {
+		groupBytes := layout.GroupBytes(l)
+		if uint64(size) < oldSize && groupBytes > 0 && uint64(size)%groupBytes != 0 {
+			return f.rewriteGroup(ctx, uint64(size)/groupBytes)
+		}
+		return nil
+	}
+	stripe := uint64(l.GetStripeSizeBytes())
+	if uint64(size) < oldSize && stripe > 0 && uint64(size)%stripe != 0 {
+		return f.trimChunk(ctx, uint64(size)/stripe, uint64(size)%stripe)
+	}
+	return nil
+}
+
+// Sync publishes the handle's size and mtime to the MDS. Data is already on
+// the OSTs once WriteAt returns.
+func (f *File) Sync(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.syncLocked(ctx)
+}
+
+func (f *File) Close(ctx context.Context) error {
+	return f.Sync(ctx)
+}
+
+func (f *File) syncLocked(ctx context.Context) error {
+	if !f.dirty {
+		return nil
+	}
+	res, err := f.client.mds.SetAttr(ctx, &protogen.SetAttrRequest{
+		InodeId:      f.inode.GetInodeId(),
+		Mask:         uint32(protogen.SetAttrMask_SETATTR_SIZE | protogen.SetAttrMask_SETATTR_MTIME),
+		SizeBytes:    f.size,
+		ModifiedUnix: time.Now().Unix(),
+	})
+	if err != nil {
+		return fmt.Errorf("setattr on sync: %w", err)
+	}
+	f.inode = res.GetInode()
+	f.dirty = false
+	return nil
+}
+
+// writeChunk writes to every replica of a chunk and fails if any of them
+// does, so a successful write is never held by fewer copies than the layout
+// promises.
+func (f *File) writeChunk(ctx context.Context, ext layout.Extent, data []byte) error {
+	refs := layout.ReplicaRefs(f.inode.GetInodeId(), f.inode.GetStripeLayout(), ext.ChunkID)
+	return fanOut(len(refs), func(i int) error {
+		ref := refs[i]
+		ost, err := f.client.ost(ref.GetOstId())
+		if err != nil {
+			return err
+		}
+		if err := putBlock(ctx, ost, ref, ext.ChunkOffset, data, false); err != nil {
+			return fmt.Errorf("write chunk %d on %s: %w", ref.GetChunkId(), ref.GetOstId(), err)
+		}
+		return nil
+	})
+}
+
+// readChunk fills dst from the first replica that answers. Missing chunks
+// and bytes past a short chunk read back as zeros, the same as a hole in a
+// sparse file, but only once no replica has the chunk.
+func (f *File) readChunk(ctx context.Context, ext layout.Extent, dst []byte) error {
+	var errs []error
+	for _, ref := range layout.ReplicaRefs(f.inode.GetInodeId(), f.inode.GetStripeLayout(), ext.ChunkID) {
+		ost, err := f.client.ost(ref.GetOstId())
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		data, err := getBlock(ctx, ost, ref, ext.ChunkOffset, ext.Length)
+		if status.Code(err) == codes.NotFound {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("read chunk %d on %s: %w", ref.GetChunkId(), ref.GetOstId(), err))
+			continue
+		}
+		n := copy(dst, data)
+		clear(dst[n:])
+		return nil
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	clear(dst)
+	return nil
+}
+
+func (f *File) trimChunk(ctx context.Context, chunkID, keep uint64) error {
+	refs := layout.ReplicaRefs(f.inode.GetInodeId(), f.inode.GetStripeLayout(), chunkID)
+	return fanOut(len(refs), func(i int) error {
+		ref := refs[i]
+		ost, err := f.client.ost(ref.GetOstId())
+		if err != nil {
+			return err
+		}
+		if _, err := ost.WriteBlock(ctx, &protogen.WriteBlockRequest{Block: ref, Offset: keep, Truncate: true}); err != nil {
+			return fmt.Errorf("trim chunk %d on %s: %w", chunkID, ref.GetOstId(), err)
+		}
+		return nil
+	})
+}
+
+func (f *File) deleteBlock(ctx context.Context, ref *protogen.BlockRef) error {
+	ost, err := f.client.ost(ref.GetOstId())
+	if err != nil {
+		return err
+	}
+	if _, err := ost.DeleteBlock(ctx, &protogen.DeleteBlockRequest{Block: ref}); err != nil {
+		return fmt.Errorf("delete chunk %d on %s: %w", ref.GetChunkId(), ref.GetOstId(), err)
+	}
+	return nil
+}
@@ -0,0 +1,303 @@
+package client
+
+import (
+	"context"
+	crand "crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	protogen "github.com/rachanaanugandula/kube-pfs/pkg/proto/gen"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	gproto "google.golang.org/protobuf/proto"
+)
+
+// MDSConfig tunes an MDSClient. Zero fields take the defaults in brackets.
+type MDSConfig struct {
+	// ClientID names this client in the request IDs it puts on mutating
+	// calls [random]. Two clients must never share one.
+	ClientID string
+	// AttemptTimeout bounds one try against one MDS [5s].
+	AttemptTimeout time.Duration
+	// CallTimeout bounds a call with all its retries when the caller's
+	// context has no deadline [30s].
+	CallTimeout time.Duration
+	// MinBackoff and MaxBackoff bound the jittered wait between retries,
+	// which doubles each time [50ms, 2s].
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// MDSClient is a MetadataServiceClient for a replicated MDS group, or a
+// single MDS. It sends each call to the member it believes leads, follows
+// the LeaderHint of a member that does not, and tries the others in turn
+// when no leader is known.
+//
+// A call that a member turned away, timed out or lost its connection is
+// retried until it succeeds or its deadline passes. That is safe because
+// reads and OST membership updates are idempotent, and the client gives
+// every other mutating request an ID that the MDS answers repeats of with
+// the original response.
+type MDSClient struct {
+	cfg  MDSConfig
+	opts []grpc.DialOption
+	seq  atomic.Uint64
+
+	mu     sync.Mutex
+	addrs  []string
+	conns  map[string]*grpc.ClientConn
+	leader int
+}
+
+var _ protogen.MetadataServiceClient = (*MDSClient)(nil)
+
+// DialMDS connects to every MDS in endpoints, skipping blank ones. Members that are not listed
+// are connected to when another member names them as the leader.
+func DialMDS(endpoints []string, cfg MDSConfig, opts ...grpc.DialOption) (*MDSClient, error) {
+	if len(opts) == 0 {
+		opts = []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	}
+	if cfg.ClientID == "" {
+		var id [16]byte
+		if _, err := crand.Read(id[:]); err != nil {
+			return nil, fmt.Errorf("pick client id: %w", err)
+		}
+		cfg.ClientID = hex.EncodeToString(id[:])
+	}
+	if cfg.AttemptTimeout <= 0 {
+		cfg.AttemptTimeout = 5 * time.Second
+	}
+	if cfg.CallTimeout <= 0 {
+		cfg.CallTimeout = 30 * time.Second
+	}
+	if cfg.MinBackoff <= 0 {
+		cfg.MinBackoff = 50 * time.Millisecond
+	}
+	if cfg.MaxBackoff < cfg.MinBackoff {
+		cfg.MaxBackoff = max(2*time.Second, cfg.MinBackoff)
+	}
+	m := &MDSClient{cfg: cfg, opts: opts, conns: map[string]*grpc.ClientConn{}}
+	for _, addr := range endpoints {
+		if addr = strings.TrimSpace(addr); addr == "" {
+			continue
+		}
+		if _, err := m.conn(addr); err != nil {
+			_ = m.Close()
+			return nil, err
+		}
+	}
+	if len(m.addrs) == 0 {
+		return nil, errors.New("no mds endpoints")
+	}
+	return m, nil
+}
+
+// conn returns the connection to addr, dialing it on first use. Callers
+// hold m.mu, except DialMDS.
+func (m *MDSClient) conn(addr string) (*grpc.ClientConn, error) {
+	if c, ok := m.conns[addr]; ok {
+		return c, nil
+	}
+	c, err := grpc.NewClient(addr, m.opts...)
+	if err != nil {
+		return nil, fmt.Errorf("dial mds %s: %w", addr, err)
+	}
+	m.conns[addr] = c
+	m.addrs = append(m.addrs, addr)
+	return c, nil
+}
+
+// Close closes every connection the client opened.
+func (m *MDSClient) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var errs []error
+	for _, c := range m.conns {
+		errs = append(errs, c.Close())
+	}
+	m.conns = map[string]*grpc.ClientConn{}
+	m.addrs = nil
+	return errors.Join(errs...)
+}
+
+// target returns the member to call next.
+func (m *MDSClient) target() (string, protogen.MetadataServiceClient, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.addrs) == 0 {
+		return "", nil, errors.New("mds client is closed")
+	}
+	addr := m.addrs[m.leader]
+	return addr, protogen.NewMetadataServiceClient(m.conns[addr]), nil
+}
+
+// redirect moves on from tried, which failed, to the hinted leader or, with
+// no hint, the next member. It reports whether that is a member other than
+// tried, which is worth calling without waiting.
+func (m *MDSClient) redirect(tried, hint string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.addrs) == 0 || m.addrs[m.leader] != tried {
+		// Another call has already moved on.
+		return true
+	}
+	if hint == "" {
+		m.leader = (m.leader + 1) % len(m.addrs)
+		return false
+	}
+	if hint == tried {
+		return false
+	}
+	if _, err := m.conn(hint); err != nil {
+		m.leader = (m.leader + 1) % len(m.addrs)
+		return false
+	}
+	for i, addr := range m.addrs {
+		if addr == hint {
+			m.leader = i
+		}
+	}
+	return true
+}
+
+func (m *MDSClient) nextRequestID() *protogen.RequestID {
+	return &protogen.RequestID{ClientId: m.cfg.ClientID, Seq: m.seq.Add(1)}
+}
+
+type mdsCall[Req, Res any] func(protogen.MetadataServiceClient, context.Context, Req, ...grpc.CallOption) (Res, error)
+
+// invoke runs call against the leader, retrying as MDSClient describes.
+func invoke[Req, Res any](ctx context.Context, m *MDSClient, call mdsCall[Req, Res], req Req, opts []grpc.CallOption) (Res, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.cfg.CallTimeout)
+		defer cancel()
+	}
+	backoff := m.cfg.MinBackoff
+	for {
+		addr, client, err := m.target()
+		if err != nil {
+			var zero Res
+			return zero, err
+		}
+		attemptCtx, cancel := context.WithTimeout(ctx, m.cfg.AttemptTimeout)
+		res, err := call(client, attemptCtx, req, opts...)
+		cancel()
+		if err == nil || ctx.Err() != nil {
+			return res, err
+		}
+		if code := status.Code(err); code != codes.Unavailable && code != codes.DeadlineExceeded {
+			return res, err
+		}
+		if m.redirect(addr, leaderHint(err)) {
+			continue
+		}
+		wait := backoff/2 + rand.N(backoff/2+1)
+		backoff = min(2*backoff, m.cfg.MaxBackoff)
+		select {
+		case <-ctx.Done():
+			return res, err
+		case <-time.After(wait):
+		}
+	}
+}
+
+// leaderHint returns the leader's address named by a member that turned a
+// call away, if any.
+func leaderHint(err error) string {
+	for _, d := range status.Convert(err).Details() {
+		if h, ok := d.(*protogen.LeaderHint); ok {
+			return h.GetLeaderAddress()
+		}
+	}
+	return ""
+}
+
+func (m *MDSClient) Create(ctx context.Context, in *protogen.CreateRequest, opts ...grpc.CallOption) (*protogen.CreateResponse, error) {
+	if in.GetRequestId() == nil {
+		in = gproto.Clone(in).(*protogen.CreateRequest)
+		in.RequestId = m.nextRequestID()
+	}
+	return invoke(ctx, m, protogen.MetadataServiceClient.Create, in, opts)
+}
+
+func (m *MDSClient) Unlink(ctx context.Context, in *protogen.UnlinkRequest, opts ...grpc.CallOption) (*protogen.UnlinkResponse, error) {
+	if in.GetRequestId() == nil {
+		in = gproto.Clone(in).(*protogen.UnlinkRequest)
+		in.RequestId = m.nextRequestID()
+	}
+	return invoke(ctx, m, protogen.MetadataServiceClient.Unlink, in, opts)
+}
+
+func (m *MDSClient) Rename(ctx context.Context, in *protogen.RenameRequest, opts ...grpc.CallOption) (*protogen.RenameResponse, error) {
+	if in.GetRequestId() == nil {
+		in = gproto.Clone(in).(*protogen.RenameRequest)
+		in.RequestId = m.nextRequestID()
+	}
+	return invoke(ctx, m, protogen.MetadataServiceClient.Rename, in, opts)
+}
+
+// SetAttr gets the original response for a retried truncate, so the chunks
+// it cut off are still reported.
+func (m *MDSClient) SetAttr(ctx context.Context, in *protogen.SetAttrRequest, opts ...grpc.CallOption) (*protogen.SetAttrResponse, error) {
+	if in.GetRequestId() == nil {
+		in = gproto.Clone(in).(*protogen.SetAttrRequest)
+		in.RequestId = m.nextRequestID()
+	}
+	return invoke(ctx, m, protogen.MetadataServiceClient.SetAttr, in, opts)
+}
+
+func (m *MDSClient) SetDirLayout(ctx context.Context, in *protogen.SetDirLayoutRequest, opts ...grpc.CallOption) (*protogen.SetDirLayoutResponse, error) {
+	if in.GetRequestId() == nil {
+		in = gproto.Clone(in).(*protogen.SetDirLayoutRequest)
+		in.RequestId = m.nextRequestID()
+	}
+	return invoke(ctx, m, protogen.MetadataServiceClient.SetDirLayout, in, opts)
+}
+
+func (m *MDSClient) Lookup(ctx context.Context, in *protogen.LookupRequest, opts ...grpc.CallOption) (*protogen.LookupResponse, error) {
+	return invoke(ctx, m, protogen.MetadataServiceClient.Lookup, in, opts)
+}
+
+func (m *MDSClient) Stat(ctx context.Context, in *protogen.StatRequest, opts ...grpc.CallOption) (*protogen.StatResponse, error) {
+	return invoke(ctx, m, protogen.MetadataServiceClient.Stat, in, opts)
+}
+
+func (m *MDSClient) ListDir(ctx context.Context, in *protogen.ListDirRequest, opts ...grpc.CallOption) (*protogen.ListDirResponse, error) {
+	return invoke(ctx, m, protogen.MetadataServiceClient.ListDir, in, opts)
+}
+
+func (m *MDSClient) GetDirLayout(ctx context.Context, in *protogen.GetDirLayoutRequest, opts ...grpc.CallOption) (*protogen.GetDirLayoutResponse, error) {
+	return invoke(ctx, m, protogen.MetadataServiceClient.GetDirLayout, in, opts)
+}
+
+func (m *MDSClient) GetShardMap(ctx context.Context, in *protogen.GetShardMapRequest, opts ...grpc.CallOption) (*protogen.GetShardMapResponse, error) {
+	return invoke(ctx, m, protogen.MetadataServiceClient.GetShardMap, in, opts)
+}
+
+func (m *MDSClient) InodesExist(ctx context.Context, in *protogen.InodesExistRequest, opts ...grpc.CallOption) (*protogen.InodesExistResponse, error) {
+	return invoke(ctx, m, protogen.MetadataServiceClient.InodesExist, in, opts)
+}
+
+// RegisterOST, Heartbeat and ListOSTs go to the member the client believes
+// leads. Every member keeps its own OST membership, so OSTs heartbeat each
+// member directly instead.
+func (m *MDSClient) RegisterOST(ctx context.Context, in *protogen.RegisterOSTRequest, opts ...grpc.CallOption) (*protogen.RegisterOSTResponse, error) {
+	return invoke(ctx, m, protogen.MetadataServiceClient.RegisterOST, in, opts)
+}
+
+func (m *MDSClient) Heartbeat(ctx context.Context, in *protogen.HeartbeatRequest, opts ...grpc.CallOption) (*protogen.HeartbeatResponse, error) {
+	return invoke(ctx, m, protogen.MetadataServiceClient.Heartbeat, in, opts)
+}
+
+func (m *MDSClient) ListOSTs(ctx context.Context, in *protogen.ListOSTsRequest, opts ...grpc.CallOption) (*protogen.ListOSTsResponse, error) {
+	return invoke(ctx, m, protogen.MetadataServiceClient.ListOSTs, in, opts)
+}
@@ -0,0 +1,169 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	protogen "github.com/rachanaanugandula/kube-pfs/pkg/proto/gen"
+	"github.com/rachanaanugandula/kube-pfs/pkg/shardmap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	gproto "google.golang.org/protobuf/proto"
+)
+
+// ShardedMDSClient is a MetadataServiceClient for an MDS whose namespace is
+// split across shards. It sends each call to the shard holding the
+// directory or inode the call names, through an MDSClient per shard, and
+// the shard coordinates any change that spans others. Against an MDS that
+// is not sharded it sends every call to that MDS.
+type ShardedMDSClient struct {
+	shards  []*protogen.MDSShard
+	clients map[string]*MDSClient
+	// root is the client of the shard holding the root, or of the only MDS.
+	root *MDSClient
+}
+
+var _ protogen.MetadataServiceClient = (*ShardedMDSClient)(nil)
+
+// DialShardedMDS reads the shard map from the MDS at endpoints, which are
+// dialed as DialMDS does, and connects to every shard in it.
+func DialShardedMDS(endpoints []string, cfg MDSConfig, opts ...grpc.DialOption) (*ShardedMDSClient, error) {
+	first, err := DialMDS(endpoints, cfg, opts...)
+	if err != nil {
+		return nil, err
+	}
+	res, err := first.GetShardMap(context.Background(), &protogen.GetShardMapRequest{})
+	if status.Code(err) == codes.Unimplemented || (err == nil && len(res.GetShards()) == 0) {
+		return &ShardedMDSClient{root: first}, nil
+	}
+	_ = first.Close()
+	if err != nil {
+		return nil, fmt.Errorf("read mds shard map: %w", err)
+	}
+	c := &ShardedMDSClient{shards: res.GetShards(), clients: map[string]*MDSClient{}}
+	for _, sh := range c.shards {
+		m, err := DialMDS([]string{sh.GetAddress()}, cfg, opts...)
+		if err != nil {
+			_ = c.Close()
+			return nil, fmt.Errorf("dial mds shard %s: %w", sh.GetShardId(), err)
+		}
+		c.clients[sh.GetShardId()] = m
+		if c.root == nil {
+			c.root = m
+		}
+	}
+	return c, nil
+}
+
+// Close closes the connections to every shard.
+func (c *ShardedMDSClient) Close() error {
+	if c.clients == nil {
+		return c.root.Close()
+	}
+	var errs []error
+	for _, m := range c.clients {
+		errs = append(errs, m.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// shard returns the client of the shard holding the inode.
+func (c *ShardedMDSClient) shard(inodeID string) *MDSClient {
+	if owner := shardmap.Owner(c.shards, inodeID); owner != nil {
+		return c.clients[owner.GetShardId()]
+	}
+	return c.root
+}
+
+// GetShardMap answers from the map read when the client was dialed.
+func (c *ShardedMDSClient) GetShardMap(context.Context, *protogen.GetShardMapRequest, ...grpc.CallOption) (*protogen.GetShardMapResponse, error) {
+	res := &protogen.GetShardMapResponse{}
+	for _, sh := range c.shards {
+		res.Shards = append(res.Shards, gproto.Clone(sh).(*protogen.MDSShard))
+	}
+	return res, nil
+}
+
+func (c *ShardedMDSClient) Create(ctx context.Context, in *protogen.CreateRequest, opts ...grpc.CallOption) (*protogen.CreateResponse, error) {
+	return c.shard(in.GetParentInodeId()).Create(ctx, in, opts...)
+}
+
+func (c *ShardedMDSClient) Lookup(ctx context.Context, in *protogen.LookupRequest, opts ...grpc.CallOption) (*protogen.LookupResponse, error) {
+	return c.shard(in.GetParentInodeId()).Lookup(ctx, in, opts...)
+}
+
+func (c *ShardedMDSClient) ListDir(ctx context.Context, in *protogen.ListDirRequest, opts ...grpc.CallOption) (*protogen.ListDirResponse, error) {
+	return c.shard(in.GetInodeId()).ListDir(ctx, in, opts...)
+}
+
+func (c *ShardedMDSClient) Unlink(ctx context.Context, in *protogen.UnlinkRequest, opts ...grpc.CallOption) (*protogen.UnlinkResponse, error) {
+	return c.shard(in.GetParentInodeId()).Unlink(ctx, in, opts...)
+}
+
+// Rename goes to the shard holding the source directory.
+func (c *ShardedMDSClient) Rename(ctx context.Context, in *protogen.RenameRequest, opts ...grpc.CallOption) (*protogen.RenameResponse, error) {
+	return c.shard(in.GetSrcParentInodeId()).Rename(ctx, in, opts...)
+}
+
+func (c *ShardedMDSClient) Stat(ctx context.Context, in *protogen.StatRequest, opts ...grpc.CallOption) (*protogen.StatResponse, error) {
+	return c.shard(in.GetInodeId()).Stat(ctx, in, opts...)
+}
+
+func (c *ShardedMDSClient) SetAttr(ctx context.Context, in *protogen.SetAttrRequest, opts ...grpc.CallOption) (*protogen.SetAttrResponse, error) {
+	return c.shard(in.GetInodeId()).SetAttr(ctx, in, opts...)
+}
+
+func (c *ShardedMDSClient) SetDirLayout(ctx context.Context, in *protogen.SetDirLayoutRequest, opts ...grpc.CallOption) (*protogen.SetDirLayoutResponse, error) {
+	return c.shard(in.GetInodeId()).SetDirLayout(ctx, in, opts...)
+}
+
+func (c *ShardedMDSClient) GetDirLayout(ctx context.Context, in *protogen.GetDirLayoutRequest, opts ...grpc.CallOption) (*protogen.GetDirLayoutResponse, error) {
+	return c.shard(in.GetInodeId()).GetDirLayout(ctx, in, opts...)
+}
+
+// InodesExist asks each shard about the inodes it holds and lists those
+// that exist in the order they were asked about.
+func (c *ShardedMDSClient) InodesExist(ctx context.Context, in *protogen.InodesExistRequest, opts ...grpc.CallOption) (*protogen.InodesExistResponse, error) {
+	if c.clients == nil {
+		return c.root.InodesExist(ctx, in, opts...)
+	}
+	byShard := map[*MDSClient][]string{}
+	for _, id := range in.GetInodeIds() {
+		m := c.shard(id)
+		byShard[m] = append(byShard[m], id)
+	}
+	existing := map[string]bool{}
+	for m, ids := range byShard {
+		res, err := m.InodesExist(ctx, &protogen.InodesExistRequest{InodeIds: ids}, opts...)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range res.GetExisting() {
+			existing[id] = true
+		}
+	}
+	res := &protogen.InodesExistResponse{}
+	for _, id := range in.GetInodeIds() {
+		if existing[id] {
+			res.Existing = append(res.Existing, id)
+		}
+	}
+	return res, nil
+}
+
+// RegisterOST, Heartbeat and ListOSTs go to the shard holding the root.
+// Every shard keeps its own OST membership, so OSTs heartbeat each shard
+// directly instead.
+func (c *ShardedMDSClient) RegisterOST(ctx context.Context, in *protogen.RegisterOSTRequest, opts ...grpc.CallOption) (*protogen.RegisterOSTResponse, error) {
+	return c.root.RegisterOST(ctx, in, opts...)
+}
+
+func (c *ShardedMDSClient) Heartbeat(ctx context.Context, in *protogen.HeartbeatRequest, opts ...grpc.CallOption) (*protogen.HeartbeatResponse, error) {
+	return c.root.Heartbeat(ctx, in, opts...)
+}
+
+func (c *ShardedMDSClient) ListOSTs(ctx context.Context, in *protogen.ListOSTsRequest, opts ...grpc.CallOption) (*protogen.ListOSTsResponse, error) {
+	return c.root.ListOSTs(ctx, in, opts...)
+}
@@ -0,0 +1,130 @@
+package erasure
+
+// Arithmetic in GF(2^8) with the reducing polynomial x^8+x^4+x^3+x^2+1
+// (0x11d), the field most Reed-Solomon storage codes use. Addition is XOR.
+
+var (
+	gfExp [512]byte
+	gfLog [256]byte
+	// gfMul[a][b] is a*b. At 64 KiB it turns the per-byte inner loop of
+	// encoding into a single table lookup.
+	gfMul [256][256]byte
+)
+
+func init() {
+	x := 1
+	for i := 0; i < 255; i++ {
+		gfExp[i] = byte(x)
+		gfLog[x] = byte(i)
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11d
+		}
+	}
+	// Doubling the exp table lets mul skip the mod 255.
+	for i := 255; i < len(gfExp); i++ {
+		gfExp[i] = gfExp[i-255]
+	}
+	for a := 1; a < 256; a++ {
+		for b := 1; b < 256; b++ {
+			gfMul[a][b] = gfExp[int(gfLog[a])+int(gfLog[b])]
+		}
+	}
+}
+
+func mul(a, b byte) byte {
+	return gfMul[a][b]
+}
+
+// inv returns the multiplicative inverse of a, which must not be zero.
+func inv(a byte) byte {
+	return gfExp[255-int(gfLog[a])]
+}
+
+// pow returns a**n, with 0**0 = 1.
+func pow(a byte, n int) byte {
+	if n == 0 {
+		return 1
+	}
+	if a == 0 {
+		return 0
+	}
+	return gfExp[(int(gfLog[a])*n)%255]
+}
+
+// mulAdd sets dst[i] ^= c*src[i] for every byte of src.
+func mulAdd(dst, src []byte, c byte) {
+	if c == 0 {
+		return
+	}
+	row := &gfMul[c]
+	for i, b := range src {
+		dst[i] ^= row[b]
+	}
+}
+
+type matrix [][]byte
+
+func newMatrix(rows, cols int) matrix {
+	m := make(matrix, rows)
+	for r := range m {
+		m[r] = make([]byte, cols)
+	}
+	return m
+}
+
+func (m matrix) mul(o matrix) matrix {
+	out := newMatrix(len(m), len(o[0]))
+	for r := range m {
+		for c := range o[0] {
+			var v byte
+			for i := range o {
+				v ^= mul(m[r][i], o[i][c])
+			}
+			out[r][c] = v
+		}
+	}
+	return out
+}
+
+// invert returns the inverse of a square matrix by Gauss-Jordan elimination,
+// or false if it is singular.
+func (m matrix) invert() (matrix, bool) {
+	n := len(m)
+	work := newMatrix(n, 2*n)
+	for r := range m {
+		copy(work[r], m[r])
+		work[r][n+r] = 1
+	}
+	for col := 0; col < n; col++ {
+		pivot := -1
+		for r := col; r < n; r++ {
+			if work[r][col] != 0 {
+				pivot = r
+				break
+			}
+		}
+		if pivot < 0 {
+			return nil, false
+		}
+		work[col], work[pivot] = work[pivot], work[col]
+		scale := inv(work[col][col])
+		for c := range work[col] {
+			work[col][c] = mul(work[col][c], scale)
+		}
+		for r := 0; r < n; r++ {
+			if r == col || work[r][col] == 0 {
+				continue
+			}
+			f := work[r][col]
+			for c := range work[r] {
+				work[r][c] ^= mul(f, work[col][c])
+			}
+		}
+	}
+	out := make(matrix, n)
+	for r := range work {
+		out[r] = work[r][n:]
+	}
+	return out, true
+}
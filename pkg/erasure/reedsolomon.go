@@ -0,0 +1,145 @@
+// Package erasure implements the systematic Reed-Solomon code used by
+// erasure-coded stripe layouts.
+package erasure
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrTooFewShards = errors.New("too few shards to reconstruct")
+
+// Code turns data shards into parity shards such that any data-many of the
+// data+parity shards are enough to rebuild all of them.
+type Code struct {
+	data   int
+	parity int
+	// encode has one row per shard. The top data rows are the identity, so
+	// data shards are stored as-is; the rest produce parity.
+	encode matrix
+}
+
+func New(data, parity int) (*Code, error) {
+	if data < 1 || parity < 0 {
+		return nil, fmt.Errorf("invalid code %d+%d: need at least one data shard", data, parity)
+	}
+	if data+parity > 256 {
+		return nil, fmt.Errorf("invalid code %d+%d: at most 256 shards", data, parity)
+	}
+	// Rows of a Vandermonde matrix at distinct points are independent in any
+	// combination of data rows. Multiplying by the inverse of its top square
+	// keeps that property and makes the code systematic.
+	vm := newMatrix(data+parity, data)
+	for r := range vm {
+		for c := range vm[r] {
+			vm[r][c] = pow(byte(r), c)
+		}
+	}
+	top, ok := vm[:data].invert()
+	if !ok {
+		return nil, fmt.Errorf("invalid code %d+%d: singular matrix", data, parity)
+	}
+	return &Code{data: data, parity: parity, encode: vm.mul(top)}, nil
+}
+
+// Encode computes the parity shards, shards[data:], from the data shards,
+// which must all have the same length. Parity shards are reused when they
+// are long enough and allocated otherwise.
+func (c *Code) Encode(shards [][]byte) error {
+	size, err := c.checkShards(shards, false)
+	if err != nil {
+		return err
+	}
+	for p := c.data; p < len(shards); p++ {
+		shards[p] = c.encodeRow(p, shards[:c.data], shards[p], size)
+	}
+	return nil
+}
+
+// Reconstruct fills in every nil shard from the others. All non-nil shards
+// must have the same length and at least data-many of them are needed.
+func (c *Code) Reconstruct(shards [][]byte) error {
+	size, err := c.checkShards(shards, true)
+	if err != nil {
+		return err
+	}
+	present := make([]int, 0, c.data)
+	for i, s := range shards {
+		if s != nil && len(present) < c.data {
+			present = append(present, i)
+		}
+	}
+	if len(present) < c.data {
+		return fmt.Errorf("%w: have %d of %d", ErrTooFewShards, len(present), c.data)
+	}
+
+	// The rows of the present shards map data to those shards; their inverse
+	// maps the shards back to data.
+	sub := make(matrix, c.data)
+	inputs := make([][]byte, c.data)
+	for i, idx := range present {
+		sub[i] = c.encode[idx]
+		inputs[i] = shards[idx]
+	}
+	decode, ok := sub.invert()
+	if !ok {
+		return errors.New("reconstruct: singular decode matrix")
+	}
+	for d := 0; d < c.data; d++ {
+		if shards[d] != nil {
+			continue
+		}
+		out := make([]byte, size)
+		for i, in := range inputs {
+			mulAdd(out, in, decode[d][i])
+		}
+		shards[d] = out
+	}
+	for p := c.data; p < len(shards); p++ {
+		if shards[p] == nil {
+			shards[p] = c.encodeRow(p, shards[:c.data], nil, size)
+		}
+	}
+	return nil
+}
+
+func (c *Code) encodeRow(row int, data [][]byte, out []byte, size int) []byte {
+	if cap(out) < size {
+		out = make([]byte, size)
+	}
+	out = out[:size]
+	clear(out)
+	for d, in := range data {
+		mulAdd(out, in, c.encode[row][d])
+	}
+	return out
+}
+
+// checkShards returns the common shard length. Missing shards are only
+// allowed when reconstructing; parity shards are never checked for Encode.
+func (c *Code) checkShards(shards [][]byte, allowMissing bool) (int, error) {
+	if len(shards) != c.data+c.parity {
+		return 0, fmt.Errorf("got %d shards, want %d", len(shards), c.data+c.parity)
+	}
+	check := shards
+	if !allowMissing {
+		check = shards[:c.data]
+	}
+	size := -1
+	for i, s := range check {
+		if s == nil {
+			if allowMissing {
+				continue
+			}
+			return 0, fmt.Errorf("data shard %d is missing", i)
+		}
+		if size >= 0 && len(s) != size {
+			return 0, fmt.Errorf("shard %d has %d bytes, want %d", i, len(s), size)
+		}
+		size = len(s)
+	}
+	if size < 0 {
+		return 0, fmt.Errorf("%w: have 0 of %d", ErrTooFewShards, c.data)
+	}
+	return size, nil
+}
@@ -0,0 +1,158 @@
+package layout
+
+import (
+	protogen "github.com/rachanaanugandula/kube-pfs/pkg/proto/gen"
+)
+
+// ChunkCount returns how many stripe chunks are needed to hold size bytes.
+func ChunkCount(l *protogen.StripeLayout, size uint64) uint64 {
+	stripe := uint64(l.GetStripeSizeBytes())
+	if stripe == 0 || size == 0 {
+		return 0
+	}
+	return (size + stripe - 1) / stripe
+}
+
+// OSTForChunk returns the OST that stores chunkID. Chunks are placed
+// round-robin over the layout's OST list, starting at index 0.
+func OSTForChunk(l *protogen.StripeLayout, chunkID uint64) string {
+	ostIDs := l.GetOstIds()
+	if len(ostIDs) == 0 {
+		return ""
+	}
+	return ostIDs[chunkID%uint64(len(ostIDs))]
+}
+
+// ReplicaCount returns how many copies of each chunk the layout keeps,
+// capped at the number of OSTs so every copy lands on a different one.
+func ReplicaCount(l *protogen.StripeLayout) int {
+	n := int(l.GetReplicaCount())
+	if n < 1 {
+		n = 1
+	}
+	if n > len(l.GetOstIds()) {
+		n = len(l.GetOstIds())
+	}
+	return n
+}
+
+// OSTsForChunk returns the distinct OSTs holding chunkID, primary first.
+// Replica r sits r places after the primary in the layout's OST list.
+func OSTsForChunk(l *protogen.StripeLayout, chunkID uint64) []string {
+	ostIDs := l.GetOstIds()
+	replicas := ReplicaCount(l)
+	out := make([]string, 0, replicas)
+	for r := 0; r < replicas; r++ {
+		out = append(out, ostIDs[(chunkID+uint64(r))%uint64(len(ostIDs))])
+	}
+	return out
+}
+
+// BlockRef builds the OST address of the primary copy of one chunk.
+func BlockRef(fileID string, l *protogen.StripeLayout, chunkID uint64) *protogen.BlockRef {
+	return &protogen.BlockRef{FileId: fileID, ChunkId: chunkID, OstId: OSTForChunk(l, chunkID)}
+}
+
+// ReplicaRefs builds the OST address of every copy of one chunk.
+func ReplicaRefs(fileID string, l *protogen.StripeLayout, chunkID uint64) []*protogen.BlockRef {
+	ostIDs := OSTsForChunk(l, chunkID)
+	refs := make([]*protogen.BlockRef, 0, len(ostIDs))
+	for _, ostID := range ostIDs {
+		refs = append(refs, &protogen.BlockRef{FileId: fileID, ChunkId: chunkID, OstId: ostID})
+	}
+	return refs
+}
+
+// IsErasureCoded reports whether the layout protects chunks with parity
+// instead of replicas.
+func IsErasureCoded(l *protogen.StripeLayout) bool {
+	return l.GetErasureCode().GetDataChunks() > 0
+}
+
+// GroupBytes returns how many bytes of file data one erasure-coded stripe
+// group holds.
+func GroupBytes(l *protogen.StripeLayout) uint64 {
+	return uint64(l.GetErasureCode().GetDataChunks()) * uint64(l.GetStripeSizeBytes())
+}
+
+// GroupCount returns how many erasure-coded stripe groups are needed to hold
+// size bytes.
+func GroupCount(l *protogen.StripeLayout, size uint64) uint64 {
+	group := GroupBytes(l)
+	if group == 0 || size == 0 {
+		return 0
+	}
+	return (size + group - 1) / group
+}
+
+// GroupRefs builds the OST address of every cell of one erasure-coded stripe
+// group, data cells first. Consecutive cells sit on consecutive OSTs, so the
+// cells of a group are on distinct OSTs as long as the layout has at least
+// data+parity of them.
+func GroupRefs(fileID string, l *protogen.StripeLayout, group uint64) []*protogen.BlockRef {
+	ostIDs := l.GetOstIds()
+	cells := uint64(l.GetErasureCode().GetDataChunks() + l.GetErasureCode().GetParityChunks())
+	if len(ostIDs) == 0 {
+		return nil
+	}
+	refs := make([]*protogen.BlockRef, 0, cells)
+	for j := uint64(0); j < cells; j++ {
+		refs = append(refs, &protogen.BlockRef{
+			FileId:  fileID,
+			ChunkId: group*cells + j,
+			OstId:   ostIDs[(group+j)%uint64(len(ostIDs))],
+		})
+	}
+	return refs
+}
+
+// TruncatedBlocks lists every copy of the chunks that lie entirely past
+// newSize but were part of a file of oldSize bytes. For erasure-coded layouts
+// it lists every cell of the stripe groups past newSize instead.
+func TruncatedBlocks(fileID string, l *protogen.StripeLayout, oldSize, newSize uint64) []*protogen.BlockRef {
+	if IsErasureCoded(l) {
+		var refs []*protogen.BlockRef
+		for group := GroupCount(l, newSize); group < GroupCount(l, oldSize); group++ {
+			refs = append(refs, GroupRefs(fileID, l, group)...)
+		}
+		return refs
+	}
+	from, to := ChunkCount(l, newSize), ChunkCount(l, oldSize)
+	if from >= to {
+		return nil
+	}
+	refs := make([]*protogen.BlockRef, 0, (to-from)*uint64(ReplicaCount(l)))
+	for chunkID := from; chunkID < to; chunkID++ {
+		refs = append(refs, ReplicaRefs(fileID, l, chunkID)...)
+	}
+	return refs
+}
+
+// Extent is the slice of a byte range that falls inside a single chunk.
+type Extent struct {
+	ChunkID     uint64
+	ChunkOffset uint64
+	BufOffset   uint64
+	Length      uint64
+}
+
+// Extents splits the file range [off, off+length) into per-chunk pieces in
+// ascending chunk order.
+func Extents(l *protogen.StripeLayout, off, length uint64) []Extent {
+	stripe := uint64(l.GetStripeSizeBytes())
+	if stripe == 0 || length == 0 {
+		return nil
+	}
+	extents := make([]Extent, 0, (length+stripe-1)/stripe+1)
+	for done := uint64(0); done < length; {
+		pos := off + done
+		chunkOff := pos % stripe
+		n := stripe - chunkOff
+		if n > length-done {
+			n = length - done
+		}
+		extents = append(extents, Extent{ChunkID: pos / stripe, ChunkOffset: chunkOff, BufOffset: done, Length: n})
+		done += n
+	}
+	return extents
+}
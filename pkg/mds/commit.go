@@ -0,0 +1,202 @@
+package mds
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rachanaanugandula/kube-pfs/pkg/metrics"
+	"go.etcd.io/bbolt"
+)
+
+const (
+	defaultCommitMaxBatch = 256
+	defaultCommitMaxDelay = time.Millisecond
+)
+
+var errCommitterClosed = errors.New("metadata committer is closed")
+
+// replicationError is a batch raft did not confirm. Its writes may still be
+// applied under a new leader, so they are not retried one by one.
+type replicationError struct {
+	err error
+}
+
+func (e *replicationError) Error() string { return e.err.Error() }
+func (e *replicationError) Unwrap() error { return e.err }
+
+// committer group-commits metadata mutations: every bolt transaction ends in
+// an fsync, so handlers hand their writes to one goroutine that applies all
+// of them that are waiting, up to maxBatch, in a single transaction. Writes
+// that queue up while a batch is being synced make up the next one. The
+// goroutine never waits for writes that have not been handed to it yet, so
+// a write on its own, such as each one in a directory whose lock serialises
+// them, commits at once; maxDelay only bounds the wait for writes already
+// on their way. A handler's commit returns once its batch is on disk.
+//
+// A batch is rolled back as a whole when one of its writes fails, and its
+// writes are then retried in transactions of their own so only the failing
+// one reports an error. Writes must therefore be safe to run twice.
+//
+// With a journal, the changes of a batch are appended to it and synced
+// before bolt commits them, and the cache is only updated after that.
+//
+// In a replicated MDS the transaction only collects the changes: replicate
+// hands them to raft, and commit returns once they are applied locally.
+type committer struct {
+	db        *bbolt.DB
+	journal   *journal
+	replicate func([]*journalRecord) error
+	maxBatch  int
+	maxDelay  time.Duration
+
+	// pending counts the writes handed to commit that run has not received
+	// yet.
+	pending atomic.Int64
+	reqs    chan commitRequest
+	stop    chan struct{}
+	wg      sync.WaitGroup
+	once    sync.Once
+}
+
+type commitRequest struct {
+	op   string
+	fn   func(*metaTx) error
+	done chan error
+}
+
+// newCommitter starts the pipeline. The journal and replicate may be nil.
+func newCommitter(db *bbolt.DB, j *journal, replicate func([]*journalRecord) error, maxBatch int, maxDelay time.Duration) *committer {
+	c := &committer{
+		db:        db,
+		journal:   j,
+		replicate: replicate,
+		maxBatch:  maxBatch,
+		maxDelay:  maxDelay,
+		reqs:      make(chan commitRequest),
+		stop:      make(chan struct{}),
+	}
+	c.wg.Add(1)
+	go c.run()
+	return c
+}
+
+// commit runs fn in a write transaction shared with other handlers and
+// returns once that transaction is durable. op names the operation in the
+// journal.
+func (c *committer) commit(op string, fn func(*metaTx) error) error {
+	req := commitRequest{op: op, fn: fn, done: make(chan error, 1)}
+	c.pending.Add(1)
+	select {
+	case c.reqs <- req:
+	case <-c.stop:
+		c.pending.Add(-1)
+		return errCommitterClosed
+	}
+	return <-req.done
+}
+
+// close stops the pipeline after the batch in flight, if any, has committed.
+func (c *committer) close() {
+	c.once.Do(func() { close(c.stop) })
+	c.wg.Wait()
+}
+
+func (c *committer) run() {
+	defer c.wg.Done()
+	for {
+		var first commitRequest
+		select {
+		case first = <-c.reqs:
+			c.pending.Add(-1)
+		case <-c.stop:
+			return
+		}
+		c.apply(c.collect(first))
+	}
+}
+
+// collect gathers the requests already handed to commit, waiting at most
+// maxDelay after the first for those not yet received.
+func (c *committer) collect(first commitRequest) []commitRequest {
+	batch := []commitRequest{first}
+	var timeout <-chan time.Time
+	if c.maxDelay > 0 {
+		timer := time.NewTimer(c.maxDelay)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	for len(batch) < c.maxBatch {
+		select {
+		case req := <-c.reqs:
+			c.pending.Add(-1)
+			batch = append(batch, req)
+			continue
+		default:
+		}
+		if timeout == nil || c.pending.Load() == 0 {
+			return batch
+		}
+		select {
+		case req := <-c.reqs:
+			c.pending.Add(-1)
+			batch = append(batch, req)
+		case <-timeout:
+			return batch
+		}
+	}
+	return batch
+}
+
+func (c *committer) apply(batch []commitRequest) {
+	start := time.Now()
+	err := c.write(batch)
+	metrics.ObserveMDSCommit(len(batch), time.Since(start))
+	var rerr *replicationError
+	if err == nil || len(batch) == 1 || errors.As(err, &rerr) {
+		for _, req := range batch {
+			req.done <- err
+		}
+		return
+	}
+	for _, req := range batch {
+		c.apply([]commitRequest{req})
+	}
+}
+
+func (c *committer) write(batch []commitRequest) error {
+	tx, err := c.db.Begin(true)
+	if err != nil {
+		return err
+	}
+	recs := make([]*journalRecord, len(batch))
+	for i, req := range batch {
+		recs[i] = &journalRecord{Op: req.op}
+		if err := req.fn(&metaTx{tx: tx, rec: recs[i]}); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if c.replicate != nil {
+		tx.Rollback()
+		if err := c.replicate(recs); err != nil {
+			return &replicationError{err: err}
+		}
+		return nil
+	}
+	if c.journal == nil {
+		return tx.Commit()
+	}
+	seqs, err := c.journal.log(tx, recs)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("journal: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		c.journal.abort(seqs)
+		return err
+	}
+	return nil
+}
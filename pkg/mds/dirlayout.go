@@ -0,0 +1,258 @@
+package mds
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"time"
+
+	protogen "github.com/rachanaanugandula/kube-pfs/pkg/proto/gen"
+	"go.etcd.io/bbolt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	gproto "google.golang.org/protobuf/proto"
+)
+
+// metaDirLayouts marks a database whose directory layouts were written by
+// SetDirLayout and Create, rather than being the record of where a
+// directory's files went at the time.
+const metaDirLayouts = "dir_layouts"
+
+// SetDirLayout replaces the layout a directory hands down to new entries. A
+// nil layout goes back to the MDS defaults.
+func (s *Service) SetDirLayout(ctx context.Context, req *protogen.SetDirLayoutRequest) (*protogen.SetDirLayoutResponse, error) {
+	if err := checkRequestID(req.GetRequestId()); err != nil {
+		return nil, err
+	}
+	if err := s.checkLeader(); err != nil {
+		return nil, err
+	}
+	if err := s.checkHolds(req.GetInodeId()); err != nil {
+		return nil, err
+	}
+	unlock, err := s.locks.lock(ctx, "setdirlayout", true, req.GetInodeId())
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if res, err := cachedReply(s, "setdirlayout", req.GetRequestId(), &protogen.SetDirLayoutResponse{}); res != nil || err != nil {
+		return res, err
+	}
+
+	inode, err := s.inode(req.GetInodeId())
+	if err != nil {
+		return nil, metadataError(err)
+	}
+	if inode == nil {
+		return nil, status.Error(codes.NotFound, "inode not found")
+	}
+	if !inode.GetIsDir() {
+		return nil, status.Error(codes.FailedPrecondition, "inode is not a directory")
+	}
+	l := &protogen.StripeLayout{}
+	if req.GetLayout() != nil {
+		l = gproto.Clone(req.GetLayout()).(*protogen.StripeLayout)
+	}
+	if err := s.checkLayout(l); err != nil {
+		return nil, err
+	}
+
+	updated := cloneInode(inode)
+	updated.StripeLayout = l
+	updated.ModifiedUnix = time.Now().Unix()
+	res := &protogen.SetDirLayoutResponse{Inode: cloneInode(updated)}
+	if err := s.persistInode("setdirlayout", updated, req.GetRequestId(), res); err != nil {
+		return nil, persistError("dir layout", err)
+	}
+	s.inodeCache.put(updated.GetInodeId(), updated)
+	return res, nil
+}
+
+func (s *Service) GetDirLayout(ctx context.Context, req *protogen.GetDirLayoutRequest) (*protogen.GetDirLayoutResponse, error) {
+	if err := s.linearize(ctx); err != nil {
+		return nil, err
+	}
+	if err := s.checkHolds(req.GetInodeId()); err != nil {
+		return nil, err
+	}
+	unlock, err := s.locks.lock(ctx, "getdirlayout", false, req.GetInodeId())
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	inode, err := s.inode(req.GetInodeId())
+	if err != nil {
+		return nil, metadataError(err)
+	}
+	if inode == nil {
+		return nil, status.Error(codes.NotFound, "inode not found")
+	}
+	if !inode.GetIsDir() {
+		return nil, status.Error(codes.FailedPrecondition, "inode is not a directory")
+	}
+	l, _ := gproto.Clone(inode.GetStripeLayout()).(*protogen.StripeLayout)
+	if l == nil {
+		l = &protogen.StripeLayout{}
+	}
+	return &protogen.GetDirLayoutResponse{Layout: l}, nil
+}
+
+// inheritLayout returns the directory layout parent hands down with the
+// non-zero fields of override on top. Choosing replicas drops an inherited
+// erasure code and the other way round, so a subtree can switch schemes;
+// choosing a pool likewise drops an inherited ost_ids list.
+func inheritLayout(parent, override *protogen.StripeLayout) *protogen.StripeLayout {
+	l := &protogen.StripeLayout{
+		StripeSizeBytes: cmp.Or(override.GetStripeSizeBytes(), parent.GetStripeSizeBytes()),
+		StripeCount:     cmp.Or(override.GetStripeCount(), parent.GetStripeCount()),
+		Pool:            cmp.Or(override.GetPool(), parent.GetPool()),
+		OstIds:          slices.Clone(parent.GetOstIds()),
+		ReplicaCount:    parent.GetReplicaCount(),
+	}
+	if ec := parent.GetErasureCode(); ec != nil {
+		l.ErasureCode = gproto.Clone(ec).(*protogen.ErasureCode)
+	}
+	switch {
+	case len(override.GetOstIds()) > 0:
+		l.OstIds = slices.Clone(override.GetOstIds())
+	case override.GetPool() != "":
+		// The inherited OST list belongs to the old pool.
+		l.OstIds = nil
+	}
+	switch {
+	case override.GetErasureCode() != nil:
+		l.ErasureCode = gproto.Clone(override.GetErasureCode()).(*protogen.ErasureCode)
+		l.ReplicaCount = override.GetReplicaCount()
+	case override.GetReplicaCount() != 0:
+		l.ErasureCode = nil
+		l.ReplicaCount = override.GetReplicaCount()
+	}
+	return l
+}
+
+// checkLayout validates a directory layout against the OSTs the MDS knows.
+// Whether enough of them are up is only checked when a file is created.
+func (s *Service) checkLayout(l *protogen.StripeLayout) error {
+	if l.GetPool() != "" && !s.osts.hasPool(l.GetPool()) {
+		return status.Errorf(codes.InvalidArgument, "pool %q is not defined on the mds", l.GetPool())
+	}
+	known := s.osts.members(l.GetPool())
+	pool := len(known)
+	if len(l.GetOstIds()) > 0 {
+		seen := map[string]bool{}
+		for _, id := range l.GetOstIds() {
+			if !slices.Contains(known, id) {
+				if l.GetPool() != "" {
+					return status.Errorf(codes.InvalidArgument, "ost %q in layout is not in pool %q", id, l.GetPool())
+				}
+				return status.Errorf(codes.InvalidArgument, "unknown ost %q in layout", id)
+			}
+			if seen[id] {
+				return status.Errorf(codes.InvalidArgument, "ost %q listed twice in layout", id)
+			}
+			seen[id] = true
+		}
+		pool = len(l.GetOstIds())
+	}
+	if int(l.GetStripeCount()) > pool {
+		return status.Errorf(codes.InvalidArgument, "stripe_count %d exceeds the %d OSTs available", l.GetStripeCount(), pool)
+	}
+	if int(l.GetReplicaCount()) > pool {
+		return status.Errorf(codes.InvalidArgument, "replica_count %d exceeds the %d OSTs available", l.GetReplicaCount(), pool)
+	}
+	ec := l.GetErasureCode()
+	if ec == nil {
+		return nil
+	}
+	if l.GetReplicaCount() > 1 {
+		return status.Error(codes.InvalidArgument, "erasure_code and replica_count cannot be combined")
+	}
+	if ec.GetDataChunks() < 1 || ec.GetParityChunks() < 1 {
+		return status.Error(codes.InvalidArgument, "erasure_code needs at least one data and one parity chunk")
+	}
+	if cells := uint64(ec.GetDataChunks()) + uint64(ec.GetParityChunks()); cells > uint64(pool) {
+		return status.Errorf(codes.InvalidArgument, "erasure_code %d+%d needs %d OSTs, have %d", ec.GetDataChunks(), ec.GetParityChunks(), cells, pool)
+	}
+	return nil
+}
+
+// nextStripeLayout resolves the layout a directory hands down into the
+// layout of a new file: stripe_count of the OSTs that are up and in the
+// directory's pool and ost_ids, or all of them, in the order the placement
+// policy picks.
+func (s *Service) nextStripeLayout(dir *protogen.StripeLayout) (*protogen.StripeLayout, error) {
+	candidates := slices.DeleteFunc(s.osts.up(time.Now()), func(o *protogen.OSTInfo) bool {
+		if dir.GetPool() != "" && o.GetPool() != dir.GetPool() {
+			return true
+		}
+		return len(dir.GetOstIds()) > 0 && !slices.Contains(dir.GetOstIds(), o.GetOstId())
+	})
+
+	l := &protogen.StripeLayout{StripeSizeBytes: cmp.Or(dir.GetStripeSizeBytes(), s.stripeSz), Pool: dir.GetPool()}
+	var need int
+	if ec := dir.GetErasureCode(); ec != nil {
+		l.ErasureCode = gproto.Clone(ec).(*protogen.ErasureCode)
+		need = int(ec.GetDataChunks() + ec.GetParityChunks())
+	} else {
+		l.ReplicaCount = cmp.Or(dir.GetReplicaCount(), s.replicas)
+		need = int(l.GetReplicaCount())
+	}
+	if len(candidates) < need {
+		if dir.GetPool() != "" {
+			return nil, status.Errorf(codes.Unavailable, "layout needs %d OSTs, only %d up in pool %q", need, len(candidates), dir.GetPool())
+		}
+		return nil, status.Errorf(codes.Unavailable, "layout needs %d OSTs, only %d up", need, len(candidates))
+	}
+
+	count := len(candidates)
+	if c := int(dir.GetStripeCount()); c > 0 {
+		count = max(min(c, count), need)
+	}
+	l.OstIds = s.placement.Order(candidates)[:count]
+	l.StripeCount = uint32(count)
+	return l, nil
+}
+
+// migrateDirLayouts resets the layouts of directories created before
+// directories handed layouts down. Those recorded the stripe size, replica
+// count and every OST configured when the directory was made, and were
+// never read; taken as directory layouts they would keep every file below
+// on the OSTs of that day. Only their erasure code was inherited, so that
+// is all they keep. The scan only runs once per database.
+func migrateDirLayouts(tx *bbolt.Tx) error {
+	metaB := tx.Bucket([]byte(bucketMeta))
+	inodesB := tx.Bucket([]byte(bucketInodes))
+	if metaB == nil || inodesB == nil {
+		return errors.New("metadata buckets are missing")
+	}
+	if metaB.Get([]byte(metaDirLayouts)) != nil {
+		return nil
+	}
+	// Bolt forbids writes while iterating, so reset inodes are put after.
+	var legacy []*protogen.Inode
+	if err := inodesB.ForEach(func(k, v []byte) error {
+		inode := &protogen.Inode{}
+		if err := gproto.Unmarshal(v, inode); err != nil {
+			return err
+		}
+		if l := inode.GetStripeLayout(); inode.GetIsDir() && l.GetPool() == "" && l.GetStripeCount() == 0 && len(l.GetOstIds()) > 0 {
+			inode.StripeLayout = &protogen.StripeLayout{ErasureCode: l.GetErasureCode()}
+			legacy = append(legacy, inode)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	for _, inode := range legacy {
+		if err := putInode(inodesB, inode); err != nil {
+			return err
+		}
+	}
+	if len(legacy) > 0 {
+		log.Printf("mds reset the layouts of %d directories created before directory layouts", len(legacy))
+	}
+	return putUint64(metaB, metaDirLayouts, 1)
+}
@@ -0,0 +1,202 @@
+package mds
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/rachanaanugandula/kube-pfs/pkg/layout"
+	"github.com/rachanaanugandula/kube-pfs/pkg/metrics"
+	protogen "github.com/rachanaanugandula/kube-pfs/pkg/proto/gen"
+	"go.etcd.io/bbolt"
+	gproto "google.golang.org/protobuf/proto"
+)
+
+const (
+	defaultGCInterval   = 30 * time.Second
+	defaultGCMaxBackoff = 10 * time.Minute
+	gcDeleteTimeout     = 5 * time.Second
+)
+
+type GCConfig struct {
+	// Interval is the pause between passes over the pending deletes.
+	Interval time.Duration
+	// MaxBackoff caps how long a file whose chunks failed to delete waits
+	// before it is retried. The wait starts at Interval and doubles.
+	MaxBackoff time.Duration
+}
+
+// GarbageCollector deletes the chunks of unlinked files from the OSTs. Unlink
+// and rename-over queue files in the pending_delete bucket; a file leaves it
+// once every copy of every chunk is gone.
+type GarbageCollector struct {
+	svc  *Service
+	dial OSTDialer
+	cfg  GCConfig
+
+	clients map[string]protogen.ObjectStorageServiceClient
+	retries map[string]gcRetry
+}
+
+type gcRetry struct {
+	failures int
+	next     time.Time
+}
+
+func NewGarbageCollector(svc *Service, dial OSTDialer, cfg GCConfig) *GarbageCollector {
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultGCInterval
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = defaultGCMaxBackoff
+	}
+	return &GarbageCollector{
+		svc:     svc,
+		dial:    dial,
+		cfg:     cfg,
+		clients: map[string]protogen.ObjectStorageServiceClient{},
+		retries: map[string]gcRetry{},
+	}
+}
+
+// Run collects until ctx is cancelled.
+func (g *GarbageCollector) Run(ctx context.Context) {
+	for {
+		if err := g.CollectOnce(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("mds gc: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(g.cfg.Interval):
+		}
+	}
+}
+
+// CollectOnce deletes the chunks of every pending file that is not backing
+// off after a failure. Chunks are listed from the size the MDS last recorded
+// for the file, and chunks that were never written are skipped by the OSTs.
+// Only the leader of a replicated MDS collects.
+func (g *GarbageCollector) CollectOnce(ctx context.Context) error {
+	if g.svc.standby() {
+		return nil
+	}
+	pending, err := g.svc.pendingDeletes()
+	if err != nil {
+		return fmt.Errorf("list pending deletes: %w", err)
+	}
+	addrs := map[string]string{}
+	for _, info := range g.svc.osts.list(time.Now()) {
+		if info.GetUp() && info.GetAddress() != "" {
+			addrs[info.GetOstId()] = info.GetAddress()
+		}
+	}
+
+	var errs []error
+	backlogInodes, backlogBlocks := 0, 0
+	now := time.Now()
+	for _, inode := range pending {
+		refs := layout.TruncatedBlocks(inode.GetInodeId(), inode.GetStripeLayout(), inode.GetSizeBytes(), 0)
+		if r, ok := g.retries[inode.GetInodeId()]; ok && now.Before(r.next) {
+			backlogInodes++
+			backlogBlocks += len(refs)
+			continue
+		}
+		deleted, err := g.deleteBlocks(ctx, addrs, refs)
+		metrics.AddGCBlocksDeleted(deleted)
+		if err == nil {
+			err = g.svc.finishPendingDelete(inode.GetInodeId())
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("collect %s: %w", inode.GetInodeId(), err))
+			r := g.retries[inode.GetInodeId()]
+			r.failures++
+			r.next = now.Add(min(g.cfg.Interval<<min(r.failures-1, 16), g.cfg.MaxBackoff))
+			g.retries[inode.GetInodeId()] = r
+			backlogInodes++
+			backlogBlocks += len(refs)
+			continue
+		}
+		delete(g.retries, inode.GetInodeId())
+	}
+	metrics.SetGCBacklog(backlogInodes, backlogBlocks)
+	return errors.Join(errs...)
+}
+
+// deleteBlocks deletes every ref it can and reports how many blocks were
+// removed. Deleting a block that is already gone counts as success, so a
+// retried file only fails again on the OSTs that are still unreachable.
+func (g *GarbageCollector) deleteBlocks(ctx context.Context, addrs map[string]string, refs []*protogen.BlockRef) (int, error) {
+	deleted := 0
+	var errs []error
+	for _, ref := range refs {
+		client, err := g.client(addrs, ref.GetOstId())
+		if err == nil {
+			var res *protogen.DeleteBlockResponse
+			res, err = g.delete(ctx, client, ref)
+			if res.GetDeleted() {
+				deleted++
+			}
+		}
+		if err != nil {
+			metrics.IncGCErrors()
+			errs = append(errs, fmt.Errorf("delete chunk %d on %s: %w", ref.GetChunkId(), ref.GetOstId(), err))
+		}
+	}
+	return deleted, errors.Join(errs...)
+}
+
+func (g *GarbageCollector) client(addrs map[string]string, ostID string) (protogen.ObjectStorageServiceClient, error) {
+	address, ok := addrs[ostID]
+	if !ok {
+		return nil, errors.New("ost is down or has not registered an address")
+	}
+	client, ok := g.clients[address]
+	if !ok {
+		var err error
+		if client, err = g.dial(address); err != nil {
+			return nil, err
+		}
+		g.clients[address] = client
+	}
+	return client, nil
+}
+
+func (g *GarbageCollector) delete(ctx context.Context, client protogen.ObjectStorageServiceClient, ref *protogen.BlockRef) (*protogen.DeleteBlockResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, gcDeleteTimeout)
+	defer cancel()
+	return client.DeleteBlock(ctx, &protogen.DeleteBlockRequest{Block: ref})
+}
+
+// pendingDeletes returns the unlinked files whose chunks may still be on the
+// OSTs.
+func (s *Service) pendingDeletes() ([]*protogen.Inode, error) {
+	var out []*protogen.Inode
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		pendingB := tx.Bucket([]byte(bucketPendingDelete))
+		if pendingB == nil {
+			return errors.New("pending delete bucket is missing")
+		}
+		return pendingB.ForEach(func(_, v []byte) error {
+			inode := &protogen.Inode{}
+			if err := gproto.Unmarshal(v, inode); err != nil {
+				return err
+			}
+			out = append(out, inode)
+			return nil
+		})
+	})
+	return out, err
+}
+
+func (s *Service) finishPendingDelete(inodeID string) error {
+	return s.commits.commit("gc", func(tx *metaTx) error {
+		pendingB := tx.Bucket(bucketPendingDelete)
+		if pendingB == nil {
+			return errors.New("pending delete bucket is missing")
+		}
+		return pendingB.Delete([]byte(inodeID))
+	})
+}
@@ -0,0 +1,104 @@
+package mds
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	protogen "github.com/rachanaanugandula/kube-pfs/pkg/proto/gen"
+)
+
+const (
+	defaultHealthPollInterval = 10 * time.Second
+	healthPollTimeout         = 2 * time.Second
+)
+
+// OSTDialer returns a client for the OST registered at address.
+type OSTDialer func(address string) (protogen.ObjectStorageServiceClient, error)
+
+type HealthPollConfig struct {
+	// Interval is the pause between polls of every OST.
+	Interval time.Duration
+}
+
+// HealthPoller samples GetHealth on every registered OST and turns the
+// latency counters into the recent latency LatencyPlacement orders by.
+type HealthPoller struct {
+	svc  *Service
+	dial OSTDialer
+	cfg  HealthPollConfig
+
+	clients map[string]protogen.ObjectStorageServiceClient
+	last    map[string]*protogen.HealthResponse
+}
+
+func NewHealthPoller(svc *Service, dial OSTDialer, cfg HealthPollConfig) *HealthPoller {
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultHealthPollInterval
+	}
+	return &HealthPoller{
+		svc:     svc,
+		dial:    dial,
+		cfg:     cfg,
+		clients: map[string]protogen.ObjectStorageServiceClient{},
+		last:    map[string]*protogen.HealthResponse{},
+	}
+}
+
+// Run polls until ctx is cancelled.
+func (p *HealthPoller) Run(ctx context.Context) {
+	for {
+		if err := p.PollOnce(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("mds health poll: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(p.cfg.Interval):
+		}
+	}
+}
+
+// PollOnce samples every OST that is up and has registered an address. The
+// first sample of an OST only sets a baseline; latency is recorded from the
+// second on, and only when the OST served operations in between.
+func (p *HealthPoller) PollOnce(ctx context.Context) error {
+	var errs []error
+	for _, info := range p.svc.osts.up(time.Now()) {
+		if info.GetAddress() == "" {
+			continue
+		}
+		health, err := p.sample(ctx, info.GetAddress())
+		if err != nil {
+			errs = append(errs, fmt.Errorf("get health of %s at %s: %w", info.GetOstId(), info.GetAddress(), err))
+			continue
+		}
+		prev := p.last[info.GetOstId()]
+		p.last[info.GetOstId()] = health
+		if prev == nil || health.GetIopsTotal() <= prev.GetIopsTotal() || health.GetLatencyTotalNs() < prev.GetLatencyTotalNs() {
+			// No traffic since the last sample, or the OST restarted and its
+			// counters went back to zero.
+			continue
+		}
+		ops := health.GetIopsTotal() - prev.GetIopsTotal()
+		latency := time.Duration((health.GetLatencyTotalNs() - prev.GetLatencyTotalNs()) / ops)
+		p.svc.osts.recordLatency(info.GetOstId(), latency)
+	}
+	return errors.Join(errs...)
+}
+
+func (p *HealthPoller) sample(ctx context.Context, address string) (*protogen.HealthResponse, error) {
+	client, ok := p.clients[address]
+	if !ok {
+		var err error
+		if client, err = p.dial(address); err != nil {
+			return nil, err
+		}
+		p.clients[address] = client
+	}
+	ctx, cancel := context.WithTimeout(ctx, healthPollTimeout)
+	defer cancel()
+	return client.GetHealth(ctx, &protogen.HealthRequest{})
+}
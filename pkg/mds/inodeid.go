@@ -0,0 +1,130 @@
+package mds
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	protogen "github.com/rachanaanugandula/kube-pfs/pkg/proto/gen"
+	"go.etcd.io/bbolt"
+	gproto "google.golang.org/protobuf/proto"
+)
+
+const (
+	bucketMeta     = "meta"
+	metaNextIno    = "next_ino"
+	metaGeneration = "generation"
+
+	rootIno     = 1
+	inodePrefix = "inode-"
+)
+
+func inodeIDFor(ino uint64) string {
+	return inodePrefix + strconv.FormatUint(ino, 10)
+}
+
+// legacyIno recovers the number of an inode created before inode numbers
+// were stored. Those IDs were inode-<unix nanos>, so their numbers are far
+// above anything the counter has handed out.
+func legacyIno(inodeID string) (uint64, bool) {
+	if inodeID == rootInodeID {
+		return rootIno, true
+	}
+	n, err := strconv.ParseUint(strings.TrimPrefix(inodeID, inodePrefix), 10, 64)
+	if err != nil || !strings.HasPrefix(inodeID, inodePrefix) {
+		return 0, false
+	}
+	return n, true
+}
+
+// loadInodeCounters reads the inode counter and starts a new generation.
+// The generation is an epoch for every inode this start creates, not a
+// per-inode count; ino numbers are never reused, so it only has to differ
+// from the epoch of whatever copy of the database handed an ID out before.
+// The generation never goes backwards, and jumps to the current time when
+// that is ahead, so a database restored from an old copy still gets a
+// generation of its own. Inodes without a number are numbered from their ID
+// and the counter is moved past all of them; that scan only runs on the
+// first start after an upgrade, since later starts find the counter stored.
+func (s *Service) loadInodeCounters(tx *bbolt.Tx) error {
+	metaB, err := tx.CreateBucketIfNotExists([]byte(bucketMeta))
+	if err != nil {
+		return err
+	}
+	inodesB := tx.Bucket([]byte(bucketInodes))
+	if inodesB == nil {
+		return errors.New("metadata buckets are missing")
+	}
+	s.generation = max(getUint64(metaB, metaGeneration)+1, uint64(time.Now().Unix()))
+	if metaB.Get([]byte(metaNextIno)) != nil {
+		s.nextIno = getUint64(metaB, metaNextIno)
+	} else if err := s.numberLegacyInodes(inodesB); err != nil {
+		return err
+	}
+	if err := putUint64(metaB, metaGeneration, s.generation); err != nil {
+		return err
+	}
+	return putUint64(metaB, metaNextIno, s.nextIno)
+}
+
+func (s *Service) numberLegacyInodes(inodesB *bbolt.Bucket) error {
+	s.nextIno = rootIno + 1
+	// Bolt forbids writes while iterating, so renumbered inodes are put after.
+	var legacy []*protogen.Inode
+	if err := inodesB.ForEach(func(k, v []byte) error {
+		inode := &protogen.Inode{}
+		if err := gproto.Unmarshal(v, inode); err != nil {
+			return err
+		}
+		if inode.GetIno() == 0 {
+			ino, ok := legacyIno(string(k))
+			if !ok {
+				return fmt.Errorf("inode %q has no inode number and an unrecognised ID", k)
+			}
+			inode.Ino = ino
+			inode.Generation = s.generation
+			legacy = append(legacy, inode)
+		}
+		s.nextIno = max(s.nextIno, inode.GetIno()+1)
+		return nil
+	}); err != nil {
+		return err
+	}
+	for _, inode := range legacy {
+		if err := putInode(inodesB, inode); err != nil {
+			return err
+		}
+	}
+	if len(legacy) > 0 {
+		log.Printf("mds numbered %d inodes created before inode numbers were stored", len(legacy))
+	}
+	return nil
+}
+
+// allocInode hands out the next inode number. persistCreate stores the
+// counter along with the inode.
+func (s *Service) allocInode() (uint64, string) {
+	s.inoMu.Lock()
+	defer s.inoMu.Unlock()
+	ino := s.nextIno
+	s.nextIno++
+	return ino, inodeIDFor(ino)
+}
+
+func getUint64(b kvBucket, key string) uint64 {
+	v := b.Get([]byte(key))
+	if len(v) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(v)
+}
+
+func putUint64(b kvBucket, key string, v uint64) error {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], v)
+	return b.Put([]byte(key), buf[:])
+}
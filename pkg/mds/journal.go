@@ -0,0 +1,571 @@
+package mds
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// The journal is an append-only file of metadata operations. It starts with
+// a header naming the journal, followed by records framed as a 4-byte length,
+// a 4-byte CRC32C of the payload and the JSON payload. Every record carries
+// the bolt changes of one operation, so replaying it needs nothing but the
+// record itself.
+//
+// Records are addressed by logical offset: the offset they had before any
+// trimming, with the first record at journalStart. The header stores the
+// logical offset of the first record left in the file, so offsets kept in
+// bolt and in snapshots stay valid when trim drops the records before them.
+// Version 1 headers have no such field and were never trimmed.
+const (
+	journalMagicV1      = "PFSJRNL1"
+	journalMagic        = "PFSJRNL2"
+	journalHeaderSizeV1 = len(journalMagicV1) + 8
+	journalHeaderSize   = journalHeaderSizeV1 + 8
+	journalStart        = int64(journalHeaderSizeV1)
+	journalFrameSize    = 8
+	// maxJournalRecord bounds a record's length, so a corrupt length field
+	// cannot make replay allocate gigabytes.
+	maxJournalRecord = 64 << 20
+
+	metaJournalID     = "journal_id"
+	metaJournalSeq    = "journal_seq"
+	metaJournalOffset = "journal_offset"
+)
+
+var journalCRC = crc32.MakeTable(crc32.Castagnoli)
+
+type journalRecord struct {
+	Seq      uint64          `json:"seq"`
+	UnixNano int64           `json:"unix_nano"`
+	Op       string          `json:"op"`
+	Changes  []journalChange `json:"changes,omitempty"`
+	// Aborted lists earlier records whose bolt commit failed after they were
+	// journaled. Replay skips them.
+	Aborted []uint64 `json:"aborted,omitempty"`
+}
+
+type journalChange struct {
+	Bucket string `json:"bucket"`
+	Key    []byte `json:"key"`
+	Value  []byte `json:"value,omitempty"`
+	Delete bool   `json:"delete,omitempty"`
+}
+
+// kvBucket is what the inode and counter helpers need from a bucket, so they
+// work on both plain and journaled buckets.
+type kvBucket interface {
+	Get(key []byte) []byte
+	Put(key, value []byte) error
+}
+
+// metaTx is a bolt write transaction that records every change made through
+// it, so the committer can journal the changes before committing them.
+type metaTx struct {
+	tx  *bbolt.Tx
+	rec *journalRecord
+}
+
+type journaledBucket struct {
+	bucket *bbolt.Bucket
+	name   string
+	rec    *journalRecord
+}
+
+// Bucket returns the named bucket, or nil if it does not exist.
+func (t *metaTx) Bucket(name string) *journaledBucket {
+	b := t.tx.Bucket([]byte(name))
+	if b == nil {
+		return nil
+	}
+	return &journaledBucket{bucket: b, name: name, rec: t.rec}
+}
+
+func (b *journaledBucket) Get(key []byte) []byte {
+	return b.bucket.Get(key)
+}
+
+func (b *journaledBucket) Put(key, value []byte) error {
+	if err := b.bucket.Put(key, value); err != nil {
+		return err
+	}
+	b.rec.Changes = append(b.rec.Changes, journalChange{Bucket: b.name, Key: slices.Clone(key), Value: slices.Clone(value)})
+	return nil
+}
+
+func (b *journaledBucket) Delete(key []byte) error {
+	if err := b.bucket.Delete(key); err != nil {
+		return err
+	}
+	b.rec.Changes = append(b.rec.Changes, journalChange{Bucket: b.name, Key: slices.Clone(key), Delete: true})
+	return nil
+}
+
+// journal appends records to the journal file. After startup it is only
+// written by the goroutine that commits to bolt: the committer, or the raft
+// apply loop in a replicated MDS. mu keeps trim from swapping the file
+// under an append.
+type journal struct {
+	mu     sync.Mutex
+	f      *os.File
+	path   string
+	header journalHeader
+	// size is the logical offset where the next record goes.
+	size    int64
+	nextSeq uint64
+	// broken is set once an append fails. What reached the file is unknown
+	// from then on, so no further records are written.
+	broken error
+}
+
+type journalHeader struct {
+	id []byte
+	// base is the logical offset of the first record in the file.
+	base int64
+	// size is the length of the header in the file.
+	size int64
+}
+
+// reader reads the journal file f by logical offset.
+func (h journalHeader) reader(f io.ReaderAt) io.ReaderAt {
+	return journalReader{f: f, h: h}
+}
+
+// physical returns where the record at logical offset off is in the file.
+func (h journalHeader) physical(off int64) int64 {
+	return off - h.base + h.size
+}
+
+type journalReader struct {
+	f io.ReaderAt
+	h journalHeader
+}
+
+func (r journalReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < r.h.base {
+		return 0, fmt.Errorf("journal offset %d was trimmed, the journal starts at %d", off, r.h.base)
+	}
+	return r.f.ReadAt(p, r.h.physical(off))
+}
+
+// openJournal opens the journal at path, creating it if it does not exist.
+// attachJournal scans it from where the database left off.
+func openJournal(path string) (*journal, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("open journal: %w", err)
+	}
+	j := &journal{f: f, path: path}
+	if err := j.init(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return j, nil
+}
+
+func (j *journal) init() error {
+	info, err := j.f.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() == 0 {
+		id := make([]byte, 8)
+		if _, err := rand.Read(id); err != nil {
+			return err
+		}
+		j.header = journalHeader{id: id, base: journalStart, size: int64(journalHeaderSize)}
+		if _, err := j.f.Write(encodeJournalHeader(j.header)); err != nil {
+			return fmt.Errorf("write journal header: %w", err)
+		}
+		j.size = journalStart
+		return j.f.Sync()
+	}
+	h, err := readJournalHeader(j.f)
+	if err != nil {
+		return err
+	}
+	j.header = h
+	j.size = h.base + info.Size() - h.size
+	return nil
+}
+
+func encodeJournalHeader(h journalHeader) []byte {
+	header := append([]byte(journalMagic), h.id...)
+	return binary.BigEndian.AppendUint64(header, uint64(h.base))
+}
+
+func readJournalHeader(r io.ReaderAt) (journalHeader, error) {
+	header := make([]byte, journalHeaderSizeV1)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return journalHeader{}, fmt.Errorf("read journal header: %w", err)
+	}
+	id := header[len(journalMagic):]
+	switch string(header[:len(journalMagic)]) {
+	case journalMagicV1:
+		return journalHeader{id: id, base: journalStart, size: journalStart}, nil
+	case journalMagic:
+		var base [8]byte
+		if _, err := r.ReadAt(base[:], int64(journalHeaderSizeV1)); err != nil {
+			return journalHeader{}, fmt.Errorf("read journal header: %w", err)
+		}
+		return journalHeader{id: id, base: int64(binary.BigEndian.Uint64(base[:])), size: int64(journalHeaderSize)}, nil
+	}
+	return journalHeader{}, errors.New("not a metadata journal")
+}
+
+// errDamagedRecord marks a journal record that is cut off by the end of the
+// file or fails its checks, as opposed to a file that cannot be read.
+var errDamagedRecord = errors.New("damaged journal record")
+
+// scanJournal calls fn with every record from offset up to size, and the
+// offset just past it, and returns where the records end. A crash mid-append
+// can only tear the records at the end of the file, so a damaged record is
+// taken as the end only when no intact record follows it. Anywhere else it
+// is an error: dropping it would drop the committed records after it.
+func scanJournal(r io.ReaderAt, offset, size int64, fn func(journalRecord, int64) error) (int64, error) {
+	for offset < size {
+		rec, n, err := readJournalRecord(r, offset, size)
+		if errors.Is(err, errDamagedRecord) {
+			next, nerr := nextIntactRecord(r, offset+1, size)
+			if nerr != nil {
+				return offset, nerr
+			}
+			if next >= 0 {
+				return offset, fmt.Errorf("%w, but an intact record follows at offset %d", err, next)
+			}
+			return offset, nil
+		}
+		if err != nil {
+			return offset, err
+		}
+		offset += n
+		if err := fn(rec, offset); err != nil {
+			return offset, err
+		}
+	}
+	return offset, nil
+}
+
+// readJournalRecord reads the record at offset and returns it with its
+// framed length.
+func readJournalRecord(r io.ReaderAt, offset, size int64) (journalRecord, int64, error) {
+	var rec journalRecord
+	if size-offset < journalFrameSize {
+		return rec, 0, fmt.Errorf("%w at offset %d: frame is cut off", errDamagedRecord, offset)
+	}
+	frame := make([]byte, journalFrameSize)
+	if _, err := r.ReadAt(frame, offset); err != nil {
+		return rec, 0, fmt.Errorf("read journal at offset %d: %w", offset, err)
+	}
+	n := int64(binary.BigEndian.Uint32(frame[:4]))
+	if n > maxJournalRecord || offset+journalFrameSize+n > size {
+		return rec, 0, fmt.Errorf("%w at offset %d: length %d runs past the end", errDamagedRecord, offset, n)
+	}
+	payload := make([]byte, n)
+	if _, err := r.ReadAt(payload, offset+journalFrameSize); err != nil {
+		return rec, 0, fmt.Errorf("read journal at offset %d: %w", offset, err)
+	}
+	if crc32.Checksum(payload, journalCRC) != binary.BigEndian.Uint32(frame[4:]) {
+		return rec, 0, fmt.Errorf("%w at offset %d: checksum mismatch", errDamagedRecord, offset)
+	}
+	// A zero-filled tail, left when the file grew but the data never reached
+	// it, passes the checksum and fails here.
+	if err := json.Unmarshal(payload, &rec); err != nil {
+		return rec, 0, fmt.Errorf("%w at offset %d: %v", errDamagedRecord, offset, err)
+	}
+	return rec, journalFrameSize + n, nil
+}
+
+// nextIntactRecord returns the first offset from offset on where an intact
+// record starts, or -1 if there is none.
+func nextIntactRecord(r io.ReaderAt, offset, size int64) (int64, error) {
+	for ; offset+journalFrameSize <= size; offset++ {
+		_, _, err := readJournalRecord(r, offset, size)
+		if err == nil {
+			return offset, nil
+		}
+		if !errors.Is(err, errDamagedRecord) {
+			return -1, err
+		}
+	}
+	return -1, nil
+}
+
+// log numbers the records of a batch, journals them and syncs the journal,
+// and records in tx how far the journal has been applied, so a restart
+// replays only what comes after this batch.
+func (j *journal) log(tx *bbolt.Tx, recs []*journalRecord) ([]uint64, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.broken != nil {
+		return nil, j.broken
+	}
+	now := time.Now().UnixNano()
+	seqs := make([]uint64, 0, len(recs))
+	for _, rec := range recs {
+		rec.Seq = j.nextSeq
+		rec.UnixNano = now
+		j.nextSeq++
+		seqs = append(seqs, rec.Seq)
+	}
+	buf, err := encodeJournal(recs)
+	if err != nil {
+		return nil, err
+	}
+	metaB := tx.Bucket([]byte(bucketMeta))
+	if metaB == nil {
+		return nil, errors.New("meta bucket is missing")
+	}
+	if err := putUint64(metaB, metaJournalSeq, seqs[len(seqs)-1]); err != nil {
+		return nil, err
+	}
+	if err := putUint64(metaB, metaJournalOffset, uint64(j.size)+uint64(len(buf))); err != nil {
+		return nil, err
+	}
+	if err := j.write(buf); err != nil {
+		return nil, err
+	}
+	return seqs, nil
+}
+
+// abort journals that the records with the given sequence numbers were not
+// committed to bolt, so replay leaves them out.
+func (j *journal) abort(seqs []uint64) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.broken != nil {
+		return
+	}
+	rec := &journalRecord{Seq: j.nextSeq, UnixNano: time.Now().UnixNano(), Op: "abort", Aborted: seqs}
+	j.nextSeq++
+	buf, err := encodeJournal([]*journalRecord{rec})
+	if err == nil {
+		err = j.write(buf)
+	}
+	if err != nil {
+		log.Printf("mds journal: records %v failed to commit and could not be marked aborted, a restart will apply them: %v", seqs, err)
+	}
+}
+
+func (j *journal) write(buf []byte) error {
+	_, err := j.f.WriteAt(buf, j.header.physical(j.size))
+	if err == nil {
+		err = j.f.Sync()
+	}
+	if err != nil {
+		j.broken = fmt.Errorf("journal append failed, restart the mds to recover: %w", err)
+		return j.broken
+	}
+	j.size += int64(len(buf))
+	return nil
+}
+
+func encodeJournal(recs []*journalRecord) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, rec := range recs {
+		payload, err := json.Marshal(rec)
+		if err != nil {
+			return nil, err
+		}
+		var frame [journalFrameSize]byte
+		binary.BigEndian.PutUint32(frame[:4], uint32(len(payload)))
+		binary.BigEndian.PutUint32(frame[4:], crc32.Checksum(payload, journalCRC))
+		buf.Write(frame[:])
+		buf.Write(payload)
+	}
+	return buf.Bytes(), nil
+}
+
+// trim drops the records before the logical offset off, which must be
+// where a record starts, by copying the rest into a new file with off as
+// its base and renaming it over the journal. It does nothing unless at
+// least half the file would go, so the copying costs at most as much again
+// as the appends.
+func (j *journal) trim(off int64) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.broken != nil {
+		return j.broken
+	}
+	if off > j.size {
+		return fmt.Errorf("trim journal to offset %d past its end at %d", off, j.size)
+	}
+	if off <= j.header.base || off-j.header.base < j.size-off {
+		return nil
+	}
+	if off < j.size {
+		if _, _, err := readJournalRecord(j.header.reader(j.f), off, j.size); err != nil {
+			return fmt.Errorf("trim journal to offset %d: %w", off, err)
+		}
+	}
+	h := journalHeader{id: j.header.id, base: off, size: int64(journalHeaderSize)}
+	tmp := j.path + ".trim"
+	f, err := os.OpenFile(tmp, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("trim journal: %w", err)
+	}
+	_, err = f.Write(encodeJournalHeader(h))
+	if err == nil {
+		_, err = io.Copy(f, io.NewSectionReader(j.f, j.header.physical(off), j.size-off))
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if err == nil {
+		err = os.Rename(tmp, j.path)
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("trim journal: %w", err)
+	}
+	// From here on the journal at path is the new file, so appends to the
+	// old one would be lost.
+	if err := syncDir(filepath.Dir(j.path)); err != nil {
+		f.Close()
+		j.broken = fmt.Errorf("journal trim could not be synced, restart the mds to recover: %w", err)
+		return j.broken
+	}
+	j.f.Close()
+	j.f = f
+	j.header = h
+	return nil
+}
+
+// start returns the logical offset of the first record in the journal.
+func (j *journal) start() int64 {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.header.base
+}
+
+func (j *journal) close() error {
+	return j.f.Close()
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// attachJournal ties the journal to the database and replays the records
+// bolt has not applied yet, e.g. because the MDS crashed between writing the
+// journal and committing. Only the journal after the offset bolt has applied
+// is read, and a torn record at its end left by a crash mid-append is
+// dropped. It must run in the startup transaction.
+func attachJournal(tx *bbolt.Tx, j *journal) error {
+	metaB := tx.Bucket([]byte(bucketMeta))
+	if metaB == nil {
+		return errors.New("meta bucket is missing")
+	}
+	storedID := metaB.Get([]byte(metaJournalID))
+	offset := int64(getUint64(metaB, metaJournalOffset))
+	switch {
+	case storedID == nil && j.size > j.header.base:
+		return errors.New("journal already has records but the database was not journaled to it; move the journal aside")
+	case storedID == nil:
+		offset = j.header.base
+		if err := metaB.Put([]byte(metaJournalID), j.header.id); err != nil {
+			return err
+		}
+	case !bytes.Equal(storedID, j.header.id):
+		return errors.New("journal belongs to another database")
+	case offset < j.header.base:
+		return fmt.Errorf("database has applied the journal up to offset %d but it was trimmed to start at %d", offset, j.header.base)
+	case offset > j.size:
+		return fmt.Errorf("database has applied the journal up to offset %d but it ends at %d", offset, j.size)
+	}
+
+	seq := getUint64(metaB, metaJournalSeq)
+	end, replayed, lastSeq, err := replayJournal(tx, j.header.reader(j.f), offset, j.size, seq, ^uint64(0), time.Time{})
+	if err != nil {
+		return fmt.Errorf("scan journal: %w", err)
+	}
+	if replayed > 0 {
+		log.Printf("mds replayed %d journal records", replayed)
+	}
+	if end < j.size {
+		log.Printf("mds journal: dropping %d bytes of torn records after offset %d", j.size-end, end)
+		if err := j.f.Truncate(j.header.physical(end)); err != nil {
+			return fmt.Errorf("truncate torn journal tail: %w", err)
+		}
+		j.size = end
+	}
+	if err := putUint64(metaB, metaJournalOffset, uint64(end)); err != nil {
+		return err
+	}
+	j.nextSeq = max(getUint64(metaB, metaJournalSeq), lastSeq) + 1
+	return nil
+}
+
+// replayJournal applies the records from offset up to size whose sequence
+// number is above applied and at most toSeq, and, when toTime is set, that
+// were written no later than toTime. It returns the offset after the last
+// record looked at, how many records it applied and the highest sequence
+// number it read.
+func replayJournal(tx *bbolt.Tx, r io.ReaderAt, offset, size int64, applied, toSeq uint64, toTime time.Time) (int64, int, uint64, error) {
+	var recs []journalRecord
+	var ends []int64
+	var lastSeq uint64
+	aborted := map[uint64]bool{}
+	if _, err := scanJournal(r, offset, size, func(rec journalRecord, end int64) error {
+		for _, seq := range rec.Aborted {
+			aborted[seq] = true
+		}
+		lastSeq = max(lastSeq, rec.Seq)
+		recs = append(recs, rec)
+		ends = append(ends, end)
+		return nil
+	}); err != nil {
+		return offset, 0, 0, err
+	}
+
+	metaB := tx.Bucket([]byte(bucketMeta))
+	replayed := 0
+	for i, rec := range recs {
+		if rec.Seq > toSeq || (!toTime.IsZero() && rec.UnixNano > toTime.UnixNano()) {
+			break
+		}
+		offset = ends[i]
+		if rec.Seq <= applied || aborted[rec.Seq] || len(rec.Aborted) > 0 {
+			continue
+		}
+		for _, c := range rec.Changes {
+			b := tx.Bucket([]byte(c.Bucket))
+			if b == nil {
+				return offset, replayed, lastSeq, fmt.Errorf("journal record %d changes unknown bucket %q", rec.Seq, c.Bucket)
+			}
+			var err error
+			if c.Delete {
+				err = b.Delete(c.Key)
+			} else {
+				err = b.Put(c.Key, c.Value)
+			}
+			if err != nil {
+				return offset, replayed, lastSeq, fmt.Errorf("replay journal record %d: %w", rec.Seq, err)
+			}
+		}
+		if err := putUint64(metaB, metaJournalSeq, rec.Seq); err != nil {
+			return offset, replayed, lastSeq, err
+		}
+		replayed++
+	}
+	return offset, replayed, lastSeq, nil
+}
@@ -0,0 +1,225 @@
+package mds
+
+import (
+	"context"
+	"hash/fnv"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rachanaanugandula/kube-pfs/pkg/metrics"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultLockShards = 64
+	// pinWait is how long a handler waits for an inode pinned by a prepared
+	// cross-shard transaction before giving up.
+	pinWait = 5 * time.Second
+)
+
+// lockTable shards the namespace locks by inode ID. Handlers lock the
+// directories whose entries they read or change and the inodes they rewrite.
+// Shards are always taken in ascending index order, so two handlers that need
+// several of them can never wait on each other.
+//
+// A transaction prepared for another shard instead pins the single inodes it
+// touches, since it keeps them until its coordinator settles it, which takes
+// as long as the coordinator is down. Holding the shards that long would
+// block every inode that hashes to them.
+type lockTable struct {
+	shards []sync.RWMutex
+
+	pinMu sync.Mutex
+	pins  map[string]bool
+	// unpinned is closed and replaced whenever pins are released.
+	unpinned chan struct{}
+}
+
+func newLockTable(n int) *lockTable {
+	return &lockTable{shards: make([]sync.RWMutex, n), pins: map[string]bool{}, unpinned: make(chan struct{})}
+}
+
+func (t *lockTable) shard(inodeID string) int {
+	h := fnv.New32a()
+	h.Write([]byte(inodeID))
+	return int(h.Sum32() % uint32(len(t.shards)))
+}
+
+// lock takes the shards of the given inodes, exclusively or shared, and
+// returns the function that releases them. Empty IDs are skipped. It waits
+// for pinned inodes among them until ctx ends or for up to pinWait, and
+// then fails with UNAVAILABLE.
+func (t *lockTable) lock(ctx context.Context, op string, write bool, inodeIDs ...string) (func(), error) {
+	idx := t.indexes(inodeIDs)
+	deadline := time.Now().Add(pinWait)
+	for {
+		for _, i := range idx {
+			waitStart := time.Now()
+			if write {
+				t.shards[i].Lock()
+			} else {
+				t.shards[i].RLock()
+			}
+			metrics.ObserveMDSLockContention(strconv.Itoa(i), op, time.Since(waitStart))
+		}
+		unpinned := t.pinned(inodeIDs)
+		if unpinned == nil {
+			return t.unlocker(idx, write), nil
+		}
+		t.unlocker(idx, write)()
+		select {
+		case <-unpinned:
+		case <-ctx.Done():
+			return nil, status.FromContextError(ctx.Err()).Err()
+		case <-time.After(time.Until(deadline)):
+			return nil, status.Error(codes.Unavailable, "the entry is part of a cross-shard transaction that has not settled; try again")
+		}
+	}
+}
+
+// pinWithin pins the given inodes for a shard preparing another shard's
+// transaction, and returns the function that unpins them. Shards cannot
+// order their locks against each other's, so instead of waiting it gives up
+// after timeout, and reports whether it pinned them.
+func (t *lockTable) pinWithin(op string, timeout time.Duration, inodeIDs ...string) (func(), bool) {
+	idx := t.indexes(inodeIDs)
+	waitStart := time.Now()
+	for pause := time.Millisecond; ; pause = min(2*pause, 50*time.Millisecond) {
+		taken := 0
+		for _, i := range idx {
+			if !t.shards[i].TryLock() {
+				break
+			}
+			taken++
+		}
+		// Holding the shards, no handler is between taking them and
+		// checking the pins.
+		if taken == len(idx) && t.pinned(inodeIDs) == nil {
+			for _, i := range idx {
+				metrics.ObserveMDSLockContention(strconv.Itoa(i), op, time.Since(waitStart))
+			}
+			unpin := t.pin(inodeIDs...)
+			t.unlocker(idx, true)()
+			return unpin, true
+		}
+		t.unlocker(idx[:taken], true)()
+		if time.Since(waitStart) >= timeout {
+			return nil, false
+		}
+		time.Sleep(pause)
+	}
+}
+
+// pin marks the given inodes as held by a prepared transaction, without
+// waiting, and returns the function that releases them. Empty IDs are
+// skipped.
+func (t *lockTable) pin(inodeIDs ...string) func() {
+	var ids []string
+	t.pinMu.Lock()
+	for _, id := range inodeIDs {
+		if id != "" && !t.pins[id] {
+			t.pins[id] = true
+			ids = append(ids, id)
+		}
+	}
+	t.pinMu.Unlock()
+	return func() {
+		t.pinMu.Lock()
+		defer t.pinMu.Unlock()
+		for _, id := range ids {
+			delete(t.pins, id)
+		}
+		close(t.unpinned)
+		t.unpinned = make(chan struct{})
+	}
+}
+
+// pinned returns a channel closed when pins are next released if any of
+// the given inodes is pinned, and nil otherwise.
+func (t *lockTable) pinned(inodeIDs []string) <-chan struct{} {
+	t.pinMu.Lock()
+	defer t.pinMu.Unlock()
+	for _, id := range inodeIDs {
+		if t.pins[id] {
+			return t.unpinned
+		}
+	}
+	return nil
+}
+
+// indexes returns the shards of the given inodes in the order they are
+// taken. Empty IDs are skipped.
+func (t *lockTable) indexes(inodeIDs []string) []int {
+	idx := make([]int, 0, len(inodeIDs))
+	for _, id := range inodeIDs {
+		if id != "" {
+			idx = append(idx, t.shard(id))
+		}
+	}
+	slices.Sort(idx)
+	return slices.Compact(idx)
+}
+
+func (t *lockTable) unlocker(idx []int, write bool) func() {
+	return func() {
+		for j := len(idx) - 1; j >= 0; j-- {
+			if write {
+				t.shards[idx[j]].Unlock()
+			} else {
+				t.shards[idx[j]].RUnlock()
+			}
+		}
+	}
+}
+
+type entryName struct {
+	parentInodeID string
+	name          string
+}
+
+// lockEntries exclusively locks the given directories and the inodes their
+// names resolve to, for handlers that rewrite or delete those inodes. Names
+// are resolved before their inodes can be locked, so they are resolved again
+// under the locks and the locks retaken if a concurrent rename or unlink got
+// in between. The IDs are "" for names that do not exist.
+func (s *Service) lockEntries(ctx context.Context, op string, entries ...entryName) ([]string, func(), error) {
+	ids, err := s.resolve(entries)
+	if err != nil {
+		return nil, nil, err
+	}
+	for {
+		lockIDs := slices.Clone(ids)
+		for _, e := range entries {
+			lockIDs = append(lockIDs, e.parentInodeID)
+		}
+		unlock, err := s.locks.lock(ctx, op, true, lockIDs...)
+		if err != nil {
+			return nil, nil, err
+		}
+		again, err := s.resolve(entries)
+		if err != nil {
+			unlock()
+			return nil, nil, err
+		}
+		if slices.Equal(ids, again) {
+			return ids, unlock, nil
+		}
+		unlock()
+		ids = again
+	}
+}
+
+func (s *Service) resolve(entries []entryName) ([]string, error) {
+	ids := make([]string, len(entries))
+	for i, e := range entries {
+		id, err := s.lookup(e.parentInodeID, e.name)
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
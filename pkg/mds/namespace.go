@@ -0,0 +1,259 @@
+package mds
+
+import (
+	"bytes"
+	"container/list"
+	"errors"
+	"hash/fnv"
+	"sync"
+
+	protogen "github.com/rachanaanugandula/kube-pfs/pkg/proto/gen"
+	"go.etcd.io/bbolt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	gproto "google.golang.org/protobuf/proto"
+)
+
+// lruCache is a string-keyed LRU with its own lock, so handlers holding
+// shard locks for reading can still record hits. A capacity of 0 never
+// evicts.
+//
+// Readers fill the cache from bolt without holding the locks writers take,
+// so a fill could store a value a writer has since replaced. Every put and
+// remove bumps the sequence number of the key's stripe, and fill only
+// stores a value when that has not moved since the reader looked at bolt.
+// Writes to other keys only hold back fills that share their stripe.
+type lruCache[V any] struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List
+	items    map[string]*list.Element
+	seqs     cacheVersion
+}
+
+const cacheFillStripes = 64
+
+// cacheVersion holds the sequence number of every stripe of a cache.
+type cacheVersion [cacheFillStripes]uint64
+
+func fillStripe(key string) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % cacheFillStripes)
+}
+
+type lruEntry[V any] struct {
+	key   string
+	value V
+}
+
+func newLRU[V any](capacity int) *lruCache[V] {
+	return &lruCache[V]{capacity: capacity, order: list.New(), items: map[string]*list.Element{}}
+}
+
+func (c *lruCache[V]) get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.order.MoveToFront(el)
+		return el.Value.(*lruEntry[V]).value, true
+	}
+	var zero V
+	return zero, false
+}
+
+func (c *lruCache[V]) put(key string, value V) {
+	stripe := fillStripe(key)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.seqs[stripe]++
+	c.store(key, value)
+}
+
+// version returns the sequence numbers a later fill is checked against.
+func (c *lruCache[V]) version() cacheVersion {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.seqs
+}
+
+// fill caches a value read from bolt unless the key's stripe changed since
+// version returned seen.
+func (c *lruCache[V]) fill(key string, value V, seen cacheVersion) {
+	stripe := fillStripe(key)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.seqs[stripe] == seen[stripe] {
+		c.store(key, value)
+	}
+}
+
+func (c *lruCache[V]) store(key string, value V) {
+	if el, ok := c.items[key]; ok {
+		el.Value.(*lruEntry[V]).value = value
+		c.order.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.order.PushFront(&lruEntry[V]{key: key, value: value})
+	if c.capacity > 0 && c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*lruEntry[V]).key)
+	}
+}
+
+func (c *lruCache[V]) remove(key string) {
+	stripe := fillStripe(key)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.seqs[stripe]++
+	if el, ok := c.items[key]; ok {
+		c.order.Remove(el)
+		delete(c.items, key)
+	}
+}
+
+// The inodes and dirents buckets are the source of truth for the namespace.
+// Inodes and dirents are cached in front of them; cached inodes are never
+// modified in place, so handlers clone before changing one and then store
+// the clone. Handlers that change an entry update the cache after bolt has
+// committed, while still holding the locks for it.
+
+func direntKey(parentInodeID, name string) string {
+	return parentInodeID + "\x00" + name
+}
+
+// inode returns the inode with the given ID, or nil if there is none.
+func (s *Service) inode(inodeID string) (*protogen.Inode, error) {
+	if inode, ok := s.inodeCache.get(inodeID); ok {
+		return inode, nil
+	}
+	var inode *protogen.Inode
+	seen := s.inodeCache.version()
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		inodesB := tx.Bucket([]byte(bucketInodes))
+		if inodesB == nil {
+			return errors.New("metadata buckets are missing")
+		}
+		var err error
+		inode, err = s.readInode(inodesB, inodeID, seen)
+		return err
+	})
+	return inode, err
+}
+
+func (s *Service) readInode(inodesB *bbolt.Bucket, inodeID string, seen cacheVersion) (*protogen.Inode, error) {
+	if inode, ok := s.inodeCache.get(inodeID); ok {
+		return inode, nil
+	}
+	v := inodesB.Get([]byte(inodeID))
+	if v == nil {
+		return nil, nil
+	}
+	inode := &protogen.Inode{}
+	if err := gproto.Unmarshal(v, inode); err != nil {
+		return nil, err
+	}
+	s.inodeCache.fill(inodeID, inode, seen)
+	return inode, nil
+}
+
+// dirInode returns the directory with the given ID, or nil if there is no
+// such inode or it is not a directory.
+func (s *Service) dirInode(inodeID string) (*protogen.Inode, error) {
+	inode, err := s.inode(inodeID)
+	if err != nil || !inode.GetIsDir() {
+		return nil, err
+	}
+	return inode, nil
+}
+
+// lookup returns the ID of the entry name in a directory, or "" if there is
+// none.
+func (s *Service) lookup(parentInodeID, name string) (string, error) {
+	key := direntKey(parentInodeID, name)
+	if id, ok := s.direntCache.get(key); ok {
+		return id, nil
+	}
+	var id string
+	seen := s.direntCache.version()
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		direntsB := tx.Bucket([]byte(bucketDirents))
+		if direntsB == nil {
+			return errors.New("metadata buckets are missing")
+		}
+		id = string(direntsB.Get([]byte(key)))
+		return nil
+	})
+	if err == nil && id != "" {
+		s.direntCache.fill(key, id, seen)
+	}
+	return id, err
+}
+
+// hasChildren reports whether a directory has any entries.
+func (s *Service) hasChildren(dirInodeID string) (bool, error) {
+	found := false
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		direntsB := tx.Bucket([]byte(bucketDirents))
+		if direntsB == nil {
+			return errors.New("metadata buckets are missing")
+		}
+		prefix := []byte(dirInodeID + "\x00")
+		k, _ := direntsB.Cursor().Seek(prefix)
+		found = k != nil && bytes.HasPrefix(k, prefix)
+		return nil
+	})
+	return found, err
+}
+
+// children returns the entries of a directory sorted by name, which is the
+// order bolt keeps the dirents in. Entries for inodes on other shards are
+// stubs holding only the inode ID, for withRemoteInodes to fill in.
+func (s *Service) children(dirInodeID string) ([]*protogen.Inode, error) {
+	var entries []*protogen.Inode
+	seen := s.inodeCache.version()
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		inodesB := tx.Bucket([]byte(bucketInodes))
+		direntsB := tx.Bucket([]byte(bucketDirents))
+		if inodesB == nil || direntsB == nil {
+			return errors.New("metadata buckets are missing")
+		}
+		prefix := []byte(dirInodeID + "\x00")
+		c := direntsB.Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			if !s.holds(string(v)) {
+				entries = append(entries, &protogen.Inode{InodeId: string(v)})
+				continue
+			}
+			inode, err := s.readInode(inodesB, string(v), seen)
+			if err != nil {
+				return err
+			}
+			if inode != nil {
+				entries = append(entries, inode)
+			}
+		}
+		return nil
+	})
+	return entries, err
+}
+
+func (s *Service) cacheEntry(inode *protogen.Inode) {
+	s.inodeCache.put(inode.GetInodeId(), inode)
+	s.direntCache.put(direntKey(inode.GetParentInodeId(), inode.GetName()), inode.GetInodeId())
+}
+
+func (s *Service) forgetEntry(parentInodeID, name, inodeID string) {
+	s.inodeCache.remove(inodeID)
+	s.direntCache.remove(direntKey(parentInodeID, name))
+}
+
+// metadataError reports a failed metadata read. Errors that already carry a
+// status, like those from other shards, keep it.
+func metadataError(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return status.Errorf(codes.Internal, "read metadata: %v", err)
+}
@@ -0,0 +1,188 @@
+package mds
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	protogen "github.com/rachanaanugandula/kube-pfs/pkg/proto/gen"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	gproto "google.golang.org/protobuf/proto"
+)
+
+const (
+	defaultHeartbeatInterval = 5 * time.Second
+	// An OST is marked down once this many heartbeats in a row are missed.
+	defaultMissedHeartbeats = 3
+)
+
+// ostTable is the MDS view of OST membership. OSTs named in Config.OSTIDs are
+// listed from the start and count as up for one heartbeat timeout after the
+// MDS starts, which gives them the time to register; from then on, and for
+// OSTs that only ever registered, liveness follows heartbeats.
+type ostTable struct {
+	mu      sync.Mutex
+	order   []string
+	entries map[string]*ostEntry
+	pools   map[string]bool
+	timeout time.Duration
+	started time.Time
+}
+
+type ostEntry struct {
+	info     *protogen.OSTInfo
+	lastSeen time.Time
+	// pool is the pool Config.Pools puts the OST in, if any. It is not the
+	// OST's to change.
+	pool string
+}
+
+func newOSTTable(ids []string, pools map[string][]string, timeout time.Duration) *ostTable {
+	t := &ostTable{entries: map[string]*ostEntry{}, pools: map[string]bool{}, timeout: timeout, started: time.Now()}
+	for _, id := range ids {
+		if _, ok := t.entries[id]; ok {
+			continue
+		}
+		t.order = append(t.order, id)
+		t.entries[id] = &ostEntry{info: &protogen.OSTInfo{OstId: id}}
+	}
+	for name, members := range pools {
+		t.pools[name] = true
+		for _, id := range members {
+			t.entries[id].info.Pool = name
+			t.entries[id].pool = name
+		}
+	}
+	return t
+}
+
+// register records an OST. It fails without changing anything when the OST
+// asks for a pool other than the one the MDS configures it in.
+func (t *ostTable) register(req *protogen.RegisterOSTRequest, now time.Time) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	e, ok := t.entries[req.GetOstId()]
+	if ok && e.pool != "" && req.GetPool() != "" && req.GetPool() != e.pool {
+		return status.Errorf(codes.FailedPrecondition, "ost %q is configured in pool %q on the mds, not %q", req.GetOstId(), e.pool, req.GetPool())
+	}
+	if !ok {
+		e = &ostEntry{info: &protogen.OSTInfo{OstId: req.GetOstId()}}
+		t.order = append(t.order, req.GetOstId())
+		t.entries[req.GetOstId()] = e
+	}
+	e.info.Address = req.GetAddress()
+	if req.GetPool() != "" {
+		e.info.Pool = req.GetPool()
+	}
+	e.info.CapacityBytes = req.GetCapacityBytes()
+	e.info.FreeBytes = req.GetFreeBytes()
+	e.lastSeen = now
+	return nil
+}
+
+// heartbeat reports false for an OST that has not registered with this MDS.
+func (t *ostTable) heartbeat(req *protogen.HeartbeatRequest, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	e, ok := t.entries[req.GetOstId()]
+	if !ok || e.lastSeen.IsZero() {
+		return false
+	}
+	e.info.CapacityBytes = req.GetCapacityBytes()
+	e.info.FreeBytes = req.GetFreeBytes()
+	e.lastSeen = now
+	return true
+}
+
+func (t *ostTable) isUp(e *ostEntry, now time.Time) bool {
+	if e.lastSeen.IsZero() {
+		return now.Sub(t.started) <= t.timeout
+	}
+	return now.Sub(e.lastSeen) <= t.timeout
+}
+
+func (t *ostTable) hasPool(name string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.pools[name]
+}
+
+// members returns every known OST in a pool, up or down, or every known OST
+// for the empty pool.
+func (t *ostTable) members(pool string) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var out []string
+	for _, id := range t.order {
+		if pool == "" || t.entries[id].info.GetPool() == pool {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// recordLatency stores the recent mean latency measured for an OST.
+func (t *ostTable) recordLatency(ostID string, latency time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if e, ok := t.entries[ostID]; ok {
+		e.info.RecentLatencyUs = uint64(latency.Microseconds())
+	}
+}
+
+// up returns the OSTs that are up, in the order they became known.
+func (t *ostTable) up(now time.Time) []*protogen.OSTInfo {
+	var out []*protogen.OSTInfo
+	for _, info := range t.list(now) {
+		if info.GetUp() {
+			out = append(out, info)
+		}
+	}
+	return out
+}
+
+func (t *ostTable) list(now time.Time) []*protogen.OSTInfo {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]*protogen.OSTInfo, 0, len(t.order))
+	for _, id := range t.order {
+		e := t.entries[id]
+		info := gproto.Clone(e.info).(*protogen.OSTInfo)
+		info.Up = t.isUp(e, now)
+		if !e.lastSeen.IsZero() {
+			info.LastHeartbeatUnix = e.lastSeen.Unix()
+		}
+		out = append(out, info)
+	}
+	return out
+}
+
+func (s *Service) RegisterOST(_ context.Context, req *protogen.RegisterOSTRequest) (*protogen.RegisterOSTResponse, error) {
+	if req.GetOstId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "ost_id is required")
+	}
+	if req.GetPool() != "" && !s.osts.hasPool(req.GetPool()) {
+		return nil, status.Errorf(codes.InvalidArgument, "pool %q is not defined on the mds", req.GetPool())
+	}
+	if err := s.osts.register(req, time.Now()); err != nil {
+		log.Printf("mds refused to register ost %s: %v", req.GetOstId(), err)
+		return nil, err
+	}
+	return &protogen.RegisterOSTResponse{HeartbeatIntervalMs: uint32(s.heartbeat.Milliseconds())}, nil
+}
+
+func (s *Service) Heartbeat(_ context.Context, req *protogen.HeartbeatRequest) (*protogen.HeartbeatResponse, error) {
+	if req.GetOstId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "ost_id is required")
+	}
+	if !s.osts.heartbeat(req, time.Now()) {
+		return nil, status.Errorf(codes.NotFound, "ost %q is not registered", req.GetOstId())
+	}
+	return &protogen.HeartbeatResponse{}, nil
+}
+
+func (s *Service) ListOSTs(_ context.Context, _ *protogen.ListOSTsRequest) (*protogen.ListOSTsResponse, error) {
+	return &protogen.ListOSTsResponse{Osts: s.osts.list(time.Now())}, nil
+}
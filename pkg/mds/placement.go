@@ -0,0 +1,137 @@
+package mds
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"sync/atomic"
+
+	protogen "github.com/rachanaanugandula/kube-pfs/pkg/proto/gen"
+)
+
+// PlacementPolicy orders the OSTs of a new file's stripe layout. Chunks are
+// placed from the front of the order, so files shorter than a full stripe
+// only ever touch the first few OSTs and the order is what steers load.
+type PlacementPolicy interface {
+	// Order returns the IDs of every OST in osts, all of which are up.
+	Order(osts []*protogen.OSTInfo) []string
+}
+
+const (
+	PlacementRoundRobin = "round-robin"
+	PlacementFreeSpace  = "free-space"
+	PlacementLatency    = "latency"
+)
+
+// NewPlacementPolicy returns the built-in policy with the given name.
+func NewPlacementPolicy(name string) (PlacementPolicy, error) {
+	switch name {
+	case "", PlacementRoundRobin:
+		return &RoundRobinPlacement{}, nil
+	case PlacementFreeSpace:
+		return &FreeSpacePlacement{}, nil
+	case PlacementLatency:
+		return &LatencyPlacement{}, nil
+	default:
+		return nil, fmt.Errorf("unknown placement policy %q (want %s, %s or %s)", name, PlacementRoundRobin, PlacementFreeSpace, PlacementLatency)
+	}
+}
+
+// RoundRobinPlacement starts each new file one OST further along.
+type RoundRobinPlacement struct {
+	next atomic.Uint64
+}
+
+func (p *RoundRobinPlacement) Order(osts []*protogen.OSTInfo) []string {
+	if len(osts) == 0 {
+		return nil
+	}
+	return rotate(osts, int(p.next.Add(1)-1)%len(osts))
+}
+
+// FreeSpacePlacement picks OSTs in a random order weighted by free space, so
+// a nearly full OST is rarely first. OSTs that have not reported their free
+// space are weighted as the average of those that have.
+type FreeSpacePlacement struct{}
+
+func (p *FreeSpacePlacement) Order(osts []*protogen.OSTInfo) []string {
+	var known, sum float64
+	for _, o := range osts {
+		if o.GetCapacityBytes() > 0 {
+			known++
+			sum += float64(o.GetFreeBytes())
+		}
+	}
+	fallback := 1.0
+	if known > 0 && sum > 0 {
+		fallback = sum / known
+	}
+	weights := make([]float64, len(osts))
+	for i, o := range osts {
+		weights[i] = fallback
+		if o.GetCapacityBytes() > 0 {
+			weights[i] = float64(o.GetFreeBytes())
+		}
+	}
+
+	// Weighted sampling without replacement. A full OST has weight zero and
+	// is only ever placed after all the others.
+	out := make([]string, 0, len(osts))
+	left := append([]*protogen.OSTInfo{}, osts...)
+	for len(left) > 0 {
+		var total float64
+		for _, w := range weights {
+			total += w
+		}
+		pick := 0
+		if total > 0 {
+			r := rand.Float64() * total
+			for pick = 0; pick < len(weights)-1; pick++ {
+				if r < weights[pick] {
+					break
+				}
+				r -= weights[pick]
+			}
+		}
+		out = append(out, left[pick].GetOstId())
+		left = append(left[:pick], left[pick+1:]...)
+		weights = append(weights[:pick], weights[pick+1:]...)
+	}
+	return out
+}
+
+// LatencyPlacement orders OSTs by the recent latency the MDS measured from
+// their GetHealth counters, fastest first. OSTs with equal latency, including
+// ones not measured yet, take turns at the front.
+type LatencyPlacement struct {
+	next atomic.Uint64
+}
+
+func (p *LatencyPlacement) Order(osts []*protogen.OSTInfo) []string {
+	if len(osts) == 0 {
+		return nil
+	}
+	turn := int(p.next.Add(1) - 1)
+	sorted := append([]*protogen.OSTInfo{}, osts...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].GetRecentLatencyUs() < sorted[j].GetRecentLatencyUs()
+	})
+	ids := make([]string, 0, len(sorted))
+	for start := 0; start < len(sorted); {
+		end := start + 1
+		for end < len(sorted) && sorted[end].GetRecentLatencyUs() == sorted[start].GetRecentLatencyUs() {
+			end++
+		}
+		ids = append(ids, rotate(sorted[start:end], turn%(end-start))...)
+		start = end
+	}
+	return ids
+}
+
+func rotate(osts []*protogen.OSTInfo, start int) []string {
+	ids := make([]string, 0, len(osts))
+	for i := range osts {
+		ids = append(ids, osts[(start+i)%len(osts)].GetOstId())
+	}
+	return ids
+}
@@ -0,0 +1,251 @@
+package mds
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	protogen "github.com/rachanaanugandula/kube-pfs/pkg/proto/gen"
+	"github.com/rachanaanugandula/kube-pfs/pkg/raft"
+	"go.etcd.io/bbolt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// metaRaftApplied is the last raft entry bolt has applied.
+	metaRaftApplied = "raft_applied"
+	// proposeTimeout bounds how long a write waits for a majority.
+	proposeTimeout = 10 * time.Second
+)
+
+// ReplicationConfig makes the MDS one member of a group that replicates the
+// metadata journal with raft. The leader serves every metadata RPC; the
+// others reject them with a LeaderHint and apply the leader's batches to
+// their own bolt. Three members survive the loss of one, five of two.
+type ReplicationConfig struct {
+	// NodeID names this MDS among Peers, which lists every member with the
+	// gRPC address it serves MetadataService and RaftService on.
+	NodeID string
+	Peers  []raft.Peer
+	// ElectionTimeout and HeartbeatInterval default to 1s and 100ms.
+	ElectionTimeout   time.Duration
+	HeartbeatInterval time.Duration
+	// CompactEvery is how many applied raft entries build up before they
+	// are dropped from the raft log; 0 means 1024.
+	CompactEvery uint64
+}
+
+// startReplication joins the raft group. It runs after the committer is up,
+// since a node elected leader bootstraps the root through it.
+func (s *Service) startReplication(cfg ReplicationConfig) error {
+	var applied uint64
+	if err := s.db.View(func(tx *bbolt.Tx) error {
+		applied = getUint64(tx.Bucket([]byte(bucketMeta)), metaRaftApplied)
+		return nil
+	}); err != nil {
+		return err
+	}
+	node, err := raft.New(raft.Config{
+		ID:                cfg.NodeID,
+		Peers:             cfg.Peers,
+		DB:                s.db,
+		Apply:             s.applyEntry,
+		Applied:           applied,
+		OnLeader:          s.bootstrapRoot,
+		ElectionTimeout:   cfg.ElectionTimeout,
+		HeartbeatInterval: cfg.HeartbeatInterval,
+		CompactEvery:      cfg.CompactEvery,
+	})
+	if err != nil {
+		return fmt.Errorf("start raft: %w", err)
+	}
+	s.raft = node
+	return nil
+}
+
+// Raft returns the node to serve as RaftService, or nil for an MDS that is
+// not replicated.
+func (s *Service) Raft() *raft.Node {
+	return s.raft
+}
+
+// replicate is the committer's hook in a replicated MDS: the batch becomes
+// one raft entry, and applyEntry writes it to bolt on every member.
+func (s *Service) replicate(recs []*journalRecord) error {
+	data, err := json.Marshal(recs)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), proposeTimeout)
+	defer cancel()
+	return s.raft.Propose(ctx, data)
+}
+
+// applyEntry applies a committed raft entry to bolt and journals it, then
+// drops what it changed from the caches. The applied index is stored with
+// the changes, so nothing is applied twice across restarts.
+func (s *Service) applyEntry(index uint64, data []byte) error {
+	var recs []journalRecord
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &recs); err != nil {
+			return fmt.Errorf("decode raft entry %d: %w", index, err)
+		}
+	}
+	tx, err := s.db.Begin(true)
+	if err != nil {
+		return err
+	}
+	out := make([]*journalRecord, len(recs))
+	for i, rec := range recs {
+		out[i] = &journalRecord{Op: rec.Op}
+		mt := &metaTx{tx: tx, rec: out[i]}
+		for _, c := range rec.Changes {
+			b := mt.Bucket(c.Bucket)
+			if b == nil {
+				tx.Rollback()
+				return fmt.Errorf("raft entry %d changes unknown bucket %q", index, c.Bucket)
+			}
+			if c.Delete {
+				err = b.Delete(c.Key)
+			} else {
+				err = b.Put(c.Key, c.Value)
+			}
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+	}
+	// Journaling the applied index with the last record lets journal replay
+	// restore it along with the changes.
+	var metaB kvBucket = tx.Bucket([]byte(bucketMeta))
+	if len(out) > 0 {
+		metaB = (&metaTx{tx: tx, rec: out[len(out)-1]}).Bucket(bucketMeta)
+	}
+	if err := putUint64(metaB, metaRaftApplied, index); err != nil {
+		tx.Rollback()
+		return err
+	}
+	var seqs []uint64
+	if s.journal != nil && len(out) > 0 {
+		if seqs, err = s.journal.log(tx, out); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("journal: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		if seqs != nil {
+			s.journal.abort(seqs)
+		}
+		return err
+	}
+	s.forgetChanges(recs)
+	return nil
+}
+
+// forgetChanges drops changed entries from the caches, to be read again
+// from bolt, and keeps the inode counter ahead of every inode created, so
+// a member that takes over never hands out a number twice.
+func (s *Service) forgetChanges(recs []journalRecord) {
+	for _, rec := range recs {
+		for _, c := range rec.Changes {
+			switch c.Bucket {
+			case bucketInodes:
+				s.inodeCache.remove(string(c.Key))
+			case bucketDirents:
+				s.direntCache.remove(string(c.Key))
+			case bucketMeta:
+				if string(c.Key) == metaNextIno && len(c.Value) == 8 {
+					s.inoMu.Lock()
+					s.nextIno = max(s.nextIno, binary.BigEndian.Uint64(c.Value))
+					s.inoMu.Unlock()
+				}
+			}
+		}
+	}
+}
+
+// bootstrapRoot creates the root directory through raft when a replicated
+// MDS first elects a leader, so every member has the same one.
+func (s *Service) bootstrapRoot() {
+	root, err := s.inode(rootInodeID)
+	if err != nil || root != nil {
+		return
+	}
+	if err := s.commits.commit("bootstrap", func(tx *metaTx) error {
+		inodesB := tx.Bucket(bucketInodes)
+		if inodesB == nil {
+			return errors.New("metadata buckets are missing")
+		}
+		if inodesB.Get([]byte(rootInodeID)) != nil {
+			return nil
+		}
+		return putInode(inodesB, s.newRoot())
+	}); err != nil {
+		log.Printf("mds bootstrap root: %v", err)
+	}
+}
+
+// checkLeader lets a write through on a standalone MDS or the leader.
+func (s *Service) checkLeader() error {
+	if s.raft == nil || s.raft.IsLeader() {
+		return nil
+	}
+	leader, _ := s.raft.Leader()
+	return replicationStatus(&raft.NotLeaderError{Leader: leader})
+}
+
+// linearize lets a read through on a standalone MDS, or on the leader once
+// it has confirmed it still leads and applied every write acknowledged
+// before the read arrived.
+func (s *Service) linearize(ctx context.Context) error {
+	if s.raft == nil {
+		return nil
+	}
+	if err := s.raft.ReadIndex(ctx); err != nil {
+		return replicationStatus(err)
+	}
+	return nil
+}
+
+// standby reports whether this MDS is a replica that must leave background
+// work, like garbage collection, to the leader.
+func (s *Service) standby() bool {
+	return s.raft != nil && !s.raft.IsLeader()
+}
+
+// replicationStatus is UNAVAILABLE, with a LeaderHint when another member
+// leads, so clients know where to retry.
+func replicationStatus(err error) error {
+	var notLeader *raft.NotLeaderError
+	switch {
+	case errors.As(err, &notLeader):
+		st := status.New(codes.Unavailable, err.Error())
+		if hinted, herr := st.WithDetails(&protogen.LeaderHint{
+			LeaderId:      notLeader.Leader.ID,
+			LeaderAddress: notLeader.Leader.Address,
+		}); herr == nil {
+			st = hinted
+		}
+		return st.Err()
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	default:
+		return status.Errorf(codes.Unavailable, "replicate metadata: %v", err)
+	}
+}
+
+// persistError reports a failed metadata write. A write that failed to
+// replicate is UNAVAILABLE and may still have been applied.
+func persistError(op string, err error) error {
+	var rerr *replicationError
+	if errors.As(err, &rerr) {
+		return replicationStatus(rerr.err)
+	}
+	return status.Errorf(codes.Internal, "persist %s: %v", op, err)
+}
@@ -0,0 +1,125 @@
+package mds
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+
+	"github.com/rachanaanugandula/kube-pfs/pkg/metrics"
+	protogen "github.com/rachanaanugandula/kube-pfs/pkg/proto/gen"
+	"go.etcd.io/bbolt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	gproto "google.golang.org/protobuf/proto"
+)
+
+// The reply cache lets a client retry a mutating request that carries a
+// RequestID and get the original response back. It lives in bolt and is
+// written in the same transaction as the change, so it is journaled and
+// replicated with it and survives restarts and failovers.
+const (
+	// bucketReplies maps client ID, a zero byte and the big-endian sequence
+	// number to the operation, a zero byte and the marshaled response.
+	bucketReplies = "replies"
+	// bucketReplyOrder maps the big-endian number of each stored reply, in
+	// the order they were stored, to its key in bucketReplies. The oldest
+	// are evicted once there are more than Config.ReplyCacheEntries.
+	bucketReplyOrder = "reply_order"
+	// metaReplyNext is the number the next stored reply gets.
+	metaReplyNext = "reply_next"
+	// defaultReplyCacheEntries is used when Config.ReplyCacheEntries is 0.
+	defaultReplyCacheEntries = 65536
+)
+
+func checkRequestID(id *protogen.RequestID) error {
+	if id == nil {
+		return nil
+	}
+	if id.GetClientId() == "" || strings.Contains(id.GetClientId(), "\x00") || id.GetSeq() == 0 {
+		return status.Error(codes.InvalidArgument, "request_id needs a client_id without NUL bytes and a seq above 0")
+	}
+	return nil
+}
+
+func replyKey(id *protogen.RequestID) []byte {
+	k := make([]byte, 0, len(id.GetClientId())+9)
+	k = append(k, id.GetClientId()...)
+	k = append(k, 0)
+	return binary.BigEndian.AppendUint64(k, id.GetSeq())
+}
+
+// cachedReply returns the stored response to the request, or a nil res if
+// there is none. Callers hold the locks the request would take, so a first
+// attempt still in flight has finished.
+func cachedReply[T gproto.Message](s *Service, op string, id *protogen.RequestID, res T) (T, error) {
+	var none T
+	if id == nil {
+		return none, nil
+	}
+	var stored []byte
+	if err := s.db.View(func(tx *bbolt.Tx) error {
+		if b := tx.Bucket([]byte(bucketReplies)); b != nil {
+			stored = bytes.Clone(b.Get(replyKey(id)))
+		}
+		return nil
+	}); err != nil {
+		return none, metadataError(err)
+	}
+	if stored == nil {
+		return none, nil
+	}
+	storedOp, blob, _ := bytes.Cut(stored, []byte{0})
+	if string(storedOp) != op {
+		return none, status.Errorf(codes.InvalidArgument, "request_id was already used for %s", storedOp)
+	}
+	if err := gproto.Unmarshal(blob, res); err != nil {
+		return none, metadataError(err)
+	}
+	metrics.IncMDSReplayedRequests(op)
+	return res, nil
+}
+
+// putReply stores the response to the request, if it has an ID, and evicts
+// the oldest replies past the limit.
+func (s *Service) putReply(tx *metaTx, op string, id *protogen.RequestID, res gproto.Message) error {
+	if id == nil {
+		return nil
+	}
+	repliesB := tx.Bucket(bucketReplies)
+	orderB := tx.Bucket(bucketReplyOrder)
+	metaB := tx.Bucket(bucketMeta)
+	if repliesB == nil || orderB == nil || metaB == nil {
+		return errors.New("reply cache buckets are missing")
+	}
+	blob, err := gproto.Marshal(res)
+	if err != nil {
+		return err
+	}
+	key := replyKey(id)
+	if err := repliesB.Put(key, append([]byte(op+"\x00"), blob...)); err != nil {
+		return err
+	}
+	next := getUint64(metaB, metaReplyNext)
+	if err := orderB.Put(binary.BigEndian.AppendUint64(nil, next), key); err != nil {
+		return err
+	}
+	if err := putUint64(metaB, metaReplyNext, next+1); err != nil {
+		return err
+	}
+	// Replies are numbered without gaps and evicted from the front, so the
+	// first number tells how many are stored.
+	for {
+		k, v := orderB.bucket.Cursor().First()
+		if k == nil || next+1-binary.BigEndian.Uint64(k) <= uint64(s.replyLimit) {
+			return nil
+		}
+		k, v = bytes.Clone(k), bytes.Clone(v)
+		if err := repliesB.Delete(v); err != nil {
+			return err
+		}
+		if err := orderB.Delete(k); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package mds
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// Snapshot writes a consistent copy of the metadata database to path and
+// returns the sequence number of the last journal record it contains.
+// Restore replays the journal on top of it.
+func (s *Service) Snapshot(path string) (uint64, error) {
+	var seq uint64
+	tmp := path + ".tmp"
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		metaB := tx.Bucket([]byte(bucketMeta))
+		if metaB == nil {
+			return errors.New("meta bucket is missing")
+		}
+		seq = getUint64(metaB, metaJournalSeq)
+		return tx.CopyFile(tmp, 0600)
+	})
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return 0, fmt.Errorf("snapshot metadata: %w", err)
+	}
+	return seq, nil
+}
+
+type SnapshotConfig struct {
+	// Dir receives one mds-<seq>.db file per snapshot.
+	Dir      string
+	Interval time.Duration
+	// Keep is how many snapshots to keep; older ones are deleted and the
+	// journal is trimmed to the oldest one kept. 0 keeps 24.
+	Keep int
+}
+
+const defaultSnapshotKeep = 24
+
+// RunSnapshots snapshots the database every interval until ctx is cancelled.
+func (s *Service) RunSnapshots(ctx context.Context, cfg SnapshotConfig) {
+	if cfg.Keep <= 0 {
+		cfg.Keep = defaultSnapshotKeep
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(cfg.Interval):
+		}
+		tmp := filepath.Join(cfg.Dir, "mds-snapshot.db")
+		seq, err := s.Snapshot(tmp)
+		if err == nil {
+			err = os.Rename(tmp, filepath.Join(cfg.Dir, fmt.Sprintf("mds-%d.db", seq)))
+		}
+		if err != nil {
+			log.Printf("mds snapshot: %v", err)
+			continue
+		}
+		log.Printf("mds snapshot at journal seq %d written to %s", seq, cfg.Dir)
+		if err := s.pruneSnapshots(cfg.Dir, cfg.Keep); err != nil {
+			log.Printf("mds snapshot: %v", err)
+		}
+	}
+}
+
+// pruneSnapshots deletes all but the newest keep snapshots in dir and trims
+// the journal to the oldest one left, which is as far back as a restore can
+// start from.
+func (s *Service) pruneSnapshots(dir string, keep int) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("list snapshots: %w", err)
+	}
+	var seqs []uint64
+	for _, e := range entries {
+		var seq uint64
+		if _, err := fmt.Sscanf(e.Name(), "mds-%d.db", &seq); err == nil && e.Name() == fmt.Sprintf("mds-%d.db", seq) {
+			seqs = append(seqs, seq)
+		}
+	}
+	if len(seqs) == 0 {
+		return nil
+	}
+	slices.Sort(seqs)
+	for len(seqs) > keep {
+		if err := os.Remove(filepath.Join(dir, fmt.Sprintf("mds-%d.db", seqs[0]))); err != nil {
+			return fmt.Errorf("delete old snapshot: %w", err)
+		}
+		seqs = seqs[1:]
+	}
+	if s.journal == nil {
+		return nil
+	}
+	oldest := filepath.Join(dir, fmt.Sprintf("mds-%d.db", seqs[0]))
+	offset, err := snapshotJournalOffset(oldest, s.journal.header.id)
+	if err != nil {
+		return fmt.Errorf("read journal offset of %s: %w", oldest, err)
+	}
+	if offset < 0 {
+		return nil
+	}
+	start := s.journal.start()
+	if err := s.journal.trim(offset); err != nil {
+		return err
+	}
+	if trimmed := s.journal.start(); trimmed > start {
+		log.Printf("mds journal trimmed to offset %d, the oldest snapshot kept", trimmed)
+	}
+	return nil
+}
+
+// snapshotJournalOffset returns how far into the journal with the given ID
+// the snapshot at path has applied, or -1 if it was not journaled to it.
+func snapshotJournalOffset(path string, id []byte) (int64, error) {
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{ReadOnly: true, Timeout: 1 * time.Second})
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+	offset := int64(-1)
+	err = db.View(func(tx *bbolt.Tx) error {
+		metaB := tx.Bucket([]byte(bucketMeta))
+		if metaB == nil {
+			return errors.New("snapshot has no meta bucket")
+		}
+		if bytes.Equal(metaB.Get([]byte(metaJournalID)), id) {
+			offset = int64(getUint64(metaB, metaJournalOffset))
+		}
+		return nil
+	})
+	return offset, err
+}
+
+type RestoreConfig struct {
+	// SnapshotPath is a copy of the database, e.g. written by Snapshot.
+	SnapshotPath string
+	// JournalPath is the journal the database was writing when the snapshot
+	// was taken.
+	JournalPath string
+	// OutPath is where the restored database is written. It must not exist.
+	OutPath string
+	// ToSeq stops the restore after the record with this sequence number;
+	// 0 replays the whole journal.
+	ToSeq uint64
+	// ToTime, if set, stops the restore before the first record written
+	// after it.
+	ToTime time.Time
+}
+
+type RestoreResult struct {
+	// Seq is the sequence number of the last record in the restored database.
+	Seq      uint64
+	Replayed int
+}
+
+// Restore rebuilds the namespace as of a journal sequence number or a point
+// in time from a snapshot plus the journal. The restored database is detached
+// from the journal: the MDS started on it must be given a new, empty journal,
+// and carries on numbering records after Seq and inodes after every inode
+// in the journal.
+func Restore(cfg RestoreConfig) (RestoreResult, error) {
+	if cfg.SnapshotPath == "" || cfg.JournalPath == "" || cfg.OutPath == "" {
+		return RestoreResult{}, errors.New("snapshot, journal and output paths are required")
+	}
+	if err := copyNewFile(cfg.SnapshotPath, cfg.OutPath); err != nil {
+		return RestoreResult{}, fmt.Errorf("copy snapshot: %w", err)
+	}
+	res, err := restoreInto(cfg)
+	if err != nil {
+		os.Remove(cfg.OutPath)
+	}
+	return res, err
+}
+
+func restoreInto(cfg RestoreConfig) (RestoreResult, error) {
+	jf, err := os.Open(cfg.JournalPath)
+	if err != nil {
+		return RestoreResult{}, fmt.Errorf("open journal: %w", err)
+	}
+	defer jf.Close()
+	header, err := readJournalHeader(jf)
+	if err != nil {
+		return RestoreResult{}, err
+	}
+	info, err := jf.Stat()
+	if err != nil {
+		return RestoreResult{}, fmt.Errorf("stat journal: %w", err)
+	}
+	size := header.base + info.Size() - header.size
+	r := header.reader(jf)
+
+	db, err := bbolt.Open(cfg.OutPath, 0600, &bbolt.Options{Timeout: 1 * time.Second})
+	if err != nil {
+		return RestoreResult{}, fmt.Errorf("open restored database: %w", err)
+	}
+	defer db.Close()
+
+	var res RestoreResult
+	err = db.Update(func(tx *bbolt.Tx) error {
+		metaB := tx.Bucket([]byte(bucketMeta))
+		if metaB == nil {
+			return errors.New("snapshot has no meta bucket")
+		}
+		// A snapshot taken before journaling started has no journal ID and
+		// needs the journal from its first record.
+		offset := journalStart
+		if id := metaB.Get([]byte(metaJournalID)); id != nil {
+			if !bytes.Equal(id, header.id) {
+				return errors.New("snapshot was not taken from a database writing this journal")
+			}
+			offset = int64(getUint64(metaB, metaJournalOffset))
+		}
+		if offset < header.base {
+			return fmt.Errorf("journal was trimmed to start at offset %d, after the snapshot's offset %d; restore from a newer snapshot", header.base, offset)
+		}
+		applied := getUint64(metaB, metaJournalSeq)
+		toSeq := cfg.ToSeq
+		if toSeq == 0 {
+			toSeq = ^uint64(0)
+		}
+		if toSeq < applied {
+			return fmt.Errorf("snapshot already contains records up to %d, past %d", applied, toSeq)
+		}
+		// Snapshots taken before requests carried IDs lack the reply cache,
+		// and those taken before sharding the transaction buckets.
+		for _, name := range []string{bucketReplies, bucketReplyOrder, bucketShardTxns, bucketShardPrepared} {
+			if _, err := tx.CreateBucketIfNotExists([]byte(name)); err != nil {
+				return err
+			}
+		}
+		_, replayed, _, err := replayJournal(tx, r, offset, size, applied, toSeq, cfg.ToTime)
+		if err != nil {
+			return err
+		}
+		res = RestoreResult{Seq: getUint64(metaB, metaJournalSeq), Replayed: replayed}
+		// Files created after the restore point may still have chunks on the
+		// OSTs, so their inode numbers must not be handed out again.
+		nextIno := getUint64(metaB, metaNextIno)
+		if _, err := scanJournal(r, offset, size, func(rec journalRecord, _ int64) error {
+			for _, c := range rec.Changes {
+				if c.Bucket == bucketMeta && string(c.Key) == metaNextIno && len(c.Value) == 8 {
+					nextIno = max(nextIno, binary.BigEndian.Uint64(c.Value))
+				}
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		if err := putUint64(metaB, metaNextIno, nextIno); err != nil {
+			return err
+		}
+		if err := metaB.Delete([]byte(metaJournalID)); err != nil {
+			return err
+		}
+		return metaB.Delete([]byte(metaJournalOffset))
+	})
+	return res, err
+}
+
+func copyNewFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
@@ -4,14 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"sort"
+	"slices"
 	"strings"
 	"sync"
-	"sync/atomic"
 	"time"
 
+	"github.com/rachanaanugandula/kube-pfs/pkg/layout"
 	"github.com/rachanaanugandula/kube-pfs/pkg/metrics"
 	protogen "github.com/rachanaanugandula/kube-pfs/pkg/proto/gen"
+	"github.com/rachanaanugandula/kube-pfs/pkg/raft"
 	"go.etcd.io/bbolt"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -22,6 +23,11 @@ const (
 	rootInodeID   = "root"
 	bucketInodes  = "inodes"
 	bucketDirents = "dirents"
+	// bucketPendingDelete holds unlinked files, keyed by inode ID, until the
+	// garbage collector has deleted their chunks from the OSTs.
+	bucketPendingDelete = "pending_delete"
+	// maxInodesExistBatch bounds one InodesExist call.
+	maxInodesExistBatch = 1000
 )
 
 type Config struct {
@@ -29,18 +35,84 @@ type Config struct {
 	OSTIDs          []string
 	DefaultMode     uint64
 	DefaultStripeSz uint32
+	// ReplicaCount is how many OSTs hold each chunk of a new file. It cannot
+	// exceed the number of OSTs; 0 means 1.
+	ReplicaCount uint32
+	// HeartbeatInterval is how often registered OSTs are told to heartbeat.
+	// One that goes HeartbeatTimeout without one is marked down, as is an
+	// OST in OSTIDs that has not registered within HeartbeatTimeout of the
+	// start. They default to 5s and three intervals.
+	HeartbeatInterval time.Duration
+	HeartbeatTimeout  time.Duration
+	// Placement orders the OSTs of new files. Nil means round-robin.
+	Placement PlacementPolicy
+	// Pools defines the named OST pools directory layouts can target, each
+	// with the OSTIDs that belong to it. OSTs that register can join a pool
+	// by name, so a pool may start out empty, but not move out of the pool
+	// they are listed in here.
+	Pools map[string][]string
+	// CacheEntries bounds how many inodes, and separately how many
+	// directory entries, the MDS keeps in memory; the rest are read from
+	// bolt when needed. 0 caches the whole namespace and loads it at startup.
+	CacheEntries int
+	// LockShards is how many locks the namespace is sharded over by inode
+	// ID. Operations in directories on different shards run in parallel.
+	// 0 means 64.
+	LockShards int
+	// CommitMaxBatch and CommitMaxDelay bound the group commit of metadata
+	// writes: a bolt transaction takes the writes already waiting, up to
+	// CommitMaxBatch of them, and waits at most CommitMaxDelay for those
+	// handed over but not yet received. A write with no other in flight
+	// commits at once. They default to 256 and 1ms; a negative delay
+	// commits only what has been received.
+	CommitMaxBatch int
+	CommitMaxDelay time.Duration
+	// JournalPath, if set, is the append-only journal every metadata change
+	// is written to before bolt commits it. It is replayed at startup and,
+	// with a bolt snapshot, lets Restore rebuild the namespace as of any
+	// point in time.
+	JournalPath string
+	// Replication, if set, replicates the journal to the other members of a
+	// raft group. Only the leader serves metadata RPCs.
+	Replication *ReplicationConfig
+	// ReplyCacheEntries bounds how many responses to requests with a
+	// RequestID are kept for replays. 0 means 65536.
+	ReplyCacheEntries int
+	// Sharding, if set, makes the MDS one shard of a namespace split across
+	// several. It cannot be combined with Replication yet.
+	Sharding *ShardingConfig
 }
 
 type Service struct {
 	protogen.UnimplementedMetadataServiceServer
 
-	mu       sync.RWMutex
-	db       *bbolt.DB
-	inodes   map[string]*protogen.Inode
-	dirents  map[string]map[string]string
-	ostIDs   []string
-	stripeSz uint32
-	rr       uint64
+	db        *bbolt.DB
+	journal   *journal
+	commits   *committer
+	raft      *raft.Node
+	shard     *shardState
+	locks     *lockTable
+	osts      *ostTable
+	heartbeat time.Duration
+	stripeSz  uint32
+	replicas  uint32
+	placement PlacementPolicy
+	rootMode  uint64
+	// replyLimit is how many replies the reply cache keeps.
+	replyLimit int
+
+	// renameMu serialises renames between directories, so the chain of
+	// parents a rename checks for loops cannot change under it.
+	renameMu sync.Mutex
+
+	// nextIno is guarded by inoMu; both it and generation, which is fixed
+	// once the service starts, are persisted in bucketMeta.
+	inoMu      sync.Mutex
+	nextIno    uint64
+	generation uint64
+
+	inodeCache  *lruCache[*protogen.Inode]
+	direntCache *lruCache[string]
 }
 
 func NewService(cfg Config) (*Service, error) {
@@ -53,33 +125,151 @@ func NewService(cfg Config) (*Service, error) {
 	if cfg.DefaultMode == 0 {
 		cfg.DefaultMode = 0644
 	}
+	if cfg.ReplicaCount == 0 {
+		cfg.ReplicaCount = 1
+	}
+	if int(cfg.ReplicaCount) > len(cfg.OSTIDs) {
+		return nil, fmt.Errorf("replica count %d needs at least as many OSTs, have %d", cfg.ReplicaCount, len(cfg.OSTIDs))
+	}
+	if cfg.HeartbeatInterval <= 0 {
+		cfg.HeartbeatInterval = defaultHeartbeatInterval
+	}
+	if cfg.HeartbeatTimeout <= 0 {
+		cfg.HeartbeatTimeout = defaultMissedHeartbeats * cfg.HeartbeatInterval
+	}
+	if cfg.Placement == nil {
+		cfg.Placement = &RoundRobinPlacement{}
+	}
+	if cfg.CacheEntries < 0 {
+		return nil, fmt.Errorf("cache entries %d cannot be negative", cfg.CacheEntries)
+	}
+	if cfg.LockShards < 0 {
+		return nil, fmt.Errorf("lock shards %d cannot be negative", cfg.LockShards)
+	}
+	if cfg.LockShards == 0 {
+		cfg.LockShards = defaultLockShards
+	}
+	if cfg.CommitMaxBatch < 0 {
+		return nil, fmt.Errorf("commit max batch %d cannot be negative", cfg.CommitMaxBatch)
+	}
+	if cfg.CommitMaxBatch == 0 {
+		cfg.CommitMaxBatch = defaultCommitMaxBatch
+	}
+	if cfg.CommitMaxDelay == 0 {
+		cfg.CommitMaxDelay = defaultCommitMaxDelay
+	}
+	if cfg.ReplyCacheEntries < 0 {
+		return nil, fmt.Errorf("reply cache entries %d cannot be negative", cfg.ReplyCacheEntries)
+	}
+	if cfg.ReplyCacheEntries == 0 {
+		cfg.ReplyCacheEntries = defaultReplyCacheEntries
+	}
+	var shard *shardState
+	if cfg.Sharding != nil {
+		if cfg.Replication != nil {
+			return nil, errors.New("a sharded mds cannot be replicated yet")
+		}
+		var err error
+		if shard, err = newShardState(*cfg.Sharding); err != nil {
+			return nil, err
+		}
+	}
+	inPool := map[string]string{}
+	for name, members := range cfg.Pools {
+		if name == "" {
+			return nil, fmt.Errorf("pool name cannot be empty")
+		}
+		for _, id := range members {
+			if !slices.Contains(cfg.OSTIDs, id) {
+				return nil, fmt.Errorf("pool %q lists unknown ost %q", name, id)
+			}
+			if other, ok := inPool[id]; ok && other != name {
+				return nil, fmt.Errorf("ost %q is in both pool %q and pool %q", id, other, name)
+			}
+			inPool[id] = name
+		}
+	}
 
 	db, err := bbolt.Open(cfg.BoltPath, 0600, &bbolt.Options{Timeout: 1 * time.Second})
 	if err != nil {
 		return nil, fmt.Errorf("open boltdb: %w", err)
 	}
+	var j *journal
+	if cfg.JournalPath != "" {
+		if j, err = openJournal(cfg.JournalPath); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
 
 	s := &Service{
-		db:       db,
-		inodes:   map[string]*protogen.Inode{},
-		dirents:  map[string]map[string]string{},
-		ostIDs:   append([]string{}, cfg.OSTIDs...),
-		stripeSz: cfg.DefaultStripeSz,
+		db:        db,
+		journal:   j,
+		shard:     shard,
+		locks:     newLockTable(cfg.LockShards),
+		osts:      newOSTTable(cfg.OSTIDs, cfg.Pools, cfg.HeartbeatTimeout),
+		heartbeat: cfg.HeartbeatInterval,
+		stripeSz:  cfg.DefaultStripeSz,
+		replicas:  cfg.ReplicaCount,
+		placement: cfg.Placement,
+		rootMode:  cfg.DefaultMode,
+
+		replyLimit: cfg.ReplyCacheEntries,
+
+		inodeCache:  newLRU[*protogen.Inode](cfg.CacheEntries),
+		direntCache: newLRU[string](cfg.CacheEntries),
 	}
 
-	if err := s.loadOrInitRoot(cfg.DefaultMode); err != nil {
+	// A replicated MDS gets its root from the first leader instead, so every
+	// member has the same one. Of the shards, only the first holds it.
+	createRoot := cfg.Replication == nil && (shard == nil || shard.firstIno() == 0)
+	err = s.loadOrInitRoot(createRoot, cfg.CacheEntries == 0)
+	if err == nil && shard != nil {
+		err = s.loadPrepared()
+	}
+	if err != nil {
+		if j != nil {
+			j.close()
+		}
 		db.Close()
 		return nil, err
 	}
+	var replicate func([]*journalRecord) error
+	if cfg.Replication != nil {
+		replicate = s.replicate
+	}
+	s.commits = newCommitter(db, j, replicate, cfg.CommitMaxBatch, cfg.CommitMaxDelay)
+	if cfg.Replication != nil {
+		if err := s.startReplication(*cfg.Replication); err != nil {
+			s.commits.close()
+			if j != nil {
+				j.close()
+			}
+			db.Close()
+			return nil, err
+		}
+	}
 
 	return s, nil
 }
 
 func (s *Service) Close() error {
+	// Stopping raft first fails the writes waiting on it, so the committer
+	// can drain.
+	if s.raft != nil {
+		s.raft.Stop()
+	}
+	s.commits.close()
+	if s.journal != nil {
+		s.journal.close()
+	}
 	return s.db.Close()
 }
 
-func (s *Service) loadOrInitRoot(defaultMode uint64) error {
+// loadOrInitRoot creates the buckets and, with createRoot, the root directory
+// on first use. With preload it also fills the caches with the whole
+// namespace.
+func (s *Service) loadOrInitRoot(createRoot, preload bool) error {
 	return s.db.Update(func(tx *bbolt.Tx) error {
 		inodesB, err := tx.CreateBucketIfNotExists([]byte(bucketInodes))
 		if err != nil {
@@ -89,72 +279,121 @@ func (s *Service) loadOrInitRoot(defaultMode uint64) error {
 		if err != nil {
 			return err
 		}
-
-		if err := inodesB.ForEach(func(k, v []byte) error {
-			inode := &protogen.Inode{}
-			if err := gproto.Unmarshal(v, inode); err != nil {
+		if _, err := tx.CreateBucketIfNotExists([]byte(bucketPendingDelete)); err != nil {
+			return err
+		}
+		for _, name := range []string{bucketMeta, bucketReplies, bucketReplyOrder, bucketShardTxns, bucketShardPrepared} {
+			if _, err := tx.CreateBucketIfNotExists([]byte(name)); err != nil {
 				return err
 			}
-			s.inodes[string(k)] = inode
-			return nil
-		}); err != nil {
-			return err
 		}
 
-		if err := direntsB.ForEach(func(k, v []byte) error {
-			parts := strings.SplitN(string(k), "\x00", 2)
-			if len(parts) != 2 {
-				return nil
-			}
-			parent, name := parts[0], parts[1]
-			if _, ok := s.dirents[parent]; !ok {
-				s.dirents[parent] = map[string]string{}
+		// Replay before reading the inode counter and before the preload
+		// below, so both see the replayed changes.
+		if s.journal != nil {
+			if err := attachJournal(tx, s.journal); err != nil {
+				return fmt.Errorf("attach journal: %w", err)
 			}
-			s.dirents[parent][name] = string(v)
-			return nil
-		}); err != nil {
-			return err
+		}
+		if err := s.loadInodeCounters(tx); err != nil {
+			return fmt.Errorf("load inode counters: %w", err)
+		}
+		if err := migrateDirLayouts(tx); err != nil {
+			return fmt.Errorf("migrate directory layouts: %w", err)
+		}
+		if s.shard != nil {
+			s.nextIno = max(s.nextIno, s.shard.firstIno())
 		}
 
-		if _, ok := s.inodes[rootInodeID]; ok {
-			if _, ok := s.dirents[rootInodeID]; !ok {
-				s.dirents[rootInodeID] = map[string]string{}
+		if preload {
+			if err := inodesB.ForEach(func(k, v []byte) error {
+				inode := &protogen.Inode{}
+				if err := gproto.Unmarshal(v, inode); err != nil {
+					return err
+				}
+				s.inodeCache.put(string(k), inode)
+				return nil
+			}); err != nil {
+				return err
+			}
+			if err := direntsB.ForEach(func(k, v []byte) error {
+				s.direntCache.put(string(k), string(v))
+				return nil
+			}); err != nil {
+				return err
 			}
-			return nil
 		}
 
-		now := time.Now().Unix()
-		root := &protogen.Inode{
-			InodeId:       rootInodeID,
-			ParentInodeId: "",
-			Name:          "/",
-			IsDir:         true,
-			SizeBytes:     0,
-			Mode:          defaultMode,
-			CreatedUnix:   now,
-			ModifiedUnix:  now,
-			StripeLayout:  &protogen.StripeLayout{StripeSizeBytes: s.stripeSz, OstIds: append([]string{}, s.ostIDs...)},
+		if !createRoot || inodesB.Get([]byte(rootInodeID)) != nil {
+			return nil
 		}
+		root := s.newRoot()
 		if err := putInode(inodesB, root); err != nil {
 			return err
 		}
-		s.inodes[rootInodeID] = root
-		s.dirents[rootInodeID] = map[string]string{}
+		s.inodeCache.put(rootInodeID, root)
 		return nil
 	})
 }
 
-func (s *Service) Create(_ context.Context, req *protogen.CreateRequest) (*protogen.CreateResponse, error) {
-	waitStart := time.Now()
-	s.mu.Lock()
-	metrics.ObserveMDSLockContention(time.Since(waitStart))
-	defer s.mu.Unlock()
+func (s *Service) newRoot() *protogen.Inode {
+	now := time.Now().Unix()
+	return &protogen.Inode{
+		InodeId:       rootInodeID,
+		ParentInodeId: "",
+		Name:          "/",
+		IsDir:         true,
+		SizeBytes:     0,
+		Mode:          s.rootMode,
+		CreatedUnix:   now,
+		ModifiedUnix:  now,
+		StripeLayout:  &protogen.StripeLayout{},
+		Ino:           rootIno,
+		Generation:    s.generation,
+	}
+}
 
+func (s *Service) Create(ctx context.Context, req *protogen.CreateRequest) (*protogen.CreateResponse, error) {
 	if req.GetParentInodeId() == "" || req.GetName() == "" {
 		return nil, status.Error(codes.InvalidArgument, "parent_inode_id and name are required")
 	}
-	parent, ok := s.inodes[req.GetParentInodeId()]
-	if !ok {
+	if err := checkRequestID(req.GetRequestId()); err != nil {
+		return nil, err
+	}
+	if err := s.checkLeader(); err != nil {
+		return nil, err
+	}
+	if err := s.checkHolds(req.GetParentInodeId()); err != nil {
+		return nil, err
+	}
+	// A directory created on another shard is numbered there, and only its
+	// entry is kept here.
+	remote := req.GetShardId()
+	if remote != "" {
+		if s.shard == nil || !s.shard.known(remote) {
+			return nil, status.Errorf(codes.InvalidArgument, "mds shard %q is not in the shard map", remote)
+		}
+		if !req.GetIsDir() {
+			return nil, status.Error(codes.InvalidArgument, "shard_id can only be set on directories")
+		}
+		if remote == s.shard.id {
+			remote = ""
+		}
+	}
+	unlock, err := s.locks.lock(ctx, "create", true, req.GetParentInodeId())
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if res, err := cachedReply(s, "create", req.GetRequestId(), &protogen.CreateResponse{}); res != nil || err != nil {
+		return res, err
+	}
+
+	parent, err := s.inode(req.GetParentInodeId())
+	if err != nil {
+		return nil, metadataError(err)
+	}
+	if parent == nil {
 		return nil, status.Error(codes.NotFound, "parent inode not found")
 	}
 	if !parent.GetIsDir() {
@@ -163,183 +402,602 @@ func (s *Service) Create(_ context.Context, req *protogen.CreateRequest) (*proto
 	if strings.Contains(req.GetName(), "/") {
 		return nil, status.Error(codes.InvalidArgument, "name cannot contain '/'")
 	}
-	if _, ok := s.dirents[parent.GetInodeId()][req.GetName()]; ok {
+	existing, err := s.lookup(parent.GetInodeId(), req.GetName())
+	if err != nil {
+		return nil, metadataError(err)
+	}
+	if existing != "" {
 		return nil, status.Error(codes.AlreadyExists, "entry already exists")
 	}
+	// New entries inherit their parent directory's layout. Erasure coding is
+	// chosen per directory, so only a directory may override it.
+	override := req.GetLayout()
+	if ec := req.GetErasureCode(); ec != nil {
+		if override != nil {
+			return nil, status.Error(codes.InvalidArgument, "set layout.erasure_code instead of erasure_code when passing a layout")
+		}
+		override = &protogen.StripeLayout{ErasureCode: ec}
+	}
+	if override.GetErasureCode() != nil && !req.GetIsDir() {
+		return nil, status.Error(codes.InvalidArgument, "erasure_code can only be set on directories")
+	}
+	stripeLayout := inheritLayout(parent.GetStripeLayout(), override)
+	if override != nil {
+		if err := s.checkLayout(stripeLayout); err != nil {
+			return nil, err
+		}
+	}
+	if !req.GetIsDir() {
+		l, err := s.nextStripeLayout(stripeLayout)
+		if err != nil {
+			return nil, err
+		}
+		stripeLayout = l
+	}
 
 	now := time.Now().Unix()
-	inodeID := fmt.Sprintf("inode-%d", time.Now().UnixNano())
 	inode := &protogen.Inode{
-		InodeId:       inodeID,
 		ParentInodeId: parent.GetInodeId(),
 		Name:          req.GetName(),
 		IsDir:         req.GetIsDir(),
 		Mode:          req.GetMode(),
 		CreatedUnix:   now,
 		ModifiedUnix:  now,
-		StripeLayout:  s.nextStripeLayout(),
+		AccessedUnix:  now,
+		StripeLayout:  stripeLayout,
 	}
 	if inode.GetMode() == 0 {
 		inode.Mode = 0644
 	}
-	if inode.GetIsDir() {
-		inode.StripeLayout = &protogen.StripeLayout{StripeSizeBytes: s.stripeSz, OstIds: append([]string{}, s.ostIDs...)}
-	}
-
-	if _, ok := s.dirents[parent.GetInodeId()]; !ok {
-		s.dirents[parent.GetInodeId()] = map[string]string{}
-	}
-	s.dirents[parent.GetInodeId()][inode.GetName()] = inode.GetInodeId()
-	s.inodes[inode.GetInodeId()] = inode
-	if inode.GetIsDir() {
-		s.dirents[inode.GetInodeId()] = map[string]string{}
+	if remote != "" {
+		return s.createOnShard(remote, inode, req.GetRequestId())
 	}
+	inode.Ino, inode.InodeId = s.allocInode()
+	inode.Generation = s.generation
 
-	if err := s.persistCreate(inode); err != nil {
-		return nil, status.Errorf(codes.Internal, "persist create: %v", err)
+	if err := s.persistCreate(inode, req.GetRequestId()); err != nil {
+		return nil, persistError("create", err)
 	}
+	s.cacheEntry(inode)
 
 	return &protogen.CreateResponse{Inode: cloneInode(inode)}, nil
 }
 
-func (s *Service) Lookup(_ context.Context, req *protogen.LookupRequest) (*protogen.LookupResponse, error) {
-	waitStart := time.Now()
-	s.mu.RLock()
-	metrics.ObserveMDSLockContention(time.Since(waitStart))
-	defer s.mu.RUnlock()
+func (s *Service) Lookup(ctx context.Context, req *protogen.LookupRequest) (*protogen.LookupResponse, error) {
+	if err := s.linearize(ctx); err != nil {
+		return nil, err
+	}
+	if err := s.checkHolds(req.GetParentInodeId()); err != nil {
+		return nil, err
+	}
+	unlock, err := s.locks.lock(ctx, "lookup", false, req.GetParentInodeId())
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
 
-	entries, ok := s.dirents[req.GetParentInodeId()]
-	if !ok {
+	parent, err := s.dirInode(req.GetParentInodeId())
+	if err != nil {
+		return nil, metadataError(err)
+	}
+	if parent == nil {
 		return nil, status.Error(codes.NotFound, "parent inode not found")
 	}
-	inodeID, ok := entries[req.GetName()]
-	if !ok {
+	inodeID, err := s.lookup(parent.GetInodeId(), req.GetName())
+	if err != nil {
+		return nil, metadataError(err)
+	}
+	if inodeID == "" {
 		return nil, status.Error(codes.NotFound, "entry not found")
 	}
-	inode := s.inodes[inodeID]
+	inode, err := s.anyInode(ctx, inodeID)
+	if err != nil {
+		return nil, metadataError(err)
+	}
 	return &protogen.LookupResponse{Inode: cloneInode(inode)}, nil
 }
 
-func (s *Service) Stat(_ context.Context, req *protogen.StatRequest) (*protogen.StatResponse, error) {
-	waitStart := time.Now()
-	s.mu.RLock()
-	metrics.ObserveMDSLockContention(time.Since(waitStart))
-	defer s.mu.RUnlock()
-	inode, ok := s.inodes[req.GetInodeId()]
-	if !ok {
+func (s *Service) Stat(ctx context.Context, req *protogen.StatRequest) (*protogen.StatResponse, error) {
+	if err := s.linearize(ctx); err != nil {
+		return nil, err
+	}
+	if err := s.checkHolds(req.GetInodeId()); err != nil {
+		return nil, err
+	}
+	unlock, err := s.locks.lock(ctx, "stat", false, req.GetInodeId())
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	inode, err := s.inode(req.GetInodeId())
+	if err != nil {
+		return nil, metadataError(err)
+	}
+	if inode == nil {
 		return nil, status.Error(codes.NotFound, "inode not found")
 	}
+	if req.GetGeneration() != 0 && req.GetGeneration() != inode.GetGeneration() {
+		return nil, status.Errorf(codes.NotFound, "stale handle: inode %s is generation %d, not %d", inode.GetInodeId(), inode.GetGeneration(), req.GetGeneration())
+	}
 	return &protogen.StatResponse{Inode: cloneInode(inode)}, nil
 }
 
-func (s *Service) ListDir(_ context.Context, req *protogen.ListDirRequest) (*protogen.ListDirResponse, error) {
-	waitStart := time.Now()
-	s.mu.RLock()
-	metrics.ObserveMDSLockContention(time.Since(waitStart))
-	defer s.mu.RUnlock()
-	children, ok := s.dirents[req.GetInodeId()]
-	if !ok {
-		return nil, status.Error(codes.NotFound, "directory inode not found")
+func (s *Service) InodesExist(ctx context.Context, req *protogen.InodesExistRequest) (*protogen.InodesExistResponse, error) {
+	if len(req.GetInodeIds()) > maxInodesExistBatch {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d inode_ids per call", maxInodesExistBatch)
 	}
+	if err := s.linearize(ctx); err != nil {
+		return nil, err
+	}
+	// Answering for another shard's inodes would report them missing.
+	if err := s.checkHolds(req.GetInodeIds()...); err != nil {
+		return nil, err
+	}
+	// Each inode is read as one snapshot, so no locks are needed to tell
+	// whether it exists.
+	var existing []string
+	for _, id := range req.GetInodeIds() {
+		inode, err := s.inode(id)
+		if err != nil {
+			return nil, metadataError(err)
+		}
+		if inode != nil {
+			existing = append(existing, id)
+		}
+	}
+	return &protogen.InodesExistResponse{Existing: existing}, nil
+}
 
-	names := make([]string, 0, len(children))
-	for name := range children {
-		names = append(names, name)
+func (s *Service) ListDir(ctx context.Context, req *protogen.ListDirRequest) (*protogen.ListDirResponse, error) {
+	if err := s.linearize(ctx); err != nil {
+		return nil, err
+	}
+	if err := s.checkHolds(req.GetInodeId()); err != nil {
+		return nil, err
+	}
+	unlock, err := s.locks.lock(ctx, "listdir", false, req.GetInodeId())
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	dir, err := s.dirInode(req.GetInodeId())
+	if err != nil {
+		return nil, metadataError(err)
+	}
+	if dir == nil {
+		return nil, status.Error(codes.NotFound, "directory inode not found")
+	}
+	children, err := s.children(dir.GetInodeId())
+	if err == nil {
+		children, err = s.withRemoteInodes(ctx, children)
+	}
+	if err != nil {
+		return nil, metadataError(err)
 	}
-	sort.Strings(names)
 
 	entries := make([]*protogen.Inode, 0, len(children))
-	for _, name := range names {
-		inodeID := children[name]
-		inode := s.inodes[inodeID]
-		if inode != nil {
-			entries = append(entries, cloneInode(inode))
-		}
+	for _, inode := range children {
+		entries = append(entries, cloneInode(inode))
 	}
 	return &protogen.ListDirResponse{Entries: entries}, nil
 }
 
-func (s *Service) Unlink(_ context.Context, req *protogen.UnlinkRequest) (*protogen.UnlinkResponse, error) {
-	waitStart := time.Now()
-	s.mu.Lock()
-	metrics.ObserveMDSLockContention(time.Since(waitStart))
-	defer s.mu.Unlock()
+func (s *Service) Unlink(ctx context.Context, req *protogen.UnlinkRequest) (*protogen.UnlinkResponse, error) {
+	if err := checkRequestID(req.GetRequestId()); err != nil {
+		return nil, err
+	}
+	if err := s.checkLeader(); err != nil {
+		return nil, err
+	}
+	if err := s.checkHolds(req.GetParentInodeId()); err != nil {
+		return nil, err
+	}
+	ids, unlock, err := s.lockEntries(ctx, "unlink", entryName{req.GetParentInodeId(), req.GetName()})
+	if err != nil {
+		return nil, metadataError(err)
+	}
+	defer unlock()
+	if res, err := cachedReply(s, "unlink", req.GetRequestId(), &protogen.UnlinkResponse{}); res != nil || err != nil {
+		return res, err
+	}
 
-	children, ok := s.dirents[req.GetParentInodeId()]
-	if !ok {
+	parent, err := s.dirInode(req.GetParentInodeId())
+	if err != nil {
+		return nil, metadataError(err)
+	}
+	if parent == nil {
 		return nil, status.Error(codes.NotFound, "parent inode not found")
 	}
-	inodeID, ok := children[req.GetName()]
-	if !ok {
+	inodeID := ids[0]
+	if inodeID == "" {
 		return &protogen.UnlinkResponse{Deleted: false}, nil
 	}
-	inode := s.inodes[inodeID]
+	if !s.holds(inodeID) {
+		return s.unlinkOnShard(req, inodeID)
+	}
+	inode, err := s.inode(inodeID)
+	if err != nil {
+		return nil, metadataError(err)
+	}
 	if inode == nil {
 		return &protogen.UnlinkResponse{Deleted: false}, nil
 	}
-	if inode.GetIsDir() && len(s.dirents[inode.GetInodeId()]) > 0 {
-		return nil, status.Error(codes.FailedPrecondition, "directory is not empty")
+	if inode.GetIsDir() {
+		nonEmpty, err := s.hasChildren(inode.GetInodeId())
+		if err != nil {
+			return nil, metadataError(err)
+		}
+		if nonEmpty {
+			return nil, status.Error(codes.FailedPrecondition, "directory is not empty")
+		}
 	}
 
-	delete(children, req.GetName())
-	delete(s.inodes, inode.GetInodeId())
-	delete(s.dirents, inode.GetInodeId())
-
-	if err := s.persistUnlink(req.GetParentInodeId(), req.GetName(), inode.GetInodeId()); err != nil {
-		return nil, status.Errorf(codes.Internal, "persist unlink: %v", err)
+	if err := s.persistUnlink(req.GetParentInodeId(), req.GetName(), inode, req.GetRequestId()); err != nil {
+		return nil, persistError("unlink", err)
 	}
+	s.forgetEntry(req.GetParentInodeId(), req.GetName(), inode.GetInodeId())
 
 	return &protogen.UnlinkResponse{Deleted: true}, nil
 }
 
-func (s *Service) nextStripeLayout() *protogen.StripeLayout {
-	if len(s.ostIDs) == 0 {
-		return &protogen.StripeLayout{StripeSizeBytes: s.stripeSz}
+func (s *Service) Rename(ctx context.Context, req *protogen.RenameRequest) (*protogen.RenameResponse, error) {
+	if req.GetSrcParentInodeId() == "" || req.GetSrcName() == "" || req.GetDstParentInodeId() == "" || req.GetDstName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "source and destination parent_inode_id and name are required")
+	}
+	if strings.Contains(req.GetDstName(), "/") {
+		return nil, status.Error(codes.InvalidArgument, "name cannot contain '/'")
+	}
+	if err := checkRequestID(req.GetRequestId()); err != nil {
+		return nil, err
+	}
+	if err := s.checkLeader(); err != nil {
+		return nil, err
+	}
+	if err := s.checkHolds(req.GetSrcParentInodeId()); err != nil {
+		return nil, err
+	}
+	// Like the kernel's rename mutex, this is taken before any shard lock.
+	if req.GetSrcParentInodeId() != req.GetDstParentInodeId() {
+		waitStart := time.Now()
+		s.renameMu.Lock()
+		metrics.ObserveMDSLockContention("rename", "rename", time.Since(waitStart))
+		defer s.renameMu.Unlock()
+	}
+	ids, unlock, err := s.lockEntries(ctx, "rename",
+		entryName{req.GetSrcParentInodeId(), req.GetSrcName()},
+		entryName{req.GetDstParentInodeId(), req.GetDstName()})
+	if err != nil {
+		return nil, metadataError(err)
+	}
+	defer unlock()
+	if res, err := cachedReply(s, "rename", req.GetRequestId(), &protogen.RenameResponse{}); res != nil || err != nil {
+		return res, err
+	}
+
+	for _, parentID := range []string{req.GetSrcParentInodeId(), req.GetDstParentInodeId()} {
+		parent, err := s.anyInode(ctx, parentID)
+		if err != nil {
+			return nil, metadataError(err)
+		}
+		if parent == nil {
+			return nil, status.Error(codes.NotFound, "parent inode not found")
+		}
+		if !parent.GetIsDir() {
+			return nil, status.Error(codes.FailedPrecondition, "parent inode is not a directory")
+		}
+	}
+
+	srcID, dstID := ids[0], ids[1]
+	if srcID == "" {
+		return nil, status.Error(codes.NotFound, "source entry not found")
+	}
+	src, err := s.anyInode(ctx, srcID)
+	if err != nil {
+		return nil, metadataError(err)
+	}
+	if src == nil {
+		return nil, status.Error(codes.NotFound, "source inode not found")
+	}
+	dstExists := dstID != ""
+	var dst *protogen.Inode
+	if dstExists {
+		if dst, err = s.anyInode(ctx, dstID); err != nil {
+			return nil, metadataError(err)
+		}
+	}
+
+	// Renaming an entry onto itself is a successful no-op, same as rename(2).
+	if dstExists && dstID == srcID {
+		return &protogen.RenameResponse{Inode: cloneInode(src)}, nil
+	}
+
+	// The parts of the rename held by other shards are made in a
+	// transaction with them. A destination directory on another shard is
+	// not read here, so an entry it already has is only found when that
+	// shard prepares.
+	cross := !s.holds(req.GetDstParentInodeId()) || !s.holds(srcID) || (dstExists && !s.holds(dstID))
+	if cross && req.GetFlags() == protogen.RenameFlags_RENAME_EXCHANGE {
+		return nil, status.Error(codes.Unimplemented, "exchanging entries across mds shards is not supported")
+	}
+	if cross && src.GetIsDir() && req.GetSrcParentInodeId() != req.GetDstParentInodeId() {
+		return nil, status.Error(codes.Unimplemented, "moving a directory to another directory across mds shards is not supported")
+	}
+
+	switch req.GetFlags() {
+	case protogen.RenameFlags_RENAME_REPLACE:
+		if dst != nil {
+			if src.GetIsDir() != dst.GetIsDir() {
+				return nil, status.Error(codes.FailedPrecondition, "cannot replace a directory with a non-directory or vice versa")
+			}
+			if dst.GetIsDir() {
+				nonEmpty, err := s.hasChildren(dstID)
+				if err != nil {
+					return nil, metadataError(err)
+				}
+				if nonEmpty {
+					return nil, status.Error(codes.FailedPrecondition, "destination directory is not empty")
+				}
+			}
+		}
+	case protogen.RenameFlags_RENAME_NOREPLACE:
+		if dstExists {
+			return nil, status.Error(codes.AlreadyExists, "destination entry already exists")
+		}
+	case protogen.RenameFlags_RENAME_EXCHANGE:
+		if dst == nil {
+			return nil, status.Error(codes.NotFound, "destination entry not found")
+		}
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown rename flags %d", req.GetFlags())
+	}
+
+	exchange := req.GetFlags() == protogen.RenameFlags_RENAME_EXCHANGE
+	loop := false
+	if src.GetIsDir() {
+		loop, err = s.isAncestorLocked(ctx, srcID, req.GetDstParentInodeId())
+	}
+	if err == nil && !loop && exchange && dst.GetIsDir() {
+		loop, err = s.isAncestorLocked(ctx, dstID, req.GetSrcParentInodeId())
+	}
+	if err != nil {
+		return nil, metadataError(err)
 	}
-	start := int(atomic.AddUint64(&s.rr, 1)-1) % len(s.ostIDs)
-	ordered := make([]string, 0, len(s.ostIDs))
-	for i := 0; i < len(s.ostIDs); i++ {
-		ordered = append(ordered, s.ostIDs[(start+i)%len(s.ostIDs)])
+	if loop {
+		return nil, status.Error(codes.InvalidArgument, "cannot move a directory into its own subtree")
 	}
-	return &protogen.StripeLayout{StripeSizeBytes: s.stripeSz, OstIds: ordered}
+	if cross {
+		return s.renameOnShards(req, src, dst)
+	}
+
+	moved := cloneInode(src)
+	moved.ParentInodeId = req.GetDstParentInodeId()
+	moved.Name = req.GetDstName()
+	var swapped, removed *protogen.Inode
+	if exchange {
+		swapped = cloneInode(dst)
+		swapped.ParentInodeId = req.GetSrcParentInodeId()
+		swapped.Name = req.GetSrcName()
+	} else {
+		removed = dst
+	}
+
+	res := &protogen.RenameResponse{Inode: cloneInode(moved), Target: cloneInode(dst)}
+	if swapped != nil {
+		res.Target = cloneInode(swapped)
+	}
+	if err := s.persistRename(req, moved, swapped, removed, res); err != nil {
+		return nil, persistError("rename", err)
+	}
+
+	// Bolt already committed, so the caches can follow without a partial state.
+	s.direntCache.remove(direntKey(req.GetSrcParentInodeId(), req.GetSrcName()))
+	if removed != nil {
+		s.inodeCache.remove(removed.GetInodeId())
+	}
+	s.cacheEntry(moved)
+	if swapped != nil {
+		s.cacheEntry(swapped)
+	}
+	return res, nil
 }
 
-func (s *Service) persistCreate(inode *protogen.Inode) error {
-	return s.db.Update(func(tx *bbolt.Tx) error {
-		inodesB := tx.Bucket([]byte(bucketInodes))
-		direntsB := tx.Bucket([]byte(bucketDirents))
+func (s *Service) SetAttr(ctx context.Context, req *protogen.SetAttrRequest) (*protogen.SetAttrResponse, error) {
+	if err := checkRequestID(req.GetRequestId()); err != nil {
+		return nil, err
+	}
+	if err := s.checkLeader(); err != nil {
+		return nil, err
+	}
+	if err := s.checkHolds(req.GetInodeId()); err != nil {
+		return nil, err
+	}
+	unlock, err := s.locks.lock(ctx, "setattr", true, req.GetInodeId())
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if res, err := cachedReply(s, "setattr", req.GetRequestId(), &protogen.SetAttrResponse{}); res != nil || err != nil {
+		return res, err
+	}
+
+	inode, err := s.inode(req.GetInodeId())
+	if err != nil {
+		return nil, metadataError(err)
+	}
+	if inode == nil {
+		return nil, status.Error(codes.NotFound, "inode not found")
+	}
+	mask := req.GetMask()
+	if mask&^setAttrKnownBits != 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown setattr mask bits %#x", mask&^setAttrKnownBits)
+	}
+
+	updated := cloneInode(inode)
+	var truncated []*protogen.BlockRef
+	if hasSetAttrBit(mask, protogen.SetAttrMask_SETATTR_SIZE) {
+		if inode.GetIsDir() {
+			return nil, status.Error(codes.InvalidArgument, "cannot set size on a directory")
+		}
+		if req.GetSizeBytes() < inode.GetSizeBytes() {
+			truncated = layout.TruncatedBlocks(inode.GetInodeId(), inode.GetStripeLayout(), inode.GetSizeBytes(), req.GetSizeBytes())
+		}
+		// A size change is a data change, so mtime moves unless the caller pins it.
+		if req.GetSizeBytes() != inode.GetSizeBytes() && !hasSetAttrBit(mask, protogen.SetAttrMask_SETATTR_MTIME) {
+			updated.ModifiedUnix = time.Now().Unix()
+		}
+		updated.SizeBytes = req.GetSizeBytes()
+	}
+	if hasSetAttrBit(mask, protogen.SetAttrMask_SETATTR_MODE) {
+		updated.Mode = req.GetMode()
+	}
+	if hasSetAttrBit(mask, protogen.SetAttrMask_SETATTR_MTIME) {
+		updated.ModifiedUnix = req.GetModifiedUnix()
+	}
+	if hasSetAttrBit(mask, protogen.SetAttrMask_SETATTR_ATIME) {
+		updated.AccessedUnix = req.GetAccessedUnix()
+	}
+	if hasSetAttrBit(mask, protogen.SetAttrMask_SETATTR_UID) {
+		updated.Uid = req.GetUid()
+	}
+	if hasSetAttrBit(mask, protogen.SetAttrMask_SETATTR_GID) {
+		updated.Gid = req.GetGid()
+	}
+
+	res := &protogen.SetAttrResponse{Inode: cloneInode(updated), TruncatedBlocks: truncated}
+	if err := s.persistInode("setattr", updated, req.GetRequestId(), res); err != nil {
+		return nil, persistError("setattr", err)
+	}
+	s.inodeCache.put(updated.GetInodeId(), updated)
+
+	return res, nil
+}
+
+const setAttrKnownBits = uint32(protogen.SetAttrMask_SETATTR_SIZE |
+	protogen.SetAttrMask_SETATTR_MODE |
+	protogen.SetAttrMask_SETATTR_MTIME |
+	protogen.SetAttrMask_SETATTR_ATIME |
+	protogen.SetAttrMask_SETATTR_UID |
+	protogen.SetAttrMask_SETATTR_GID)
+
+func hasSetAttrBit(mask uint32, bit protogen.SetAttrMask) bool {
+	return mask&uint32(bit) != 0
+}
+
+// isAncestorLocked reports whether ancestorID is inodeID itself or one of its
+// parents. Callers must hold s.renameMu. Parents held by other shards are
+// read from them, without their locks; those shards only move directories
+// between directories they hold.
+func (s *Service) isAncestorLocked(ctx context.Context, ancestorID, inodeID string) (bool, error) {
+	for id := inodeID; id != ""; {
+		if id == ancestorID {
+			return true, nil
+		}
+		inode, err := s.anyInode(ctx, id)
+		if err != nil || inode == nil {
+			return false, err
+		}
+		id = inode.GetParentInodeId()
+	}
+	return false, nil
+}
+
+// persistCreate writes a new inode, its dirent, the advanced inode counter
+// and the reply to a request with an ID in one transaction. Creates in other
+// directories may share it, so the stored counter only ever moves forward.
+func (s *Service) persistCreate(inode *protogen.Inode, id *protogen.RequestID) error {
+	return s.commits.commit("create", func(tx *metaTx) error {
+		inodesB := tx.Bucket(bucketInodes)
+		direntsB := tx.Bucket(bucketDirents)
 		if inodesB == nil || direntsB == nil {
 			return errors.New("metadata buckets are missing")
 		}
 		if err := putInode(inodesB, inode); err != nil {
 			return err
 		}
+		metaB := tx.Bucket(bucketMeta)
+		if metaB == nil {
+			return errors.New("meta bucket is missing")
+		}
+		if err := putUint64(metaB, metaNextIno, max(getUint64(metaB, metaNextIno), inode.GetIno()+1)); err != nil {
+			return err
+		}
 		key := []byte(inode.GetParentInodeId() + "\x00" + inode.GetName())
 		if err := direntsB.Put(key, []byte(inode.GetInodeId())); err != nil {
 			return err
 		}
-		return nil
+		return s.putReply(tx, "create", id, &protogen.CreateResponse{Inode: inode})
 	})
 }
 
-func (s *Service) persistUnlink(parentInodeID, name, inodeID string) error {
-	return s.db.Update(func(tx *bbolt.Tx) error {
-		inodesB := tx.Bucket([]byte(bucketInodes))
-		direntsB := tx.Bucket([]byte(bucketDirents))
+func (s *Service) persistUnlink(parentInodeID, name string, inode *protogen.Inode, id *protogen.RequestID) error {
+	return s.commits.commit("unlink", func(tx *metaTx) error {
+		inodesB := tx.Bucket(bucketInodes)
+		direntsB := tx.Bucket(bucketDirents)
 		if inodesB == nil || direntsB == nil {
 			return errors.New("metadata buckets are missing")
 		}
-		if err := inodesB.Delete([]byte(inodeID)); err != nil {
+		if err := deleteInode(tx, inodesB, inode); err != nil {
 			return err
 		}
 		if err := direntsB.Delete([]byte(parentInodeID + "\x00" + name)); err != nil {
 			return err
 		}
-		return nil
+		return s.putReply(tx, "unlink", id, &protogen.UnlinkResponse{Deleted: true})
+	})
+}
+
+// persistInode rewrites an inode and stores res as the reply to a request
+// with an ID.
+func (s *Service) persistInode(op string, inode *protogen.Inode, id *protogen.RequestID, res gproto.Message) error {
+	return s.commits.commit(op, func(tx *metaTx) error {
+		inodesB := tx.Bucket(bucketInodes)
+		if inodesB == nil {
+			return errors.New("metadata buckets are missing")
+		}
+		if err := putInode(inodesB, inode); err != nil {
+			return err
+		}
+		return s.putReply(tx, op, id, res)
+	})
+}
+
+// persistRename applies every dirent and inode change of a rename in one bolt
+// transaction so a crash can never leave the entry under both names or neither.
+func (s *Service) persistRename(req *protogen.RenameRequest, moved, swapped, removed *protogen.Inode, res *protogen.RenameResponse) error {
+	return s.commits.commit("rename", func(tx *metaTx) error {
+		inodesB := tx.Bucket(bucketInodes)
+		direntsB := tx.Bucket(bucketDirents)
+		if inodesB == nil || direntsB == nil {
+			return errors.New("metadata buckets are missing")
+		}
+		srcKey := []byte(req.GetSrcParentInodeId() + "\x00" + req.GetSrcName())
+		if swapped != nil {
+			if err := direntsB.Put(srcKey, []byte(swapped.GetInodeId())); err != nil {
+				return err
+			}
+			if err := putInode(inodesB, swapped); err != nil {
+				return err
+			}
+		} else if err := direntsB.Delete(srcKey); err != nil {
+			return err
+		}
+		if removed != nil {
+			if err := deleteInode(tx, inodesB, removed); err != nil {
+				return err
+			}
+		}
+		dstKey := []byte(moved.GetParentInodeId() + "\x00" + moved.GetName())
+		if err := direntsB.Put(dstKey, []byte(moved.GetInodeId())); err != nil {
+			return err
+		}
+		if err := putInode(inodesB, moved); err != nil {
+			return err
+		}
+		return s.putReply(tx, "rename", req.GetRequestId(), res)
 	})
 }
 
-func putInode(bucket *bbolt.Bucket, inode *protogen.Inode) error {
+func putInode(bucket kvBucket, inode *protogen.Inode) error {
 	blob, err := gproto.Marshal(inode)
 	if err != nil {
 		return err
@@ -347,6 +1005,22 @@ func putInode(bucket *bbolt.Bucket, inode *protogen.Inode) error {
 	return bucket.Put([]byte(inode.GetInodeId()), blob)
 }
 
+// deleteInode removes an inode and, for a file, queues its chunks for the
+// garbage collector in the same transaction, so data is never forgotten.
+func deleteInode(tx *metaTx, inodesB *journaledBucket, inode *protogen.Inode) error {
+	if err := inodesB.Delete([]byte(inode.GetInodeId())); err != nil {
+		return err
+	}
+	if inode.GetIsDir() {
+		return nil
+	}
+	pendingB := tx.Bucket(bucketPendingDelete)
+	if pendingB == nil {
+		return errors.New("pending delete bucket is missing")
+	}
+	return putInode(pendingB, inode)
+}
+
 func cloneInode(inode *protogen.Inode) *protogen.Inode {
 	if inode == nil {
 		return nil
@@ -0,0 +1,431 @@
+package mds
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	protogen "github.com/rachanaanugandula/kube-pfs/pkg/proto/gen"
+	"github.com/rachanaanugandula/kube-pfs/pkg/shardmap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultShardTxnTimeout = 10 * time.Second
+	// shardCallTimeout bounds one ShardService call to another shard.
+	shardCallTimeout = 5 * time.Second
+	// shardLockTimeout is how long a shard preparing a transaction waits
+	// for locks and pins held by others before refusing it.
+	shardLockTimeout = time.Second
+)
+
+// ShardDialer returns a client for the shard serving ShardService at address.
+type ShardDialer func(address string) (protogen.ShardServiceClient, error)
+
+type Shard struct {
+	ID      string
+	Address string
+}
+
+// ShardingConfig makes the MDS one shard of a namespace split across
+// several. A shard holds the inodes numbered in its range of the shard map
+// and the entries of the directories among them. Files are created on their
+// parent's shard; a directory goes to another shard when its create names
+// one, and what is created below it lands there too. Clients read the map
+// with GetShardMap and send each call to the shard holding the directory or
+// inode it names. Changes that span shards are two-phase commits over
+// ShardService, coordinated by the shard the call was sent to.
+type ShardingConfig struct {
+	// ShardID names this MDS among Shards, which lists every shard, in map
+	// order, with the gRPC address it serves MetadataService and
+	// ShardService on. The first holds the root. Shards can be added at the
+	// end of the list, but never removed or reordered.
+	ShardID string
+	Shards  []Shard
+	// Dial connects to the other shards.
+	Dial ShardDialer
+	// TxnTimeout is how long a shard keeps the inodes of a transaction it
+	// prepared pinned before asking the coordinator for the outcome. It
+	// keeps them until it gets an answer, however long the coordinator is
+	// down. 0 means 10s.
+	TxnTimeout time.Duration
+}
+
+// shardState is what a sharded MDS knows about the other shards and the
+// transactions in flight between them.
+type shardState struct {
+	id      string
+	shards  []*protogen.MDSShard
+	dial    ShardDialer
+	timeout time.Duration
+
+	mu      sync.Mutex
+	clients map[string]protogen.ShardServiceClient
+	// coordinating holds the transactions this shard is preparing, which
+	// have no outcome yet.
+	coordinating map[string]bool
+	// prepared holds the transactions of other shards this shard has
+	// prepared, with the inodes they pin until the outcome is known.
+	prepared map[string]*preparedTxn
+	txnSeq   uint64
+}
+
+func newShardState(cfg ShardingConfig) (*shardState, error) {
+	if cfg.Dial == nil {
+		return nil, errors.New("sharding needs a dialer for the other shards")
+	}
+	if cfg.TxnTimeout <= 0 {
+		cfg.TxnTimeout = defaultShardTxnTimeout
+	}
+	st := &shardState{
+		id:           cfg.ShardID,
+		dial:         cfg.Dial,
+		timeout:      cfg.TxnTimeout,
+		clients:      map[string]protogen.ShardServiceClient{},
+		coordinating: map[string]bool{},
+		prepared:     map[string]*preparedTxn{},
+	}
+	found := false
+	seen := map[string]bool{}
+	for i, sh := range cfg.Shards {
+		if sh.ID == "" || sh.Address == "" {
+			return nil, fmt.Errorf("shard %d needs an ID and an address", i)
+		}
+		if seen[sh.ID] {
+			return nil, fmt.Errorf("shard %q is listed twice", sh.ID)
+		}
+		seen[sh.ID] = true
+		found = found || sh.ID == cfg.ShardID
+		st.shards = append(st.shards, &protogen.MDSShard{ShardId: sh.ID, Address: sh.Address, FirstIno: shardmap.FirstIno(i)})
+	}
+	if !found {
+		return nil, fmt.Errorf("shard %q is not in the shard map", cfg.ShardID)
+	}
+	return st, nil
+}
+
+// firstIno returns where this shard's inode numbers start.
+func (st *shardState) firstIno() uint64 {
+	for _, sh := range st.shards {
+		if sh.GetShardId() == st.id {
+			return sh.GetFirstIno()
+		}
+	}
+	return 0
+}
+
+func (st *shardState) known(shardID string) bool {
+	for _, sh := range st.shards {
+		if sh.GetShardId() == shardID {
+			return true
+		}
+	}
+	return false
+}
+
+func (st *shardState) client(shardID string) (protogen.ShardServiceClient, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if c, ok := st.clients[shardID]; ok {
+		return c, nil
+	}
+	for _, sh := range st.shards {
+		if sh.GetShardId() != shardID {
+			continue
+		}
+		c, err := st.dial(sh.GetAddress())
+		if err != nil {
+			return nil, fmt.Errorf("dial shard %s: %w", shardID, err)
+		}
+		st.clients[shardID] = c
+		return c, nil
+	}
+	return nil, fmt.Errorf("shard %q is not in the shard map", shardID)
+}
+
+// shardOf returns the shard holding an inode. An MDS that is not sharded,
+// and IDs that are not inode IDs, are taken to be local.
+func (s *Service) shardOf(inodeID string) string {
+	if s.shard == nil {
+		return ""
+	}
+	if owner := shardmap.Owner(s.shard.shards, inodeID); owner != nil {
+		return owner.GetShardId()
+	}
+	return s.shard.id
+}
+
+// holds reports whether this MDS holds the inode.
+func (s *Service) holds(inodeID string) bool {
+	return s.shard == nil || s.shardOf(inodeID) == s.shard.id
+}
+
+// checkHolds turns away a call for an inode or directory held by another
+// shard, which the client should have sent there.
+func (s *Service) checkHolds(inodeIDs ...string) error {
+	for _, id := range inodeIDs {
+		if !s.holds(id) {
+			return status.Errorf(codes.FailedPrecondition, "inode %s is held by mds shard %s", id, s.shardOf(id))
+		}
+	}
+	return nil
+}
+
+// anyInode is inode for an inode that may be held by another shard, such as
+// one an entry here refers to.
+func (s *Service) anyInode(ctx context.Context, inodeID string) (*protogen.Inode, error) {
+	if s.holds(inodeID) {
+		return s.inode(inodeID)
+	}
+	inodes, err := s.remoteInodes(ctx, []string{inodeID})
+	if err != nil {
+		return nil, err
+	}
+	return inodes[inodeID], nil
+}
+
+// remoteInodes reads inodes from the shards holding them. Missing inodes
+// are left out of the map.
+func (s *Service) remoteInodes(ctx context.Context, inodeIDs []string) (map[string]*protogen.Inode, error) {
+	byShard := map[string][]string{}
+	for _, id := range inodeIDs {
+		byShard[s.shardOf(id)] = append(byShard[s.shardOf(id)], id)
+	}
+	inodes := map[string]*protogen.Inode{}
+	for shardID, ids := range byShard {
+		c, err := s.shard.client(shardID)
+		if err != nil {
+			return nil, status.Error(codes.Unavailable, err.Error())
+		}
+		callCtx, cancel := context.WithTimeout(ctx, shardCallTimeout)
+		res, err := c.GetInodes(callCtx, &protogen.GetInodesRequest{InodeIds: ids})
+		cancel()
+		if err != nil {
+			return nil, status.Errorf(codes.Unavailable, "read inodes from mds shard %s: %v", shardID, err)
+		}
+		for _, inode := range res.GetInodes() {
+			inodes[inode.GetInodeId()] = inode
+		}
+	}
+	return inodes, nil
+}
+
+// withRemoteInodes fills in the entries children left as stubs for inodes
+// on other shards, dropping any that no longer exist.
+func (s *Service) withRemoteInodes(ctx context.Context, entries []*protogen.Inode) ([]*protogen.Inode, error) {
+	var remote []string
+	for _, inode := range entries {
+		if !s.holds(inode.GetInodeId()) {
+			remote = append(remote, inode.GetInodeId())
+		}
+	}
+	if len(remote) == 0 {
+		return entries, nil
+	}
+	inodes, err := s.remoteInodes(ctx, remote)
+	if err != nil {
+		return nil, err
+	}
+	out := entries[:0]
+	for _, inode := range entries {
+		if !s.holds(inode.GetInodeId()) {
+			if inode = inodes[inode.GetInodeId()]; inode == nil {
+				continue
+			}
+		}
+		out = append(out, inode)
+	}
+	return out, nil
+}
+
+// GetShardMap lists the shards, or none when the MDS is not sharded.
+func (s *Service) GetShardMap(context.Context, *protogen.GetShardMapRequest) (*protogen.GetShardMapResponse, error) {
+	res := &protogen.GetShardMapResponse{}
+	if s.shard != nil {
+		for _, sh := range s.shard.shards {
+			res.Shards = append(res.Shards, &protogen.MDSShard{ShardId: sh.GetShardId(), Address: sh.GetAddress(), FirstIno: sh.GetFirstIno()})
+		}
+	}
+	return res, nil
+}
+
+// ShardService returns the server other shards call, or nil for an MDS that
+// is not sharded.
+func (s *Service) ShardService() protogen.ShardServiceServer {
+	if s.shard == nil {
+		return nil
+	}
+	return &shardServer{s: s}
+}
+
+type shardServer struct {
+	protogen.UnimplementedShardServiceServer
+	s *Service
+}
+
+func (ss *shardServer) GetInodes(ctx context.Context, req *protogen.GetInodesRequest) (*protogen.GetInodesResponse, error) {
+	res := &protogen.GetInodesResponse{}
+	missing, err := ss.s.readInodes(req.GetInodeIds(), res)
+	if err != nil || len(missing) == 0 {
+		return res, err
+	}
+	// A missing inode may be one a prepared transaction creates, whose entry
+	// the coordinator has already committed. It is read again once the
+	// transaction is settled, rather than reported gone.
+	unlock, err := ss.s.locks.lock(ctx, "getinodes", false, missing...)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if _, err := ss.s.readInodes(missing, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// readInodes adds the inodes this shard holds among inodeIDs to res, and
+// returns the IDs of those it holds but did not find.
+func (s *Service) readInodes(inodeIDs []string, res *protogen.GetInodesResponse) ([]string, error) {
+	var missing []string
+	for _, id := range inodeIDs {
+		if !s.holds(id) {
+			continue
+		}
+		inode, err := s.inode(id)
+		if err != nil {
+			return nil, metadataError(err)
+		}
+		if inode == nil {
+			missing = append(missing, id)
+			continue
+		}
+		res.Inodes = append(res.Inodes, cloneInode(inode))
+	}
+	return missing, nil
+}
+
+// createOnShard is Create for a directory made on another shard. Callers
+// hold the lock of the parent and have checked the name is free.
+func (s *Service) createOnShard(shardID string, inode *protogen.Inode, id *protogen.RequestID) (*protogen.CreateResponse, error) {
+	var res *protogen.CreateResponse
+	if err := s.runShardTxn("create", map[string][]*protogen.ShardChange{
+		shardID: {{CreateInode: inode}},
+	}, func(tx *metaTx, inodes map[string][]*protogen.Inode) error {
+		direntsB := tx.Bucket(bucketDirents)
+		if direntsB == nil {
+			return errors.New("metadata buckets are missing")
+		}
+		created := inodes[shardID][0]
+		if err := direntsB.Put([]byte(direntKey(created.GetParentInodeId(), created.GetName())), []byte(created.GetInodeId())); err != nil {
+			return err
+		}
+		res = &protogen.CreateResponse{Inode: created}
+		return s.putReply(tx, "create", id, res)
+	}); err != nil {
+		return nil, err
+	}
+	created := res.GetInode()
+	s.direntCache.put(direntKey(created.GetParentInodeId(), created.GetName()), created.GetInodeId())
+	return &protogen.CreateResponse{Inode: cloneInode(created)}, nil
+}
+
+// unlinkOnShard is Unlink for an entry whose inode is on another shard,
+// which checks a directory is empty. Callers hold the locks of the entry.
+func (s *Service) unlinkOnShard(req *protogen.UnlinkRequest, inodeID string) (*protogen.UnlinkResponse, error) {
+	key := direntKey(req.GetParentInodeId(), req.GetName())
+	err := s.runShardTxn("unlink", map[string][]*protogen.ShardChange{
+		s.shardOf(inodeID): {{RemoveInodeId: inodeID}},
+	}, func(tx *metaTx, _ map[string][]*protogen.Inode) error {
+		direntsB := tx.Bucket(bucketDirents)
+		if direntsB == nil {
+			return errors.New("metadata buckets are missing")
+		}
+		if err := direntsB.Delete([]byte(key)); err != nil {
+			return err
+		}
+		return s.putReply(tx, "unlink", req.GetRequestId(), &protogen.UnlinkResponse{Deleted: true})
+	})
+	// Like a local entry whose inode is gone, the entry is left alone.
+	if status.Code(err) == codes.NotFound {
+		return &protogen.UnlinkResponse{Deleted: false}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.direntCache.remove(key)
+	return &protogen.UnlinkResponse{Deleted: true}, nil
+}
+
+// renameOnShards is Rename when the source inode, the destination directory
+// or the inode the rename replaces is on another shard. Callers hold the
+// locks Rename takes and have checked the rename can be made.
+func (s *Service) renameOnShards(req *protogen.RenameRequest, src, removed *protogen.Inode) (*protogen.RenameResponse, error) {
+	dstParentID, srcID := req.GetDstParentInodeId(), src.GetInodeId()
+	entry := &protogen.ShardEntry{ParentInodeId: dstParentID, Name: req.GetDstName(), InodeId: srcID}
+	changes := map[string][]*protogen.ShardChange{}
+	if !s.holds(dstParentID) {
+		changes[s.shardOf(dstParentID)] = append(changes[s.shardOf(dstParentID)], &protogen.ShardChange{AddEntry: entry})
+	}
+	srcShard, movedAt := s.shardOf(srcID), -1
+	if !s.holds(srcID) {
+		movedAt = len(changes[srcShard])
+		changes[srcShard] = append(changes[srcShard], &protogen.ShardChange{MoveInode: entry})
+	}
+	if removed != nil && !s.holds(removed.GetInodeId()) {
+		changes[s.shardOf(removed.GetInodeId())] = append(changes[s.shardOf(removed.GetInodeId())], &protogen.ShardChange{RemoveInodeId: removed.GetInodeId()})
+	}
+
+	moved := cloneInode(src)
+	moved.ParentInodeId = dstParentID
+	moved.Name = req.GetDstName()
+	srcKey, dstKey := direntKey(req.GetSrcParentInodeId(), req.GetSrcName()), direntKey(dstParentID, req.GetDstName())
+	res := &protogen.RenameResponse{Target: cloneInode(removed)}
+	err := s.runShardTxn("rename", changes, func(tx *metaTx, inodes map[string][]*protogen.Inode) error {
+		inodesB := tx.Bucket(bucketInodes)
+		direntsB := tx.Bucket(bucketDirents)
+		if inodesB == nil || direntsB == nil {
+			return errors.New("metadata buckets are missing")
+		}
+		if err := direntsB.Delete([]byte(srcKey)); err != nil {
+			return err
+		}
+		if removed != nil && s.holds(removed.GetInodeId()) {
+			if err := deleteInode(tx, inodesB, removed); err != nil {
+				return err
+			}
+		}
+		if s.holds(dstParentID) {
+			if err := direntsB.Put([]byte(dstKey), []byte(srcID)); err != nil {
+				return err
+			}
+		}
+		res.Inode = moved
+		if movedAt >= 0 {
+			res.Inode = inodes[srcShard][movedAt]
+		} else if err := putInode(inodesB, moved); err != nil {
+			return err
+		}
+		return s.putReply(tx, "rename", req.GetRequestId(), res)
+	})
+	if status.Code(err) == codes.AlreadyExists && req.GetFlags() == protogen.RenameFlags_RENAME_REPLACE {
+		return nil, status.Error(codes.FailedPrecondition, "replacing an entry in a directory on another mds shard is not supported")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.direntCache.remove(srcKey)
+	if s.holds(dstParentID) {
+		s.direntCache.put(dstKey, srcID)
+	}
+	if movedAt < 0 {
+		s.inodeCache.put(srcID, moved)
+	}
+	if removed != nil && s.holds(removed.GetInodeId()) {
+		s.inodeCache.remove(removed.GetInodeId())
+	}
+	return &protogen.RenameResponse{Inode: cloneInode(res.GetInode()), Target: cloneInode(res.GetTarget())}, nil
+}
@@ -0,0 +1,619 @@
+package mds
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	protogen "github.com/rachanaanugandula/kube-pfs/pkg/proto/gen"
+	"go.etcd.io/bbolt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	gproto "google.golang.org/protobuf/proto"
+)
+
+// A change that spans shards is committed in two phases. The coordinator
+// asks every other shard involved to prepare its part: that shard locks
+// what the part touches, checks it can be done and stores it as prepared.
+// The coordinator then commits its own part together with a record of the
+// transaction, which is the point the transaction commits, and tells the
+// others to commit theirs. A transaction without a record is presumed
+// aborted, so a coordinator that fails before committing leaves nothing to
+// clean up but the prepared parts, which their shards abort once the
+// coordinator says it has no record of them.
+const (
+	// bucketShardTxns maps the ID of each transaction this shard committed
+	// as coordinator to the JSON list of shards yet to commit their part.
+	bucketShardTxns = "shard_txns"
+	// bucketShardPrepared maps the ID of each transaction this shard has
+	// prepared for another to the marshaled PrepareShardTxnRequest, with
+	// created inodes numbered.
+	bucketShardPrepared = "shard_prepared"
+)
+
+// preparedTxn is a transaction prepared here and the inodes it pins. mu
+// serialises its commit and abort.
+type preparedTxn struct {
+	mu    sync.Mutex
+	done  bool
+	req   *protogen.PrepareShardTxnRequest
+	res   *protogen.PrepareShardTxnResponse
+	unpin func()
+	since time.Time
+}
+
+// runShardTxn prepares changes, by shard ID, on the other shards, then
+// commits local, which applies this shard's part, with the inodes each
+// shard reported. The error is a status to return to the client.
+func (s *Service) runShardTxn(op string, changes map[string][]*protogen.ShardChange, local func(tx *metaTx, inodes map[string][]*protogen.Inode) error) error {
+	st := s.shard
+	st.mu.Lock()
+	st.txnSeq++
+	txnID := fmt.Sprintf("%s-%d-%d", st.id, s.generation, st.txnSeq)
+	st.coordinating[txnID] = true
+	st.mu.Unlock()
+	defer func() {
+		st.mu.Lock()
+		delete(st.coordinating, txnID)
+		st.mu.Unlock()
+	}()
+
+	participants := make([]string, 0, len(changes))
+	for shardID := range changes {
+		participants = append(participants, shardID)
+	}
+	slices.Sort(participants)
+	inodes := map[string][]*protogen.Inode{}
+	for i, shardID := range participants {
+		res, err := callShard(s, shardID, func(ctx context.Context, c protogen.ShardServiceClient) (*protogen.PrepareShardTxnResponse, error) {
+			return c.PrepareShardTxn(ctx, &protogen.PrepareShardTxnRequest{TxnId: txnID, CoordinatorShardId: st.id, Changes: changes[shardID]})
+		})
+		if err != nil {
+			// The failed shard may have prepared before its answer was lost.
+			s.abortShardTxn(txnID, participants[:i+1])
+			se := status.Convert(err)
+			return status.Errorf(se.Code(), "prepare %s on mds shard %s: %s", op, shardID, se.Message())
+		}
+		if len(res.GetInodes()) != len(changes[shardID]) {
+			s.abortShardTxn(txnID, participants[:i+1])
+			return status.Errorf(codes.Internal, "mds shard %s answered %d changes with %d inodes", shardID, len(changes[shardID]), len(res.GetInodes()))
+		}
+		inodes[shardID] = res.GetInodes()
+	}
+
+	record, err := json.Marshal(participants)
+	if err != nil {
+		s.abortShardTxn(txnID, participants)
+		return persistError(op, err)
+	}
+	if err := s.commits.commit(op, func(tx *metaTx) error {
+		txnsB := tx.Bucket(bucketShardTxns)
+		if txnsB == nil {
+			return errors.New("shard transaction bucket is missing")
+		}
+		if err := txnsB.Put([]byte(txnID), record); err != nil {
+			return err
+		}
+		return local(tx, inodes)
+	}); err != nil {
+		s.abortShardTxn(txnID, participants)
+		return persistError(op, err)
+	}
+	// The transaction has committed. Shards that miss the news now are told
+	// again by ResolveShardTxnsOnce.
+	if err := s.finishShardTxn(txnID, participants); err != nil {
+		log.Printf("mds shard txn %s: %v", txnID, err)
+	}
+	return nil
+}
+
+// callShard runs one ShardService call against a shard.
+func callShard[Res any](s *Service, shardID string, call func(context.Context, protogen.ShardServiceClient) (Res, error)) (Res, error) {
+	c, err := s.shard.client(shardID)
+	if err != nil {
+		var zero Res
+		return zero, status.Error(codes.Unavailable, err.Error())
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), shardCallTimeout)
+	defer cancel()
+	return call(ctx, c)
+}
+
+// abortShardTxn tells shards to drop their part of a transaction that will
+// not commit. Shards it misses abort once they ask for the outcome.
+func (s *Service) abortShardTxn(txnID string, shardIDs []string) {
+	for _, shardID := range shardIDs {
+		if _, err := callShard(s, shardID, func(ctx context.Context, c protogen.ShardServiceClient) (*protogen.ShardTxnResponse, error) {
+			return c.AbortShardTxn(ctx, &protogen.ShardTxnRequest{TxnId: txnID})
+		}); err != nil {
+			log.Printf("mds shard txn %s: abort on shard %s: %v", txnID, shardID, err)
+		}
+	}
+}
+
+// finishShardTxn tells the participants of a committed transaction to
+// commit their parts, and forgets it once they all have.
+func (s *Service) finishShardTxn(txnID string, shardIDs []string) error {
+	var errs []error
+	for _, shardID := range shardIDs {
+		if _, err := callShard(s, shardID, func(ctx context.Context, c protogen.ShardServiceClient) (*protogen.ShardTxnResponse, error) {
+			return c.CommitShardTxn(ctx, &protogen.ShardTxnRequest{TxnId: txnID})
+		}); err != nil {
+			errs = append(errs, fmt.Errorf("commit on shard %s: %w", shardID, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	return s.commits.commit("shard_txn_done", func(tx *metaTx) error {
+		txnsB := tx.Bucket(bucketShardTxns)
+		if txnsB == nil {
+			return errors.New("shard transaction bucket is missing")
+		}
+		return txnsB.Delete([]byte(txnID))
+	})
+}
+
+func (ss *shardServer) PrepareShardTxn(_ context.Context, req *protogen.PrepareShardTxnRequest) (*protogen.PrepareShardTxnResponse, error) {
+	s, st := ss.s, ss.s.shard
+	if req.GetTxnId() == "" || len(req.GetChanges()) == 0 || !st.known(req.GetCoordinatorShardId()) {
+		return nil, status.Error(codes.InvalidArgument, "txn_id, a known coordinator_shard_id and changes are required")
+	}
+	st.mu.Lock()
+	p := st.prepared[req.GetTxnId()]
+	st.mu.Unlock()
+	if p != nil {
+		return p.res, nil
+	}
+
+	pinIDs := preparedPins(req)
+	if err := s.checkHolds(pinIDs...); err != nil {
+		return nil, err
+	}
+	unpin, ok := s.locks.pinWithin("shardtxn", shardLockTimeout, pinIDs...)
+	if !ok {
+		return nil, status.Error(codes.Aborted, "entries are busy; try again")
+	}
+	prepared, res, err := s.prepareChanges(req)
+	if err != nil {
+		unpin()
+		return nil, err
+	}
+	// The inodes it creates are pinned too, so they are not read before
+	// they exist once the coordinator has made their entry.
+	unpinCreated := s.locks.pin(createdPins(prepared)...)
+	if err := s.commits.commit("shard_prepare", func(tx *metaTx) error {
+		return s.putPrepared(tx, prepared)
+	}); err != nil {
+		unpinCreated()
+		unpin()
+		return nil, persistError("shard prepare", err)
+	}
+	st.mu.Lock()
+	st.prepared[req.GetTxnId()] = &preparedTxn{req: prepared, res: res, unpin: func() { unpinCreated(); unpin() }, since: time.Now()}
+	st.mu.Unlock()
+	return res, nil
+}
+
+// preparedPins returns the inodes the changes of a transaction touch, which
+// stay pinned until it is settled: the directories it adds entries to and
+// the inodes it moves or removes.
+func preparedPins(req *protogen.PrepareShardTxnRequest) []string {
+	var ids []string
+	for _, c := range req.GetChanges() {
+		switch {
+		case c.GetAddEntry() != nil:
+			ids = append(ids, c.GetAddEntry().GetParentInodeId())
+		case c.GetMoveInode() != nil:
+			ids = append(ids, c.GetMoveInode().GetInodeId())
+		case c.GetRemoveInodeId() != "":
+			ids = append(ids, c.GetRemoveInodeId())
+		}
+	}
+	return ids
+}
+
+// createdPins returns the inodes a prepared transaction creates.
+func createdPins(req *protogen.PrepareShardTxnRequest) []string {
+	var ids []string
+	for _, c := range req.GetChanges() {
+		if c.GetCreateInode() != nil {
+			ids = append(ids, c.GetCreateInode().GetInodeId())
+		}
+	}
+	return ids
+}
+
+// prepareChanges checks every change can be made and numbers the inodes it
+// creates. Callers hold the locks of the entries and inodes it touches.
+func (s *Service) prepareChanges(req *protogen.PrepareShardTxnRequest) (*protogen.PrepareShardTxnRequest, *protogen.PrepareShardTxnResponse, error) {
+	prepared := gproto.Clone(req).(*protogen.PrepareShardTxnRequest)
+	res := &protogen.PrepareShardTxnResponse{}
+	for _, c := range prepared.GetChanges() {
+		var after *protogen.Inode
+		switch {
+		case c.GetCreateInode() != nil:
+			after = c.GetCreateInode()
+			after.Ino, after.InodeId = s.allocInode()
+			after.Generation = s.generation
+		case c.GetAddEntry() != nil:
+			e := c.GetAddEntry()
+			if e.GetName() == "" || strings.Contains(e.GetName(), "/") || e.GetInodeId() == "" {
+				return nil, nil, status.Error(codes.InvalidArgument, "add_entry needs an inode_id and a name without '/'")
+			}
+			parent, err := s.dirInode(e.GetParentInodeId())
+			if err != nil {
+				return nil, nil, metadataError(err)
+			}
+			if parent == nil {
+				return nil, nil, status.Error(codes.NotFound, "parent directory not found")
+			}
+			existing, err := s.lookup(e.GetParentInodeId(), e.GetName())
+			if err != nil {
+				return nil, nil, metadataError(err)
+			}
+			if existing != "" {
+				return nil, nil, status.Error(codes.AlreadyExists, "entry already exists")
+			}
+		case c.GetMoveInode() != nil:
+			e := c.GetMoveInode()
+			inode, err := s.inode(e.GetInodeId())
+			if err != nil {
+				return nil, nil, metadataError(err)
+			}
+			if inode == nil {
+				return nil, nil, status.Error(codes.NotFound, "inode not found")
+			}
+			after = cloneInode(inode)
+			after.ParentInodeId = e.GetParentInodeId()
+			after.Name = e.GetName()
+		case c.GetRemoveInodeId() != "":
+			inode, err := s.inode(c.GetRemoveInodeId())
+			if err != nil {
+				return nil, nil, metadataError(err)
+			}
+			if inode == nil {
+				return nil, nil, status.Error(codes.NotFound, "inode not found")
+			}
+			if inode.GetIsDir() {
+				nonEmpty, err := s.hasChildren(inode.GetInodeId())
+				if err != nil {
+					return nil, nil, metadataError(err)
+				}
+				if nonEmpty {
+					return nil, nil, status.Error(codes.FailedPrecondition, "directory is not empty")
+				}
+			}
+		default:
+			return nil, nil, status.Error(codes.InvalidArgument, "a change must set one field")
+		}
+		if after == nil {
+			after = &protogen.Inode{}
+		}
+		res.Inodes = append(res.Inodes, cloneInode(after))
+	}
+	return prepared, res, nil
+}
+
+// putPrepared stores a prepared transaction, and moves the inode counter
+// past the inodes it creates.
+func (s *Service) putPrepared(tx *metaTx, req *protogen.PrepareShardTxnRequest) error {
+	preparedB := tx.Bucket(bucketShardPrepared)
+	metaB := tx.Bucket(bucketMeta)
+	if preparedB == nil || metaB == nil {
+		return errors.New("shard transaction buckets are missing")
+	}
+	for _, c := range req.GetChanges() {
+		if ino := c.GetCreateInode().GetIno(); ino != 0 {
+			if err := putUint64(metaB, metaNextIno, max(getUint64(metaB, metaNextIno), ino+1)); err != nil {
+				return err
+			}
+		}
+	}
+	blob, err := gproto.Marshal(req)
+	if err != nil {
+		return err
+	}
+	return preparedB.Put([]byte(req.GetTxnId()), blob)
+}
+
+func (ss *shardServer) CommitShardTxn(_ context.Context, req *protogen.ShardTxnRequest) (*protogen.ShardTxnResponse, error) {
+	if err := ss.s.finishPrepared(req.GetTxnId(), true); err != nil {
+		return nil, persistError("shard commit", err)
+	}
+	return &protogen.ShardTxnResponse{}, nil
+}
+
+func (ss *shardServer) AbortShardTxn(_ context.Context, req *protogen.ShardTxnRequest) (*protogen.ShardTxnResponse, error) {
+	if err := ss.s.finishPrepared(req.GetTxnId(), false); err != nil {
+		return nil, persistError("shard abort", err)
+	}
+	return &protogen.ShardTxnResponse{}, nil
+}
+
+// ResolveShardTxn answers from this shard's view as coordinator: a
+// transaction it is still preparing is pending, one it has a record of
+// committed, and any other aborted. The record is only deleted once every
+// participant has committed, and a participant never asks about a
+// transaction it no longer holds prepared.
+func (ss *shardServer) ResolveShardTxn(_ context.Context, req *protogen.ShardTxnRequest) (*protogen.ResolveShardTxnResponse, error) {
+	st := ss.s.shard
+	st.mu.Lock()
+	pending := st.coordinating[req.GetTxnId()]
+	st.mu.Unlock()
+	if pending {
+		return &protogen.ResolveShardTxnResponse{State: protogen.ShardTxnState_SHARD_TXN_PENDING}, nil
+	}
+	// Checked after coordinating: the record is written before the
+	// transaction leaves it.
+	committed := false
+	if err := ss.s.db.View(func(tx *bbolt.Tx) error {
+		if b := tx.Bucket([]byte(bucketShardTxns)); b != nil {
+			committed = b.Get([]byte(req.GetTxnId())) != nil
+		}
+		return nil
+	}); err != nil {
+		return nil, metadataError(err)
+	}
+	if committed {
+		return &protogen.ResolveShardTxnResponse{State: protogen.ShardTxnState_SHARD_TXN_COMMITTED}, nil
+	}
+	return &protogen.ResolveShardTxnResponse{State: protogen.ShardTxnState_SHARD_TXN_ABORTED}, nil
+}
+
+// finishPrepared commits or aborts a transaction prepared here and unpins
+// its inodes. Unknown transactions have already finished, or were never
+// prepared, and are left alone.
+func (s *Service) finishPrepared(txnID string, commit bool) error {
+	st := s.shard
+	st.mu.Lock()
+	p := st.prepared[txnID]
+	st.mu.Unlock()
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.done {
+		return nil
+	}
+	op := "shard_abort"
+	if commit {
+		op = "shard_commit"
+	}
+	var written []*protogen.Inode
+	var removed []string
+	if err := s.commits.commit(op, func(tx *metaTx) error {
+		preparedB := tx.Bucket(bucketShardPrepared)
+		inodesB := tx.Bucket(bucketInodes)
+		direntsB := tx.Bucket(bucketDirents)
+		if preparedB == nil || inodesB == nil || direntsB == nil {
+			return errors.New("metadata buckets are missing")
+		}
+		if commit {
+			var err error
+			if written, removed, err = applyPrepared(tx, inodesB, direntsB, p.req); err != nil {
+				return err
+			}
+		}
+		return preparedB.Delete([]byte(txnID))
+	}); err != nil {
+		return err
+	}
+
+	if commit {
+		for _, inode := range written {
+			s.inodeCache.put(inode.GetInodeId(), inode)
+		}
+		for _, id := range removed {
+			s.inodeCache.remove(id)
+		}
+		for _, c := range p.req.GetChanges() {
+			if e := c.GetAddEntry(); e != nil {
+				s.direntCache.put(direntKey(e.GetParentInodeId(), e.GetName()), e.GetInodeId())
+			}
+		}
+	}
+	p.done = true
+	st.mu.Lock()
+	delete(st.prepared, txnID)
+	st.mu.Unlock()
+	p.unpin()
+	return nil
+}
+
+// applyPrepared makes the changes of a prepared transaction. It returns
+// the inodes it wrote and the IDs of those it removed.
+func applyPrepared(tx *metaTx, inodesB, direntsB *journaledBucket, req *protogen.PrepareShardTxnRequest) ([]*protogen.Inode, []string, error) {
+	var written []*protogen.Inode
+	var removed []string
+	for _, c := range req.GetChanges() {
+		switch {
+		case c.GetCreateInode() != nil:
+			if err := putInode(inodesB, c.GetCreateInode()); err != nil {
+				return nil, nil, err
+			}
+			written = append(written, cloneInode(c.GetCreateInode()))
+		case c.GetMoveInode() != nil:
+			moved, err := movedInode(inodesB, c.GetMoveInode())
+			if err != nil || moved == nil {
+				return nil, nil, err
+			}
+			if err := putInode(inodesB, moved); err != nil {
+				return nil, nil, err
+			}
+			written = append(written, moved)
+		case c.GetAddEntry() != nil:
+			e := c.GetAddEntry()
+			if err := direntsB.Put([]byte(direntKey(e.GetParentInodeId(), e.GetName())), []byte(e.GetInodeId())); err != nil {
+				return nil, nil, err
+			}
+		case c.GetRemoveInodeId() != "":
+			inode, err := storedInode(inodesB, c.GetRemoveInodeId())
+			if err != nil {
+				return nil, nil, err
+			}
+			if inode == nil {
+				continue
+			}
+			if err := deleteInode(tx, inodesB, inode); err != nil {
+				return nil, nil, err
+			}
+			removed = append(removed, inode.GetInodeId())
+		}
+	}
+	return written, removed, nil
+}
+
+// movedInode returns the inode a move_inode change leaves, or nil if the
+// inode is gone.
+func movedInode(inodesB kvBucket, e *protogen.ShardEntry) (*protogen.Inode, error) {
+	inode, err := storedInode(inodesB, e.GetInodeId())
+	if err != nil || inode == nil {
+		return nil, err
+	}
+	inode.ParentInodeId = e.GetParentInodeId()
+	inode.Name = e.GetName()
+	return inode, nil
+}
+
+func storedInode(inodesB kvBucket, inodeID string) (*protogen.Inode, error) {
+	v := inodesB.Get([]byte(inodeID))
+	if v == nil {
+		return nil, nil
+	}
+	inode := &protogen.Inode{}
+	if err := gproto.Unmarshal(v, inode); err != nil {
+		return nil, err
+	}
+	return inode, nil
+}
+
+// loadPrepared pins again the inodes of the transactions this shard had
+// prepared when it stopped, and gives them a fresh timeout.
+func (s *Service) loadPrepared() error {
+	return s.db.View(func(tx *bbolt.Tx) error {
+		preparedB := tx.Bucket([]byte(bucketShardPrepared))
+		inodesB := tx.Bucket([]byte(bucketInodes))
+		if preparedB == nil || inodesB == nil {
+			return errors.New("shard transaction buckets are missing")
+		}
+		return preparedB.ForEach(func(k, v []byte) error {
+			req := &protogen.PrepareShardTxnRequest{}
+			if err := gproto.Unmarshal(v, req); err != nil {
+				return err
+			}
+			res := &protogen.PrepareShardTxnResponse{}
+			for _, c := range req.GetChanges() {
+				after := &protogen.Inode{}
+				switch {
+				case c.GetCreateInode() != nil:
+					after = cloneInode(c.GetCreateInode())
+				case c.GetMoveInode() != nil:
+					moved, err := movedInode(inodesB, c.GetMoveInode())
+					if err != nil {
+						return err
+					}
+					if moved != nil {
+						after = moved
+					}
+				}
+				res.Inodes = append(res.Inodes, after)
+			}
+			s.shard.prepared[req.GetTxnId()] = &preparedTxn{
+				req:   req,
+				res:   res,
+				unpin: s.locks.pin(append(preparedPins(req), createdPins(req)...)...),
+				since: time.Now(),
+			}
+			return nil
+		})
+	})
+}
+
+// ResolveShardTxnsOnce settles transactions left unfinished: as coordinator
+// it tells participants again to commit, and as participant it asks the
+// coordinator about transactions prepared longer ago than TxnTimeout.
+func (s *Service) ResolveShardTxnsOnce(ctx context.Context) error {
+	if s.shard == nil {
+		return nil
+	}
+	committed := map[string][]string{}
+	if err := s.db.View(func(tx *bbolt.Tx) error {
+		txnsB := tx.Bucket([]byte(bucketShardTxns))
+		if txnsB == nil {
+			return errors.New("shard transaction bucket is missing")
+		}
+		return txnsB.ForEach(func(k, v []byte) error {
+			var participants []string
+			if err := json.Unmarshal(v, &participants); err != nil {
+				return fmt.Errorf("decode shard txn %s: %w", k, err)
+			}
+			committed[string(bytes.Clone(k))] = participants
+			return nil
+		})
+	}); err != nil {
+		return err
+	}
+	var errs []error
+	for txnID, participants := range committed {
+		if err := s.finishShardTxn(txnID, participants); err != nil {
+			errs = append(errs, fmt.Errorf("finish shard txn %s: %w", txnID, err))
+		}
+	}
+
+	st := s.shard
+	st.mu.Lock()
+	var stale []*preparedTxn
+	for _, p := range st.prepared {
+		if time.Since(p.since) >= st.timeout {
+			stale = append(stale, p)
+		}
+	}
+	st.mu.Unlock()
+	for _, p := range stale {
+		if ctx.Err() != nil {
+			break
+		}
+		txnID := p.req.GetTxnId()
+		res, err := callShard(s, p.req.GetCoordinatorShardId(), func(ctx context.Context, c protogen.ShardServiceClient) (*protogen.ResolveShardTxnResponse, error) {
+			return c.ResolveShardTxn(ctx, &protogen.ShardTxnRequest{TxnId: txnID})
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("resolve shard txn %s: %w", txnID, err))
+			continue
+		}
+		switch res.GetState() {
+		case protogen.ShardTxnState_SHARD_TXN_COMMITTED:
+			err = s.finishPrepared(txnID, true)
+		case protogen.ShardTxnState_SHARD_TXN_ABORTED:
+			err = s.finishPrepared(txnID, false)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("finish prepared shard txn %s: %w", txnID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// RunShardResolver calls ResolveShardTxnsOnce every interval until ctx is
+// cancelled.
+func (s *Service) RunShardResolver(ctx context.Context, interval time.Duration) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+		if err := s.ResolveShardTxnsOnce(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("mds shard resolver: %v", err)
+		}
+	}
+}
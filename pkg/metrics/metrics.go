@@ -29,12 +29,79 @@ var (
 		Help: "Total IO operations observed",
 	}, []string{"component", "node", "op"})
 
-	mdsLockContention = promauto.NewHistogram(prometheus.HistogramOpts{
+	checksumErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "pfs_checksum_errors_total",
+		Help: "Blocks that failed checksum verification",
+	}, []string{"component", "node"})
+
+	scrubBlocksScanned = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "pfs_ost_scrub_blocks_scanned_total",
+		Help: "Blocks verified by the OST scrubber",
+	}, []string{"node"})
+
+	scrubBytesScanned = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "pfs_ost_scrub_bytes_scanned_total",
+		Help: "Bytes verified by the OST scrubber",
+	}, []string{"node"})
+
+	scrubErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "pfs_ost_scrub_errors_total",
+		Help: "Corrupt or unreadable blocks found by the OST scrubber",
+	}, []string{"node"})
+
+	scrubLastFullPassUnix = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "pfs_ost_scrub_last_full_pass_unix",
+		Help: "Unix timestamp of the last completed scrub pass",
+	}, []string{"node"})
+
+	quarantinedBlocks = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "pfs_ost_quarantined_blocks",
+		Help: "Blocks currently listed as corrupt on the OST",
+	}, []string{"node"})
+
+	mdsLockContention = promauto.NewHistogramVec(prometheus.HistogramOpts{
 		Name:    "pfs_mds_lock_contention_seconds",
-		Help:    "Observed wait time before MDS lock acquisition",
+		Help:    "Observed wait time before MDS lock acquisition by lock shard and operation",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"shard", "op"})
+
+	mdsCommitBatchSize = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "pfs_mds_commit_batch_size",
+		Help:    "Metadata writes group-committed per bolt transaction",
+		Buckets: prometheus.ExponentialBuckets(1, 2, 10),
+	})
+
+	mdsCommitLatency = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "pfs_mds_commit_seconds",
+		Help:    "Time to commit and fsync one batch of metadata writes",
 		Buckets: prometheus.DefBuckets,
 	})
 
+	gcPendingInodes = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "pfs_mds_gc_pending_inodes",
+		Help: "Unlinked files whose chunks are still waiting to be deleted",
+	})
+
+	gcPendingBlocks = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "pfs_mds_gc_pending_blocks",
+		Help: "Chunk copies of unlinked files still waiting to be deleted",
+	})
+
+	gcBlocksDeleted = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "pfs_mds_gc_blocks_deleted_total",
+		Help: "Chunk copies of unlinked files deleted from the OSTs",
+	})
+
+	gcErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "pfs_mds_gc_errors_total",
+		Help: "Failed chunk deletions, retried on a later pass",
+	})
+
+	mdsReplayedRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "pfs_mds_replayed_requests_total",
+		Help: "Repeated metadata requests answered from the reply cache by operation",
+	}, []string{"op"})
+
 	csiOpsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "pfs_csi_operations_total",
 		Help: "Count of CSI operations by operation and result",
@@ -66,8 +133,51 @@ func IncIOPS(component, node, op string) {
 	iopsTotal.WithLabelValues(component, node, op).Inc()
 }
 
-func ObserveMDSLockContention(d time.Duration) {
-	mdsLockContention.Observe(d.Seconds())
+func IncChecksumErrors(component, node string) {
+	checksumErrors.WithLabelValues(component, node).Inc()
+}
+
+func ObserveScrubBlock(node string, bytes uint64) {
+	scrubBlocksScanned.WithLabelValues(node).Inc()
+	scrubBytesScanned.WithLabelValues(node).Add(float64(bytes))
+}
+
+func IncScrubErrors(node string) {
+	scrubErrors.WithLabelValues(node).Inc()
+}
+
+func SetScrubLastFullPass(node string, when time.Time) {
+	scrubLastFullPassUnix.WithLabelValues(node).Set(float64(when.Unix()))
+}
+
+func SetQuarantinedBlocks(node string, n int) {
+	quarantinedBlocks.WithLabelValues(node).Set(float64(n))
+}
+
+func ObserveMDSLockContention(shard, op string, d time.Duration) {
+	mdsLockContention.WithLabelValues(shard, op).Observe(d.Seconds())
+}
+
+func ObserveMDSCommit(batch int, d time.Duration) {
+	mdsCommitBatchSize.Observe(float64(batch))
+	mdsCommitLatency.Observe(d.Seconds())
+}
+
+func SetGCBacklog(inodes, blocks int) {
+	gcPendingInodes.Set(float64(inodes))
+	gcPendingBlocks.Set(float64(blocks))
+}
+
+func AddGCBlocksDeleted(n int) {
+	gcBlocksDeleted.Add(float64(n))
+}
+
+func IncGCErrors() {
+	gcErrors.Inc()
+}
+
+func IncMDSReplayedRequests(op string) {
+	mdsReplayedRequests.WithLabelValues(op).Inc()
 }
 
 func IncCSIOp(operation, result string) {
@@ -0,0 +1,232 @@
+package ost
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// Checksums are kept per 64 KiB sector so a small in-place write only
+// rehashes the sectors it touches and a read only verifies what it returns.
+const checksumSectorSize = 64 * 1024
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+var errChecksumMismatch = errors.New("checksum mismatch")
+
+// blockChecksums is the content of a .crc sidecar: the block length the
+// checksums were computed for, followed by one CRC32C per sector.
+type blockChecksums struct {
+	length  uint64
+	sectors []uint32
+}
+
+func checksumPath(blockPath string) string {
+	return strings.TrimSuffix(blockPath, ".blk") + ".crc"
+}
+
+// pendingSuffix names the checksums a write parks next to the block while it
+// renames the block into place. While they exist the block may be the old
+// or the new one, and whichever checksums verify it tell which.
+const pendingSuffix = ".crc.next"
+
+func pendingChecksumPath(blockPath string) string {
+	return strings.TrimSuffix(blockPath, ".blk") + pendingSuffix
+}
+
+func crc32c(data []byte) uint32 {
+	return crc32.Checksum(data, crc32cTable)
+}
+
+// loadChecksums returns nil, nil for blocks written before checksums
+// existed; those are served unverified. Parked checksums left by an
+// interrupted write win if the block verifies against them.
+func loadChecksums(blockPath string) (*blockChecksums, error) {
+	if next, err := readChecksums(pendingChecksumPath(blockPath)); err == nil && next != nil {
+		if _, err := verifyAgainst(blockPath, next); err == nil {
+			return next, nil
+		}
+	}
+	return readChecksums(checksumPath(blockPath))
+}
+
+// settleChecksums finishes or undoes a write that stopped between parking
+// its checksums and moving them into place: the parked checksums replace
+// the old ones if the block verifies against them and are dropped
+// otherwise. The caller holds the block's write lock, or runs before the
+// service accepts requests.
+func settleChecksums(blockPath string) error {
+	pending := pendingChecksumPath(blockPath)
+	next, err := readChecksums(pending)
+	switch {
+	case next == nil && err == nil:
+		return nil
+	case err != nil && !errors.Is(err, errChecksumMismatch):
+		return err
+	case err == nil:
+		_, verr := verifyAgainst(blockPath, next)
+		if verr == nil {
+			return os.Rename(pending, checksumPath(blockPath))
+		}
+		if !errors.Is(verr, errChecksumMismatch) && !errors.Is(verr, fs.ErrNotExist) {
+			return verr
+		}
+	}
+	return os.Remove(pending)
+}
+
+func readChecksums(path string) (*blockChecksums, error) {
+	blob, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if len(blob) < 8 || (len(blob)-8)%4 != 0 {
+		return nil, fmt.Errorf("%w: malformed checksum file", errChecksumMismatch)
+	}
+	cs := &blockChecksums{length: binary.BigEndian.Uint64(blob[:8])}
+	for off := 8; off < len(blob); off += 4 {
+		cs.sectors = append(cs.sectors, binary.BigEndian.Uint32(blob[off:]))
+	}
+	return cs, nil
+}
+
+func (cs *blockChecksums) encode() []byte {
+	blob := make([]byte, 8+4*len(cs.sectors))
+	binary.BigEndian.PutUint64(blob, cs.length)
+	for i, sum := range cs.sectors {
+		binary.BigEndian.PutUint32(blob[8+4*i:], sum)
+	}
+	return blob
+}
+
+func sectorCount(length uint64) uint64 {
+	return (length + checksumSectorSize - 1) / checksumSectorSize
+}
+
+// rehash recomputes the checksums of every sector overlapping [from, to)
+// after the block was resized to length.
+func (cs *blockChecksums) rehash(r io.ReaderAt, from, to, length uint64) error {
+	sectors := make([]uint32, sectorCount(length))
+	copy(sectors, cs.sectors)
+	cs.sectors = sectors
+	cs.length = length
+	if to > length {
+		to = length
+	}
+	buf := make([]byte, checksumSectorSize)
+	for i := from / checksumSectorSize; i*checksumSectorSize < to; i++ {
+		data, err := readSector(r, buf, i, length)
+		if err != nil {
+			return err
+		}
+		cs.sectors[i] = crc32c(data)
+	}
+	return nil
+}
+
+// readVerified reads [off, off+n) from a block, verifying every sector it
+// overlaps. cs may be nil for legacy blocks.
+func readVerified(r io.ReaderAt, cs *blockChecksums, length, off, n uint64) ([]byte, error) {
+	if off >= length || n == 0 {
+		return []byte{}, nil
+	}
+	if off+n > length {
+		n = length - off
+	}
+	if cs == nil {
+		out := make([]byte, n)
+		if _, err := r.ReadAt(out, int64(off)); err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		return out, nil
+	}
+	if cs.length != length || uint64(len(cs.sectors)) != sectorCount(length) {
+		return nil, fmt.Errorf("%w: block is %d bytes, checksums cover %d", errChecksumMismatch, length, cs.length)
+	}
+
+	out := make([]byte, 0, n)
+	buf := make([]byte, checksumSectorSize)
+	for i := off / checksumSectorSize; i*checksumSectorSize < off+n; i++ {
+		data, err := readSector(r, buf, i, length)
+		if err != nil {
+			return nil, err
+		}
+		if crc32c(data) != cs.sectors[i] {
+			return nil, fmt.Errorf("%w in sector %d", errChecksumMismatch, i)
+		}
+		lo, hi := i*checksumSectorSize, i*checksumSectorSize+uint64(len(data))
+		if lo < off {
+			data = data[off-lo:]
+		}
+		if hi > off+n {
+			data = data[:uint64(len(data))-(hi-(off+n))]
+		}
+		out = append(out, data...)
+	}
+	return out, nil
+}
+
+func readSector(r io.ReaderAt, buf []byte, index, length uint64) ([]byte, error) {
+	start := index * checksumSectorSize
+	size := uint64(checksumSectorSize)
+	if start+size > length {
+		size = length - start
+	}
+	data := buf[:size]
+	if _, err := r.ReadAt(data, int64(start)); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return data, nil
+}
+
+// verifyBlock checks every sector of the block at path and returns the number
+// of bytes read. checked is false for legacy blocks without a sidecar.
+func verifyBlock(path string) (scanned uint64, checked bool, err error) {
+	cs, err := loadChecksums(path)
+	if err != nil {
+		return 0, true, err
+	}
+	if cs == nil {
+		return 0, false, nil
+	}
+	scanned, err = verifyAgainst(path, cs)
+	return scanned, true, err
+}
+
+// verifyAgainst checks every sector of the block at path against cs and
+// returns the number of bytes read.
+func verifyAgainst(path string, cs *blockChecksums) (scanned uint64, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	length := uint64(info.Size())
+	if cs.length != length || uint64(len(cs.sectors)) != sectorCount(length) {
+		return 0, fmt.Errorf("%w: block is %d bytes, checksums cover %d", errChecksumMismatch, length, cs.length)
+	}
+	buf := make([]byte, checksumSectorSize)
+	for i := range cs.sectors {
+		data, err := readSector(f, buf, uint64(i), length)
+		if err != nil {
+			return scanned, err
+		}
+		scanned += uint64(len(data))
+		if crc32c(data) != cs.sectors[i] {
+			return scanned, fmt.Errorf("%w in sector %d", errChecksumMismatch, i)
+		}
+	}
+	return scanned, nil
+}
@@ -0,0 +1,118 @@
+package ost
+
+import (
+	"context"
+	"log"
+	"syscall"
+	"time"
+
+	protogen "github.com/rachanaanugandula/kube-pfs/pkg/proto/gen"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// registerRetry is how long the heartbeater waits before retrying a failed
+// registration, e.g. while the MDS is still starting.
+const registerRetry = 2 * time.Second
+
+type HeartbeatConfig struct {
+	// Address is the gRPC address the MDS hands out for this OST.
+	Address string
+	// Pool is the MDS pool the OST joins, e.g. "fast" or "capacity". Empty
+	// leaves it to the MDS configuration.
+	Pool string
+}
+
+// Heartbeater registers the OST with the MDS and keeps it marked up there.
+type Heartbeater struct {
+	svc *Service
+	mds protogen.MetadataServiceClient
+	cfg HeartbeatConfig
+}
+
+func NewHeartbeater(svc *Service, mds protogen.MetadataServiceClient, cfg HeartbeatConfig) *Heartbeater {
+	return &Heartbeater{svc: svc, mds: mds, cfg: cfg}
+}
+
+// Capacity reports the size of the filesystem holding the data directory and
+// how much of it is still available to the OST.
+func (s *Service) Capacity() (total, free uint64, err error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(s.dataDir, &st); err != nil {
+		return 0, 0, err
+	}
+	return uint64(st.Blocks) * uint64(st.Bsize), uint64(st.Bavail) * uint64(st.Bsize), nil
+}
+
+// Run registers and then heartbeats at the interval the MDS asks for until
+// ctx is cancelled. A heartbeat the MDS does not recognise, as after an MDS
+// restart, makes it register again.
+func (h *Heartbeater) Run(ctx context.Context) {
+	for {
+		interval, err := h.register(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("ost(%s) register with mds failed: %v", h.svc.ostID, err)
+			if !sleepCtx(ctx, registerRetry) {
+				return
+			}
+			continue
+		}
+		log.Printf("ost(%s) registered with mds as %s, heartbeat every %s", h.svc.ostID, h.cfg.Address, interval)
+		for sleepCtx(ctx, interval) {
+			err := h.heartbeat(ctx)
+			if status.Code(err) == codes.NotFound {
+				break
+			}
+			if err != nil && ctx.Err() == nil {
+				log.Printf("ost(%s) heartbeat failed: %v", h.svc.ostID, err)
+			}
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+func (h *Heartbeater) register(ctx context.Context) (time.Duration, error) {
+	total, free, err := h.svc.Capacity()
+	if err != nil {
+		return 0, err
+	}
+	res, err := h.mds.RegisterOST(ctx, &protogen.RegisterOSTRequest{
+		OstId:         h.svc.ostID,
+		Address:       h.cfg.Address,
+		Pool:          h.cfg.Pool,
+		CapacityBytes: total,
+		FreeBytes:     free,
+	})
+	if err != nil {
+		return 0, err
+	}
+	interval := time.Duration(res.GetHeartbeatIntervalMs()) * time.Millisecond
+	if interval <= 0 {
+		interval = registerRetry
+	}
+	return interval, nil
+}
+
+func (h *Heartbeater) heartbeat(ctx context.Context) error {
+	total, free, err := h.svc.Capacity()
+	if err != nil {
+		return err
+	}
+	_, err = h.mds.Heartbeat(ctx, &protogen.HeartbeatRequest{OstId: h.svc.ostID, CapacityBytes: total, FreeBytes: free})
+	return err
+}
+
+// sleepCtx waits for d and reports false if ctx was cancelled first.
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
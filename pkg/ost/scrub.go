@@ -0,0 +1,152 @@
+package ost
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"log"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rachanaanugandula/kube-pfs/pkg/metrics"
+	protogen "github.com/rachanaanugandula/kube-pfs/pkg/proto/gen"
+)
+
+const (
+	defaultScrubRate     = 32 * 1024 * 1024
+	defaultScrubInterval = time.Hour
+)
+
+type ScrubConfig struct {
+	// RateBytesPerSec caps how fast the scrubber reads so it does not compete
+	// with client I/O for disk bandwidth.
+	RateBytesPerSec int64
+	// Interval is the pause between the end of one pass and the next.
+	Interval time.Duration
+}
+
+type ScrubStats struct {
+	BlocksScanned uint64
+	BytesScanned  uint64
+	Errors        uint64
+}
+
+// Scrubber walks the OST data directory and re-verifies every block against
+// its checksums, quarantining the ones that fail.
+type Scrubber struct {
+	svc *Service
+	cfg ScrubConfig
+}
+
+func NewScrubber(svc *Service, cfg ScrubConfig) *Scrubber {
+	if cfg.RateBytesPerSec <= 0 {
+		cfg.RateBytesPerSec = defaultScrubRate
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultScrubInterval
+	}
+	return &Scrubber{svc: svc, cfg: cfg}
+}
+
+// Run scrubs until ctx is cancelled.
+func (sc *Scrubber) Run(ctx context.Context) {
+	for {
+		stats, err := sc.ScrubOnce(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("ost(%s) scrub pass failed: %v", sc.svc.ostID, err)
+		} else {
+			log.Printf("ost(%s) scrub pass done: blocks=%d bytes=%d errors=%d", sc.svc.ostID, stats.BlocksScanned, stats.BytesScanned, stats.Errors)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(sc.cfg.Interval):
+		}
+	}
+}
+
+// ScrubOnce makes one full pass over the data directory.
+func (sc *Scrubber) ScrubOnce(ctx context.Context) (ScrubStats, error) {
+	var stats ScrubStats
+	started := time.Now()
+	err := filepath.WalkDir(sc.svc.dataDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if d.IsDir() && path != sc.svc.dataDir && strings.HasPrefix(d.Name(), ".") {
+			// Hidden directories such as a sweeper trash hold no live blocks.
+			return fs.SkipDir
+		}
+		ref, ok := sc.blockRef(path, d)
+		if !ok {
+			return nil
+		}
+		unlock := sc.svc.blockLocks.rlock(path)
+		scanned, checked, verr := verifyBlock(path)
+		unlock()
+		if !checked {
+			return nil
+		}
+		stats.BlocksScanned++
+		stats.BytesScanned += scanned
+		metrics.ObserveScrubBlock(sc.svc.ostID, scanned)
+		switch {
+		case errors.Is(verr, errChecksumMismatch):
+			stats.Errors++
+			metrics.IncScrubErrors(sc.svc.ostID)
+			sc.svc.quarantineBlock(ref, detectedByScrub, verr.Error())
+		case errors.Is(verr, fs.ErrNotExist):
+			// Deleted between the directory listing and the read.
+		case verr != nil:
+			stats.Errors++
+			metrics.IncScrubErrors(sc.svc.ostID)
+			log.Printf("ost(%s) scrub could not read %s: %v", sc.svc.ostID, path, verr)
+		default:
+			sc.svc.releaseBlock(ref)
+		}
+		return sc.throttle(ctx, started, stats.BytesScanned)
+	})
+	if err != nil {
+		return stats, err
+	}
+	metrics.SetScrubLastFullPass(sc.svc.ostID, time.Now())
+	return stats, nil
+}
+
+// blockRef maps <dataDir>/<fileID>/<chunk>.blk back to the block it stores.
+// In-flight temp files never parse as a chunk number and are skipped.
+func (sc *Scrubber) blockRef(path string, d fs.DirEntry) (*protogen.BlockRef, bool) {
+	if d.IsDir() || !strings.HasSuffix(d.Name(), ".blk") {
+		return nil, false
+	}
+	chunkID, err := strconv.ParseUint(strings.TrimSuffix(d.Name(), ".blk"), 10, 64)
+	if err != nil {
+		return nil, false
+	}
+	fileID := filepath.Base(filepath.Dir(path))
+	return &protogen.BlockRef{FileId: fileID, ChunkId: chunkID, OstId: sc.svc.ostID}, true
+}
+
+// throttle sleeps long enough to keep the pass at or under the configured
+// byte rate.
+func (sc *Scrubber) throttle(ctx context.Context, started time.Time, scanned uint64) error {
+	due := time.Duration(float64(scanned) / float64(sc.cfg.RateBytesPerSec) * float64(time.Second))
+	wait := due - time.Since(started)
+	if wait <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(wait):
+		return nil
+	}
+}
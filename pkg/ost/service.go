@@ -4,9 +4,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"log"
+	"math"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -14,8 +21,17 @@ import (
 	protogen "github.com/rachanaanugandula/kube-pfs/pkg/proto/gen"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
+// streamFrameSize bounds how much of a block ReadBlockStream holds in memory
+// and sends per message.
+const streamFrameSize = 4 * checksumSectorSize
+
+// defaultMaxBlockBytes leaves room for a stripe of the largest size the
+// layout can name plus an erasure-coded cell's header.
+const defaultMaxBlockBytes = 8 << 30
+
 type Service struct {
 	protogen.UnimplementedObjectStorageServiceServer
 
@@ -24,19 +40,64 @@ type Service struct {
 	iopsTotal  atomic.Uint64
 	bytesTotal atomic.Uint64
 	latencyNS  atomic.Uint64
+
+	syncMode      SyncMode
+	maxBlockBytes uint64
+	blockLocks    blockLocks
+
+	quarantineMu sync.Mutex
+	quarantine   map[string]*protogen.CorruptBlock
+}
+
+type Config struct {
+	OSTID   string
+	DataDir string
+	// SyncMode defaults to SyncFull.
+	SyncMode SyncMode
+	// MaxBlockBytes bounds how far into a block a write may reach, so a
+	// stray offset cannot make a huge sparse block. 0 means 8 GiB.
+	MaxBlockBytes uint64
 }
 
 func NewService(ostID, dataDir string) (*Service, error) {
-	if ostID == "" {
+	return NewServiceWithConfig(Config{OSTID: ostID, DataDir: dataDir})
+}
+
+func NewServiceWithConfig(cfg Config) (*Service, error) {
+	if cfg.OSTID == "" {
 		return nil, errors.New("ost id is required")
 	}
-	if dataDir == "" {
+	if cfg.DataDir == "" {
 		return nil, errors.New("data dir is required")
 	}
-	if err := os.MkdirAll(dataDir, 0755); err != nil {
+	mode, err := ParseSyncMode(string(cfg.SyncMode))
+	if err != nil {
+		return nil, err
+	}
+	cfg.SyncMode = mode
+	if cfg.MaxBlockBytes == 0 {
+		cfg.MaxBlockBytes = defaultMaxBlockBytes
+	}
+	if cfg.MaxBlockBytes > math.MaxInt64 {
+		return nil, fmt.Errorf("max block size %d is beyond the largest file offset", cfg.MaxBlockBytes)
+	}
+	if err := os.MkdirAll(cfg.DataDir, 0755); err != nil {
 		return nil, fmt.Errorf("create data dir: %w", err)
 	}
-	return &Service{ostID: ostID, dataDir: dataDir}, nil
+	if err := recoverWrites(cfg.OSTID, cfg.DataDir); err != nil {
+		return nil, fmt.Errorf("recover interrupted writes: %w", err)
+	}
+	s := &Service{
+		ostID:         cfg.OSTID,
+		dataDir:       cfg.DataDir,
+		syncMode:      cfg.SyncMode,
+		maxBlockBytes: cfg.MaxBlockBytes,
+		quarantine:    map[string]*protogen.CorruptBlock{},
+	}
+	if err := s.loadQuarantine(); err != nil {
+		return nil, fmt.Errorf("load quarantined blocks: %w", err)
+	}
+	return s, nil
 }
 
 func (s *Service) WriteBlock(_ context.Context, req *protogen.WriteBlockRequest) (*protogen.WriteBlockResponse, error) {
@@ -46,14 +107,30 @@ func (s *Service) WriteBlock(_ context.Context, req *protogen.WriteBlockRequest)
 	if req.GetBlock() == nil {
 		return nil, status.Error(codes.InvalidArgument, "block is required")
 	}
-	path := s.blockPath(req.GetBlock())
-	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
-		return nil, status.Errorf(codes.Internal, "mkdir block parent: %v", err)
+	if req.Crc32C != nil && crc32c(req.GetData()) != req.GetCrc32C() {
+		s.recordChecksumError(req.GetBlock(), "client checksum mismatch on write")
+		return nil, status.Error(codes.DataLoss, "data does not match client checksum")
 	}
-	if err := os.WriteFile(path, req.GetData(), 0644); err != nil {
+	if err := s.checkExtent(req.GetOffset(), uint64(len(req.GetData()))); err != nil {
+		return nil, err
+	}
+	bw, err := s.openBlockForWrite(req.GetBlock())
+	if err != nil {
+		return nil, err
+	}
+	defer bw.discard()
+
+	if _, err := bw.f.WriteAt(req.GetData(), int64(req.GetOffset())); err != nil {
 		return nil, status.Errorf(codes.Internal, "write block: %v", err)
 	}
-	return &protogen.WriteBlockResponse{BytesWritten: uint64(len(req.GetData()))}, nil
+	length, err := bw.finish(req.GetOffset(), req.GetOffset()+uint64(len(req.GetData())), req.GetTruncate())
+	if err != nil {
+		return nil, err
+	}
+	if err := bw.commit(); err != nil {
+		return nil, err
+	}
+	return &protogen.WriteBlockResponse{BytesWritten: uint64(len(req.GetData())), BlockLength: length}, nil
 }
 
 func (s *Service) ReadBlock(_ context.Context, req *protogen.ReadBlockRequest) (*protogen.ReadBlockResponse, error) {
@@ -63,21 +140,15 @@ func (s *Service) ReadBlock(_ context.Context, req *protogen.ReadBlockRequest) (
 	if req.GetBlock() == nil {
 		return nil, status.Error(codes.InvalidArgument, "block is required")
 	}
-	path := s.blockPath(req.GetBlock())
-	blob, err := os.ReadFile(path)
+	br, err := s.openBlockForRead(req.GetBlock())
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, status.Error(codes.NotFound, "block not found")
-		}
-		return nil, status.Errorf(codes.Internal, "read block: %v", err)
-	}
-	offset := req.GetOffset()
-	if offset > uint64(len(blob)) {
-		return &protogen.ReadBlockResponse{Data: []byte{}}, nil
+		return nil, err
 	}
-	blob = blob[offset:]
-	if req.GetLength() > 0 && req.GetLength() < uint64(len(blob)) {
-		blob = blob[:req.GetLength()]
+	defer br.close()
+
+	blob, err := br.read(req.GetOffset(), br.span(req.GetOffset(), req.GetLength()))
+	if err != nil {
+		return nil, err
 	}
 	metrics.AddReadThroughput("ost", s.ostID, len(blob))
 	return &protogen.ReadBlockResponse{Data: blob}, nil
@@ -91,6 +162,7 @@ func (s *Service) DeleteBlock(_ context.Context, req *protogen.DeleteBlockReques
 		return nil, status.Error(codes.InvalidArgument, "block is required")
 	}
 	path := s.blockPath(req.GetBlock())
+	defer s.blockLocks.lock(path)()
 	err := os.Remove(path)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -98,18 +170,496 @@ func (s *Service) DeleteBlock(_ context.Context, req *protogen.DeleteBlockReques
 		}
 		return nil, status.Errorf(codes.Internal, "delete block: %v", err)
 	}
+	for _, p := range []string{checksumPath(path), pendingChecksumPath(path)} {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			return nil, status.Errorf(codes.Internal, "delete block checksums: %v", err)
+		}
+	}
+	s.releaseBlock(req.GetBlock())
 	return &protogen.DeleteBlockResponse{Deleted: true}, nil
 }
 
+func (s *Service) ListCorruptBlocks(context.Context, *protogen.ListCorruptBlocksRequest) (*protogen.ListCorruptBlocksResponse, error) {
+	s.quarantineMu.Lock()
+	defer s.quarantineMu.Unlock()
+	blocks := make([]*protogen.CorruptBlock, 0, len(s.quarantine))
+	for _, block := range s.quarantine {
+		blocks = append(blocks, proto.Clone(block).(*protogen.CorruptBlock))
+	}
+	sort.Slice(blocks, func(i, j int) bool {
+		a, b := blocks[i].GetBlock(), blocks[j].GetBlock()
+		if a.GetFileId() != b.GetFileId() {
+			return a.GetFileId() < b.GetFileId()
+		}
+		return a.GetChunkId() < b.GetChunkId()
+	})
+	return &protogen.ListCorruptBlocksResponse{Blocks: blocks}, nil
+}
+
+func (s *Service) WriteBlockStream(stream protogen.ObjectStorageService_WriteBlockStreamServer) error {
+	start := time.Now()
+	var written uint64
+	defer func() { s.observe("write", int(written), start) }()
+
+	first, err := stream.Recv()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return status.Error(codes.InvalidArgument, "block is required")
+		}
+		return err
+	}
+	if first.GetBlock() == nil {
+		return status.Error(codes.InvalidArgument, "block is required")
+	}
+	if err := s.checkExtent(first.GetOffset(), uint64(len(first.GetData()))); err != nil {
+		return err
+	}
+	bw, err := s.openBlockForWrite(first.GetBlock())
+	if err != nil {
+		return err
+	}
+	// A stream that breaks part way only ever touched the temp copy.
+	defer bw.discard()
+
+	offset := first.GetOffset()
+	sum := crc32.New(crc32cTable)
+	for msg := first; ; {
+		if err := s.checkExtent(offset+written, uint64(len(msg.GetData()))); err != nil {
+			return err
+		}
+		n, err := bw.f.WriteAt(msg.GetData(), int64(offset+written))
+		sum.Write(msg.GetData()[:n])
+		written += uint64(n)
+		if err != nil {
+			return status.Errorf(codes.Internal, "write block: %v", err)
+		}
+		msg, err = stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if (msg.GetBlock() != nil && !proto.Equal(msg.GetBlock(), first.GetBlock())) || (msg.GetOffset() != 0 && msg.GetOffset() != offset) {
+			return status.Error(codes.InvalidArgument, "later stream messages must not change the block or offset")
+		}
+	}
+	if first.Crc32C != nil && sum.Sum32() != first.GetCrc32C() {
+		s.recordChecksumError(first.GetBlock(), "client checksum mismatch on stream write")
+		return status.Error(codes.DataLoss, "streamed data does not match client checksum")
+	}
+	length, err := bw.finish(offset, offset+written, first.GetTruncate())
+	if err != nil {
+		return err
+	}
+	if err := bw.commit(); err != nil {
+		return err
+	}
+	return stream.SendAndClose(&protogen.WriteBlockResponse{BytesWritten: written, BlockLength: length})
+}
+
+func (s *Service) ReadBlockStream(req *protogen.ReadBlockRequest, stream protogen.ObjectStorageService_ReadBlockStreamServer) error {
+	start := time.Now()
+	defer s.observe("read", 0, start)
+
+	if req.GetBlock() == nil {
+		return status.Error(codes.InvalidArgument, "block is required")
+	}
+	br, err := s.openBlockForRead(req.GetBlock())
+	if err != nil {
+		return err
+	}
+	defer br.close()
+
+	pos, end := req.GetOffset(), req.GetOffset()+br.span(req.GetOffset(), req.GetLength())
+	for pos < end {
+		// Frames end on frame boundaries so each sector is verified once.
+		n := streamFrameSize - pos%streamFrameSize
+		if n > end-pos {
+			n = end - pos
+		}
+		data, err := br.read(pos, n)
+		if err != nil {
+			return err
+		}
+		metrics.AddReadThroughput("ost", s.ostID, len(data))
+		if err := stream.Send(&protogen.ReadBlockResponse{Data: data}); err != nil {
+			return err
+		}
+		pos += n
+	}
+	return nil
+}
+
+// checkExtent rejects a write of n bytes at offset that would reach past
+// the largest block the OST keeps, including one whose end overflows.
+func (s *Service) checkExtent(offset, n uint64) error {
+	if offset > s.maxBlockBytes || n > s.maxBlockBytes-offset {
+		return status.Errorf(codes.InvalidArgument, "write of %d bytes at offset %d passes the %d byte block size limit", n, offset, s.maxBlockBytes)
+	}
+	return nil
+}
+
 func (s *Service) GetHealth(context.Context, *protogen.HealthRequest) (*protogen.HealthResponse, error) {
 	return &protogen.HealthResponse{
 		OstId:           s.ostID,
 		Healthy:         true,
 		IopsTotal:       s.iopsTotal.Load(),
 		ThroughputBytes: s.bytesTotal.Load(),
+		LatencyTotalNs:  s.latencyNS.Load(),
 	}, nil
 }
 
+// blockWriter stages a write in a temp copy of the block, so the block on
+// disk is either entirely old or entirely new no matter when the OST dies.
+type blockWriter struct {
+	svc       *Service
+	ref       *protogen.BlockRef
+	path      string
+	f         *os.File
+	cs        *blockChecksums
+	oldLength uint64
+	// replaced is set when the write covers every byte of the block, so
+	// none of its old contents, corrupt or not, survive it.
+	replaced bool
+	unlock   func()
+	done     bool
+}
+
+// openBlockForWrite locks the block and returns a writer over a temp file
+// holding a copy of its current contents, creating the file directory on
+// first use. Copying the whole block is the price of partial writes never
+// being torn.
+func (s *Service) openBlockForWrite(ref *protogen.BlockRef) (*blockWriter, error) {
+	path := s.blockPath(ref)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, status.Errorf(codes.Internal, "mkdir block parent: %v", err)
+	}
+	unlock := s.blockLocks.lock(path)
+	// A write that failed after renaming its block leaves the checksums
+	// parked; they must be in place before this write parks its own.
+	if err := settleChecksums(path); err != nil {
+		unlock()
+		return nil, status.Errorf(codes.Internal, "settle block checksums: %v", err)
+	}
+	tmp, err := createTemp(path)
+	if err != nil {
+		unlock()
+		return nil, status.Errorf(codes.Internal, "create temp block: %v", err)
+	}
+	bw := &blockWriter{svc: s, ref: ref, path: path, f: tmp, unlock: unlock}
+
+	old, err := os.Open(path)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		bw.discard()
+		return nil, status.Errorf(codes.Internal, "open block: %v", err)
+	default:
+		n, err := io.Copy(tmp, old)
+		old.Close()
+		if err != nil {
+			bw.discard()
+			return nil, status.Errorf(codes.Internal, "copy block: %v", err)
+		}
+		bw.oldLength = uint64(n)
+	}
+	return bw, nil
+}
+
+// finish applies the optional truncate at end, rehashes the sectors touched
+// by a write of [offset, end) and returns the resulting block length.
+func (bw *blockWriter) finish(offset, end uint64, truncate bool) (uint64, error) {
+	length := bw.oldLength
+	if truncate {
+		if err := bw.f.Truncate(int64(end)); err != nil {
+			return 0, status.Errorf(codes.Internal, "truncate block: %v", err)
+		}
+		length = end
+	} else if end > length {
+		length = end
+	}
+	bw.replaced = offset == 0 && end >= length
+
+	cs, err := loadChecksums(bw.path)
+	from, to := min(offset, bw.oldLength), end
+	if err != nil || cs == nil {
+		// Legacy or unreadable sidecar: start over from the bytes on disk.
+		cs, from, to = &blockChecksums{}, 0, length
+	}
+	if length != bw.oldLength {
+		to = max(to, length)
+	}
+	if err := cs.rehash(bw.f, from, to, length); err != nil {
+		return 0, status.Errorf(codes.Internal, "checksum block: %v", err)
+	}
+	bw.cs = cs
+	return length, nil
+}
+
+// commit makes the staged block and its checksums durable according to the
+// sync mode and moves both into place. The checksums are parked under their
+// pending name before the block is renamed and only then replace the old
+// ones, so wherever a crash or a failed rename stops, the block on disk
+// verifies against one of the two and settleChecksums keeps that one. A
+// write that replaced the whole block takes it out of quarantine.
+func (bw *blockWriter) commit() error {
+	mode := bw.svc.syncMode
+	csTmp, err := createTemp(checksumPath(bw.path))
+	if err != nil {
+		return status.Errorf(codes.Internal, "create temp checksums: %v", err)
+	}
+	defer os.Remove(csTmp.Name())
+	if _, err := csTmp.Write(bw.cs.encode()); err != nil {
+		csTmp.Close()
+		return status.Errorf(codes.Internal, "write block checksums: %v", err)
+	}
+	if mode != SyncNone {
+		if err := csTmp.Sync(); err != nil {
+			csTmp.Close()
+			return status.Errorf(codes.Internal, "sync block checksums: %v", err)
+		}
+		if err := bw.f.Sync(); err != nil {
+			csTmp.Close()
+			return status.Errorf(codes.Internal, "sync block: %v", err)
+		}
+	}
+	if err := csTmp.Close(); err != nil {
+		return status.Errorf(codes.Internal, "close block checksums: %v", err)
+	}
+	if err := bw.f.Close(); err != nil {
+		return status.Errorf(codes.Internal, "close block: %v", err)
+	}
+
+	pending := pendingChecksumPath(bw.path)
+	if err := os.Rename(csTmp.Name(), pending); err != nil {
+		return status.Errorf(codes.Internal, "park block checksums: %v", err)
+	}
+	if mode == SyncFull {
+		// The parked checksums must be on disk before the block they
+		// describe can be.
+		if err := syncDir(filepath.Dir(bw.path)); err != nil {
+			_ = os.Remove(pending)
+			return status.Errorf(codes.Internal, "sync block directory: %v", err)
+		}
+	}
+	if err := os.Rename(bw.f.Name(), bw.path); err != nil {
+		_ = os.Remove(pending)
+		return status.Errorf(codes.Internal, "rename block: %v", err)
+	}
+	bw.done = true
+	if err := os.Rename(pending, checksumPath(bw.path)); err != nil {
+		return status.Errorf(codes.Internal, "rename block checksums: %v", err)
+	}
+	if bw.replaced {
+		bw.svc.releaseBlock(bw.ref)
+	}
+	if mode == SyncFull {
+		if err := syncDir(filepath.Dir(bw.path)); err != nil {
+			return status.Errorf(codes.Internal, "sync block directory: %v", err)
+		}
+	}
+	return nil
+}
+
+// discard drops the temp copy unless it was committed and releases the
+// block lock. It is safe to call more than once.
+func (bw *blockWriter) discard() {
+	if bw.unlock == nil {
+		return
+	}
+	bw.f.Close()
+	if !bw.done {
+		_ = os.Remove(bw.f.Name())
+	}
+	bw.unlock()
+	bw.unlock = nil
+}
+
+// blockReader is an open block with its checksums loaded for verification.
+type blockReader struct {
+	svc    *Service
+	ref    *protogen.BlockRef
+	f      *os.File
+	cs     *blockChecksums
+	length uint64
+	unlock func()
+}
+
+func (s *Service) openBlockForRead(ref *protogen.BlockRef) (*blockReader, error) {
+	path := s.blockPath(ref)
+	unlock := s.blockLocks.rlock(path)
+	f, err := os.Open(path)
+	if err != nil {
+		unlock()
+		if os.IsNotExist(err) {
+			return nil, status.Error(codes.NotFound, "block not found")
+		}
+		return nil, status.Errorf(codes.Internal, "open block: %v", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		unlock()
+		return nil, status.Errorf(codes.Internal, "stat block: %v", err)
+	}
+	cs, err := loadChecksums(path)
+	if err != nil {
+		f.Close()
+		unlock()
+		if errors.Is(err, errChecksumMismatch) {
+			s.quarantineBlock(ref, detectedByRead, err.Error())
+			return nil, status.Errorf(codes.DataLoss, "block checksums unreadable: %v", err)
+		}
+		return nil, status.Errorf(codes.Internal, "read block checksums: %v", err)
+	}
+	return &blockReader{svc: s, ref: ref, f: f, cs: cs, length: uint64(info.Size()), unlock: unlock}, nil
+}
+
+func (br *blockReader) close() {
+	br.f.Close()
+	br.unlock()
+}
+
+// span clamps a requested (offset, length) to the block; length 0 means to
+// the end of the block.
+func (br *blockReader) span(offset, length uint64) uint64 {
+	if offset >= br.length {
+		return 0
+	}
+	if length == 0 || length > br.length-offset {
+		return br.length - offset
+	}
+	return length
+}
+
+func (br *blockReader) read(offset, n uint64) ([]byte, error) {
+	data, err := readVerified(br.f, br.cs, br.length, offset, n)
+	if err != nil {
+		if errors.Is(err, errChecksumMismatch) {
+			br.svc.quarantineBlock(br.ref, detectedByRead, err.Error())
+			return nil, status.Errorf(codes.DataLoss, "block failed verification: %v", err)
+		}
+		return nil, status.Errorf(codes.Internal, "read block: %v", err)
+	}
+	return data, nil
+}
+
+func (s *Service) recordChecksumError(ref *protogen.BlockRef, reason string) {
+	metrics.IncChecksumErrors("ost", s.ostID)
+	log.Printf("ost(%s) checksum error on %s/%d: %s", s.ostID, ref.GetFileId(), ref.GetChunkId(), reason)
+}
+
+const (
+	detectedByRead  = "read"
+	detectedByScrub = "scrub"
+)
+
+// quarantineSuffix names the marker a quarantined block has next to it, so
+// the quarantine outlives restarts. It holds the CorruptBlock listed for it.
+const quarantineSuffix = ".corrupt"
+
+func quarantinePath(blockPath string) string {
+	return strings.TrimSuffix(blockPath, ".blk") + quarantineSuffix
+}
+
+// quarantineBlock records a block whose stored bytes no longer match their
+// checksums. It stays listed until the block is deleted, rewritten whole or
+// a scrub pass finds it healthy again.
+func (s *Service) quarantineBlock(ref *protogen.BlockRef, detectedBy, reason string) {
+	s.recordChecksumError(ref, reason)
+	block := &protogen.CorruptBlock{
+		Block:        &protogen.BlockRef{FileId: ref.GetFileId(), ChunkId: ref.GetChunkId(), OstId: s.ostID},
+		Reason:       reason,
+		DetectedBy:   detectedBy,
+		DetectedUnix: time.Now().Unix(),
+	}
+	s.quarantineMu.Lock()
+	defer s.quarantineMu.Unlock()
+	s.quarantine[quarantineKey(ref)] = block
+	metrics.SetQuarantinedBlocks(s.ostID, len(s.quarantine))
+	// The marker only carries the quarantine over a restart, so failing to
+	// write it does not stop the block being reported now.
+	if err := writeQuarantineMarker(quarantinePath(s.blockPath(ref)), block); err != nil {
+		log.Printf("ost(%s) could not persist quarantine of %s/%d: %v", s.ostID, ref.GetFileId(), ref.GetChunkId(), err)
+	}
+}
+
+func (s *Service) releaseBlock(ref *protogen.BlockRef) {
+	s.quarantineMu.Lock()
+	defer s.quarantineMu.Unlock()
+	delete(s.quarantine, quarantineKey(ref))
+	metrics.SetQuarantinedBlocks(s.ostID, len(s.quarantine))
+	if err := os.Remove(quarantinePath(s.blockPath(ref))); err != nil && !os.IsNotExist(err) {
+		log.Printf("ost(%s) could not clear quarantine of %s/%d: %v", s.ostID, ref.GetFileId(), ref.GetChunkId(), err)
+	}
+}
+
+func writeQuarantineMarker(path string, block *protogen.CorruptBlock) error {
+	blob, err := proto.Marshal(block)
+	if err != nil {
+		return err
+	}
+	tmp, err := createTemp(path)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(blob); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// loadQuarantine lists the blocks quarantined before the OST restarted.
+// Markers whose block is gone are dropped. It runs before the service
+// accepts requests.
+func (s *Service) loadQuarantine() error {
+	err := filepath.WalkDir(s.dataDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && path != s.dataDir && strings.HasPrefix(d.Name(), ".") {
+			// Hidden directories such as a sweeper trash hold no live blocks.
+			return fs.SkipDir
+		}
+		if d.IsDir() || !strings.HasSuffix(d.Name(), quarantineSuffix) {
+			return nil
+		}
+		blockPath := strings.TrimSuffix(path, quarantineSuffix) + ".blk"
+		if _, err := os.Stat(blockPath); os.IsNotExist(err) {
+			return os.Remove(path)
+		}
+		blob, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		block := &protogen.CorruptBlock{}
+		if err := proto.Unmarshal(blob, block); err != nil {
+			return fmt.Errorf("read quarantine marker %s: %w", path, err)
+		}
+		if block.GetBlock() == nil {
+			return fmt.Errorf("quarantine marker %s names no block", path)
+		}
+		block.Block.OstId = s.ostID
+		s.quarantine[quarantineKey(block.GetBlock())] = block
+		return nil
+	})
+	if len(s.quarantine) > 0 {
+		log.Printf("ost(%s) has %d blocks quarantined from before it restarted", s.ostID, len(s.quarantine))
+	}
+	metrics.SetQuarantinedBlocks(s.ostID, len(s.quarantine))
+	return err
+}
+
+func quarantineKey(ref *protogen.BlockRef) string {
+	return fmt.Sprintf("%s/%d", sanitize(ref.GetFileId()), ref.GetChunkId())
+}
+
 func (s *Service) blockPath(ref *protogen.BlockRef) string {
 	fileID := sanitize(ref.GetFileId())
 	chunkID := fmt.Sprintf("%d", ref.GetChunkId())
@@ -0,0 +1,222 @@
+package ost
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	protogen "github.com/rachanaanugandula/kube-pfs/pkg/proto/gen"
+)
+
+const (
+	defaultSweepBatchSize      = 500
+	defaultSweepMinAge         = time.Hour
+	defaultSweepTrashRetention = 7 * 24 * time.Hour
+)
+
+type SweepConfig struct {
+	// BatchSize is how many file IDs go into one InodesExist call.
+	BatchSize int
+	// MinAge leaves alone directories modified more recently than this, so
+	// a sweep never races a file that is still being written.
+	MinAge time.Duration
+	// DryRun only reports the orphans.
+	DryRun bool
+	// TrashDir, when set, receives orphaned directories instead of them being
+	// deleted. It must be on the same filesystem as the data directory, and
+	// inside it only under a name starting with "." so the scans skip it.
+	TrashDir string
+	// TrashRetention is how long trashed directories are kept before a
+	// later sweep deletes them.
+	TrashRetention time.Duration
+}
+
+// Orphan is a file directory on the OST whose inode no longer exists.
+type Orphan struct {
+	FileID string
+	Blocks int
+	Bytes  uint64
+}
+
+type SweepReport struct {
+	// Scanned counts the file directories old enough to be checked.
+	Scanned int
+	Orphans []Orphan
+	// Removed counts orphans deleted or moved to the trash; zero on a dry run.
+	Removed int
+	// TrashPurged counts trashed directories deleted after their retention.
+	TrashPurged int
+}
+
+func (r *SweepReport) OrphanBytes() uint64 {
+	var n uint64
+	for _, o := range r.Orphans {
+		n += o.Bytes
+	}
+	return n
+}
+
+// Sweeper reconciles the OST's file directories against the MDS namespace
+// and removes the ones no inode refers to, such as data left behind by a
+// crash between an unlink and the MDS garbage collector reaching this OST.
+type Sweeper struct {
+	svc *Service
+	mds protogen.MetadataServiceClient
+	cfg SweepConfig
+}
+
+func NewSweeper(svc *Service, mds protogen.MetadataServiceClient, cfg SweepConfig) *Sweeper {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultSweepBatchSize
+	}
+	if cfg.MinAge <= 0 {
+		cfg.MinAge = defaultSweepMinAge
+	}
+	if cfg.TrashRetention <= 0 {
+		cfg.TrashRetention = defaultSweepTrashRetention
+	}
+	return &Sweeper{svc: svc, mds: mds, cfg: cfg}
+}
+
+// SweepOnce makes one reconciliation pass. It stops at the first MDS error
+// without removing anything from the batch that failed.
+func (sw *Sweeper) SweepOnce(ctx context.Context) (*SweepReport, error) {
+	report := &SweepReport{}
+	entries, err := os.ReadDir(sw.svc.dataDir)
+	if err != nil {
+		return report, fmt.Errorf("list data dir: %w", err)
+	}
+	cutoff := time.Now().Add(-sw.cfg.MinAge)
+	var batch []string
+	for _, e := range entries {
+		if !e.IsDir() || strings.HasPrefix(e.Name(), ".") || sw.isTrash(e.Name()) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		report.Scanned++
+		batch = append(batch, e.Name())
+		if len(batch) == sw.cfg.BatchSize {
+			if err := sw.sweepBatch(ctx, batch, report); err != nil {
+				return report, err
+			}
+			batch = batch[:0]
+		}
+	}
+	if len(batch) > 0 {
+		if err := sw.sweepBatch(ctx, batch, report); err != nil {
+			return report, err
+		}
+	}
+	if sw.cfg.TrashDir != "" && !sw.cfg.DryRun {
+		purged, err := sw.purgeTrash()
+		report.TrashPurged = purged
+		if err != nil {
+			return report, fmt.Errorf("purge trash: %w", err)
+		}
+	}
+	return report, nil
+}
+
+func (sw *Sweeper) sweepBatch(ctx context.Context, fileIDs []string, report *SweepReport) error {
+	res, err := sw.mds.InodesExist(ctx, &protogen.InodesExistRequest{InodeIds: fileIDs})
+	if err != nil {
+		return fmt.Errorf("ask mds which inodes exist: %w", err)
+	}
+	existing := map[string]bool{}
+	for _, id := range res.GetExisting() {
+		existing[id] = true
+	}
+	for _, fileID := range fileIDs {
+		if existing[fileID] {
+			continue
+		}
+		orphan, chunks := sw.inspect(fileID)
+		report.Orphans = append(report.Orphans, orphan)
+		if sw.cfg.DryRun {
+			continue
+		}
+		if err := sw.remove(fileID); err != nil {
+			return fmt.Errorf("remove orphan %s: %w", fileID, err)
+		}
+		for _, chunkID := range chunks {
+			sw.svc.releaseBlock(&protogen.BlockRef{FileId: fileID, ChunkId: chunkID})
+		}
+		report.Removed++
+		log.Printf("ost(%s) swept orphaned file %s: blocks=%d bytes=%d", sw.svc.ostID, fileID, orphan.Blocks, orphan.Bytes)
+	}
+	return nil
+}
+
+// inspect sizes up an orphan for the report and lists its chunks.
+func (sw *Sweeper) inspect(fileID string) (Orphan, []uint64) {
+	orphan := Orphan{FileID: fileID}
+	entries, err := os.ReadDir(filepath.Join(sw.svc.dataDir, fileID))
+	if err != nil {
+		return orphan, nil
+	}
+	var chunks []uint64
+	for _, e := range entries {
+		chunkID, err := strconv.ParseUint(strings.TrimSuffix(e.Name(), ".blk"), 10, 64)
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".blk") || err != nil {
+			continue
+		}
+		chunks = append(chunks, chunkID)
+		orphan.Blocks++
+		if info, err := e.Info(); err == nil {
+			orphan.Bytes += uint64(info.Size())
+		}
+	}
+	return orphan, chunks
+}
+
+func (sw *Sweeper) remove(fileID string) error {
+	dir := filepath.Join(sw.svc.dataDir, fileID)
+	if sw.cfg.TrashDir == "" {
+		return os.RemoveAll(dir)
+	}
+	if err := os.MkdirAll(sw.cfg.TrashDir, 0755); err != nil {
+		return err
+	}
+	dst := filepath.Join(sw.cfg.TrashDir, fmt.Sprintf("%s.%d", fileID, time.Now().UnixNano()))
+	if err := os.Rename(dir, dst); err != nil {
+		return err
+	}
+	// Retention runs from when the directory was trashed.
+	now := time.Now()
+	return os.Chtimes(dst, now, now)
+}
+
+func (sw *Sweeper) isTrash(name string) bool {
+	return sw.cfg.TrashDir != "" && filepath.Clean(sw.cfg.TrashDir) == filepath.Join(sw.svc.dataDir, name)
+}
+
+func (sw *Sweeper) purgeTrash() (int, error) {
+	entries, err := os.ReadDir(sw.cfg.TrashDir)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	cutoff := time.Now().Add(-sw.cfg.TrashRetention)
+	purged := 0
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(sw.cfg.TrashDir, e.Name())); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
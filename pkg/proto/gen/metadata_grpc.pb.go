@@ -19,11 +19,20 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	MetadataService_Create_FullMethodName  = "/kubepfs.v1.MetadataService/Create"
-	MetadataService_Lookup_FullMethodName  = "/kubepfs.v1.MetadataService/Lookup"
-	MetadataService_Stat_FullMethodName    = "/kubepfs.v1.MetadataService/Stat"
-	MetadataService_ListDir_FullMethodName = "/kubepfs.v1.MetadataService/ListDir"
-	MetadataService_Unlink_FullMethodName  = "/kubepfs.v1.MetadataService/Unlink"
+	MetadataService_Create_FullMethodName       = "/kubepfs.v1.MetadataService/Create"
+	MetadataService_Lookup_FullMethodName       = "/kubepfs.v1.MetadataService/Lookup"
+	MetadataService_Stat_FullMethodName         = "/kubepfs.v1.MetadataService/Stat"
+	MetadataService_ListDir_FullMethodName      = "/kubepfs.v1.MetadataService/ListDir"
+	MetadataService_Unlink_FullMethodName       = "/kubepfs.v1.MetadataService/Unlink"
+	MetadataService_Rename_FullMethodName       = "/kubepfs.v1.MetadataService/Rename"
+	MetadataService_SetAttr_FullMethodName      = "/kubepfs.v1.MetadataService/SetAttr"
+	MetadataService_RegisterOST_FullMethodName  = "/kubepfs.v1.MetadataService/RegisterOST"
+	MetadataService_Heartbeat_FullMethodName    = "/kubepfs.v1.MetadataService/Heartbeat"
+	MetadataService_ListOSTs_FullMethodName     = "/kubepfs.v1.MetadataService/ListOSTs"
+	MetadataService_SetDirLayout_FullMethodName = "/kubepfs.v1.MetadataService/SetDirLayout"
+	MetadataService_GetDirLayout_FullMethodName = "/kubepfs.v1.MetadataService/GetDirLayout"
+	MetadataService_InodesExist_FullMethodName  = "/kubepfs.v1.MetadataService/InodesExist"
+	MetadataService_GetShardMap_FullMethodName  = "/kubepfs.v1.MetadataService/GetShardMap"
 )
 
 // MetadataServiceClient is the client API for MetadataService service.
@@ -35,6 +44,15 @@ type MetadataServiceClient interface {
 	Stat(ctx context.Context, in *StatRequest, opts ...grpc.CallOption) (*StatResponse, error)
 	ListDir(ctx context.Context, in *ListDirRequest, opts ...grpc.CallOption) (*ListDirResponse, error)
 	Unlink(ctx context.Context, in *UnlinkRequest, opts ...grpc.CallOption) (*UnlinkResponse, error)
+	Rename(ctx context.Context, in *RenameRequest, opts ...grpc.CallOption) (*RenameResponse, error)
+	SetAttr(ctx context.Context, in *SetAttrRequest, opts ...grpc.CallOption) (*SetAttrResponse, error)
+	RegisterOST(ctx context.Context, in *RegisterOSTRequest, opts ...grpc.CallOption) (*RegisterOSTResponse, error)
+	Heartbeat(ctx context.Context, in *HeartbeatRequest, opts ...grpc.CallOption) (*HeartbeatResponse, error)
+	ListOSTs(ctx context.Context, in *ListOSTsRequest, opts ...grpc.CallOption) (*ListOSTsResponse, error)
+	SetDirLayout(ctx context.Context, in *SetDirLayoutRequest, opts ...grpc.CallOption) (*SetDirLayoutResponse, error)
+	GetDirLayout(ctx context.Context, in *GetDirLayoutRequest, opts ...grpc.CallOption) (*GetDirLayoutResponse, error)
+	InodesExist(ctx context.Context, in *InodesExistRequest, opts ...grpc.CallOption) (*InodesExistResponse, error)
+	GetShardMap(ctx context.Context, in *GetShardMapRequest, opts ...grpc.CallOption) (*GetShardMapResponse, error)
 }
 
 type metadataServiceClient struct {
@@ -95,6 +113,96 @@ func (c *metadataServiceClient) Unlink(ctx context.Context, in *UnlinkRequest, o
 	return out, nil
 }
 
+func (c *metadataServiceClient) Rename(ctx context.Context, in *RenameRequest, opts ...grpc.CallOption) (*RenameResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RenameResponse)
+	err := c.cc.Invoke(ctx, MetadataService_Rename_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metadataServiceClient) SetAttr(ctx context.Context, in *SetAttrRequest, opts ...grpc.CallOption) (*SetAttrResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetAttrResponse)
+	err := c.cc.Invoke(ctx, MetadataService_SetAttr_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metadataServiceClient) RegisterOST(ctx context.Context, in *RegisterOSTRequest, opts ...grpc.CallOption) (*RegisterOSTResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RegisterOSTResponse)
+	err := c.cc.Invoke(ctx, MetadataService_RegisterOST_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metadataServiceClient) Heartbeat(ctx context.Context, in *HeartbeatRequest, opts ...grpc.CallOption) (*HeartbeatResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HeartbeatResponse)
+	err := c.cc.Invoke(ctx, MetadataService_Heartbeat_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metadataServiceClient) ListOSTs(ctx context.Context, in *ListOSTsRequest, opts ...grpc.CallOption) (*ListOSTsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListOSTsResponse)
+	err := c.cc.Invoke(ctx, MetadataService_ListOSTs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metadataServiceClient) SetDirLayout(ctx context.Context, in *SetDirLayoutRequest, opts ...grpc.CallOption) (*SetDirLayoutResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetDirLayoutResponse)
+	err := c.cc.Invoke(ctx, MetadataService_SetDirLayout_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metadataServiceClient) GetDirLayout(ctx context.Context, in *GetDirLayoutRequest, opts ...grpc.CallOption) (*GetDirLayoutResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetDirLayoutResponse)
+	err := c.cc.Invoke(ctx, MetadataService_GetDirLayout_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metadataServiceClient) InodesExist(ctx context.Context, in *InodesExistRequest, opts ...grpc.CallOption) (*InodesExistResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(InodesExistResponse)
+	err := c.cc.Invoke(ctx, MetadataService_InodesExist_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metadataServiceClient) GetShardMap(ctx context.Context, in *GetShardMapRequest, opts ...grpc.CallOption) (*GetShardMapResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetShardMapResponse)
+	err := c.cc.Invoke(ctx, MetadataService_GetShardMap_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // MetadataServiceServer is the server API for MetadataService service.
 // All implementations must embed UnimplementedMetadataServiceServer
 // for forward compatibility.
@@ -104,6 +212,15 @@ type MetadataServiceServer interface {
 	Stat(context.Context, *StatRequest) (*StatResponse, error)
 	ListDir(context.Context, *ListDirRequest) (*ListDirResponse, error)
 	Unlink(context.Context, *UnlinkRequest) (*UnlinkResponse, error)
+	Rename(context.Context, *RenameRequest) (*RenameResponse, error)
+	SetAttr(context.Context, *SetAttrRequest) (*SetAttrResponse, error)
+	RegisterOST(context.Context, *RegisterOSTRequest) (*RegisterOSTResponse, error)
+	Heartbeat(context.Context, *HeartbeatRequest) (*HeartbeatResponse, error)
+	ListOSTs(context.Context, *ListOSTsRequest) (*ListOSTsResponse, error)
+	SetDirLayout(context.Context, *SetDirLayoutRequest) (*SetDirLayoutResponse, error)
+	GetDirLayout(context.Context, *GetDirLayoutRequest) (*GetDirLayoutResponse, error)
+	InodesExist(context.Context, *InodesExistRequest) (*InodesExistResponse, error)
+	GetShardMap(context.Context, *GetShardMapRequest) (*GetShardMapResponse, error)
 	mustEmbedUnimplementedMetadataServiceServer()
 }
 
@@ -129,6 +246,33 @@ func (UnimplementedMetadataServiceServer) ListDir(context.Context, *ListDirReque
 func (UnimplementedMetadataServiceServer) Unlink(context.Context, *UnlinkRequest) (*UnlinkResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Unlink not implemented")
 }
+func (UnimplementedMetadataServiceServer) Rename(context.Context, *RenameRequest) (*RenameResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Rename not implemented")
+}
+func (UnimplementedMetadataServiceServer) SetAttr(context.Context, *SetAttrRequest) (*SetAttrResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetAttr not implemented")
+}
+func (UnimplementedMetadataServiceServer) RegisterOST(context.Context, *RegisterOSTRequest) (*RegisterOSTResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RegisterOST not implemented")
+}
+func (UnimplementedMetadataServiceServer) Heartbeat(context.Context, *HeartbeatRequest) (*HeartbeatResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Heartbeat not implemented")
+}
+func (UnimplementedMetadataServiceServer) ListOSTs(context.Context, *ListOSTsRequest) (*ListOSTsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListOSTs not implemented")
+}
+func (UnimplementedMetadataServiceServer) SetDirLayout(context.Context, *SetDirLayoutRequest) (*SetDirLayoutResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetDirLayout not implemented")
+}
+func (UnimplementedMetadataServiceServer) GetDirLayout(context.Context, *GetDirLayoutRequest) (*GetDirLayoutResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDirLayout not implemented")
+}
+func (UnimplementedMetadataServiceServer) InodesExist(context.Context, *InodesExistRequest) (*InodesExistResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method InodesExist not implemented")
+}
+func (UnimplementedMetadataServiceServer) GetShardMap(context.Context, *GetShardMapRequest) (*GetShardMapResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetShardMap not implemented")
+}
 func (UnimplementedMetadataServiceServer) mustEmbedUnimplementedMetadataServiceServer() {}
 func (UnimplementedMetadataServiceServer) testEmbeddedByValue()                         {}
 
@@ -240,6 +384,168 @@ func _MetadataService_Unlink_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
+func _MetadataService_Rename_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenameRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetadataServiceServer).Rename(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MetadataService_Rename_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetadataServiceServer).Rename(ctx, req.(*RenameRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetadataService_SetAttr_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetAttrRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetadataServiceServer).SetAttr(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MetadataService_SetAttr_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetadataServiceServer).SetAttr(ctx, req.(*SetAttrRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetadataService_RegisterOST_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterOSTRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetadataServiceServer).RegisterOST(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MetadataService_RegisterOST_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetadataServiceServer).RegisterOST(ctx, req.(*RegisterOSTRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetadataService_Heartbeat_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HeartbeatRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetadataServiceServer).Heartbeat(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MetadataService_Heartbeat_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetadataServiceServer).Heartbeat(ctx, req.(*HeartbeatRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetadataService_ListOSTs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListOSTsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetadataServiceServer).ListOSTs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MetadataService_ListOSTs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetadataServiceServer).ListOSTs(ctx, req.(*ListOSTsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetadataService_SetDirLayout_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetDirLayoutRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetadataServiceServer).SetDirLayout(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MetadataService_SetDirLayout_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetadataServiceServer).SetDirLayout(ctx, req.(*SetDirLayoutRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetadataService_GetDirLayout_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDirLayoutRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetadataServiceServer).GetDirLayout(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MetadataService_GetDirLayout_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetadataServiceServer).GetDirLayout(ctx, req.(*GetDirLayoutRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetadataService_InodesExist_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InodesExistRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetadataServiceServer).InodesExist(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MetadataService_InodesExist_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetadataServiceServer).InodesExist(ctx, req.(*InodesExistRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetadataService_GetShardMap_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetShardMapRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetadataServiceServer).GetShardMap(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MetadataService_GetShardMap_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetadataServiceServer).GetShardMap(ctx, req.(*GetShardMapRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // MetadataService_ServiceDesc is the grpc.ServiceDesc for MetadataService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -267,6 +573,42 @@ var MetadataService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Unlink",
 			Handler:    _MetadataService_Unlink_Handler,
 		},
+		{
+			MethodName: "Rename",
+			Handler:    _MetadataService_Rename_Handler,
+		},
+		{
+			MethodName: "SetAttr",
+			Handler:    _MetadataService_SetAttr_Handler,
+		},
+		{
+			MethodName: "RegisterOST",
+			Handler:    _MetadataService_RegisterOST_Handler,
+		},
+		{
+			MethodName: "Heartbeat",
+			Handler:    _MetadataService_Heartbeat_Handler,
+		},
+		{
+			MethodName: "ListOSTs",
+			Handler:    _MetadataService_ListOSTs_Handler,
+		},
+		{
+			MethodName: "SetDirLayout",
+			Handler:    _MetadataService_SetDirLayout_Handler,
+		},
+		{
+			MethodName: "GetDirLayout",
+			Handler:    _MetadataService_GetDirLayout_Handler,
+		},
+		{
+			MethodName: "InodesExist",
+			Handler:    _MetadataService_InodesExist_Handler,
+		},
+		{
+			MethodName: "GetShardMap",
+			Handler:    _MetadataService_GetShardMap_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "metadata.proto",
@@ -19,10 +19,13 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	ObjectStorageService_WriteBlock_FullMethodName  = "/kubepfs.v1.ObjectStorageService/WriteBlock"
-	ObjectStorageService_ReadBlock_FullMethodName   = "/kubepfs.v1.ObjectStorageService/ReadBlock"
-	ObjectStorageService_DeleteBlock_FullMethodName = "/kubepfs.v1.ObjectStorageService/DeleteBlock"
-	ObjectStorageService_GetHealth_FullMethodName   = "/kubepfs.v1.ObjectStorageService/GetHealth"
+	ObjectStorageService_WriteBlock_FullMethodName        = "/kubepfs.v1.ObjectStorageService/WriteBlock"
+	ObjectStorageService_ReadBlock_FullMethodName         = "/kubepfs.v1.ObjectStorageService/ReadBlock"
+	ObjectStorageService_DeleteBlock_FullMethodName       = "/kubepfs.v1.ObjectStorageService/DeleteBlock"
+	ObjectStorageService_GetHealth_FullMethodName         = "/kubepfs.v1.ObjectStorageService/GetHealth"
+	ObjectStorageService_WriteBlockStream_FullMethodName  = "/kubepfs.v1.ObjectStorageService/WriteBlockStream"
+	ObjectStorageService_ReadBlockStream_FullMethodName   = "/kubepfs.v1.ObjectStorageService/ReadBlockStream"
+	ObjectStorageService_ListCorruptBlocks_FullMethodName = "/kubepfs.v1.ObjectStorageService/ListCorruptBlocks"
 )
 
 // ObjectStorageServiceClient is the client API for ObjectStorageService service.
@@ -33,6 +36,9 @@ type ObjectStorageServiceClient interface {
 	ReadBlock(ctx context.Context, in *ReadBlockRequest, opts ...grpc.CallOption) (*ReadBlockResponse, error)
 	DeleteBlock(ctx context.Context, in *DeleteBlockRequest, opts ...grpc.CallOption) (*DeleteBlockResponse, error)
 	GetHealth(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*HealthResponse, error)
+	WriteBlockStream(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[WriteBlockStreamRequest, WriteBlockResponse], error)
+	ReadBlockStream(ctx context.Context, in *ReadBlockRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ReadBlockResponse], error)
+	ListCorruptBlocks(ctx context.Context, in *ListCorruptBlocksRequest, opts ...grpc.CallOption) (*ListCorruptBlocksResponse, error)
 }
 
 type objectStorageServiceClient struct {
@@ -83,6 +89,48 @@ func (c *objectStorageServiceClient) GetHealth(ctx context.Context, in *HealthRe
 	return out, nil
 }
 
+func (c *objectStorageServiceClient) WriteBlockStream(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[WriteBlockStreamRequest, WriteBlockResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ObjectStorageService_ServiceDesc.Streams[0], ObjectStorageService_WriteBlockStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WriteBlockStreamRequest, WriteBlockResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ObjectStorageService_WriteBlockStreamClient = grpc.ClientStreamingClient[WriteBlockStreamRequest, WriteBlockResponse]
+
+func (c *objectStorageServiceClient) ReadBlockStream(ctx context.Context, in *ReadBlockRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ReadBlockResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ObjectStorageService_ServiceDesc.Streams[1], ObjectStorageService_ReadBlockStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ReadBlockRequest, ReadBlockResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ObjectStorageService_ReadBlockStreamClient = grpc.ServerStreamingClient[ReadBlockResponse]
+
+func (c *objectStorageServiceClient) ListCorruptBlocks(ctx context.Context, in *ListCorruptBlocksRequest, opts ...grpc.CallOption) (*ListCorruptBlocksResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListCorruptBlocksResponse)
+	err := c.cc.Invoke(ctx, ObjectStorageService_ListCorruptBlocks_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ObjectStorageServiceServer is the server API for ObjectStorageService service.
 // All implementations must embed UnimplementedObjectStorageServiceServer
 // for forward compatibility.
@@ -91,6 +139,9 @@ type ObjectStorageServiceServer interface {
 	ReadBlock(context.Context, *ReadBlockRequest) (*ReadBlockResponse, error)
 	DeleteBlock(context.Context, *DeleteBlockRequest) (*DeleteBlockResponse, error)
 	GetHealth(context.Context, *HealthRequest) (*HealthResponse, error)
+	WriteBlockStream(grpc.ClientStreamingServer[WriteBlockStreamRequest, WriteBlockResponse]) error
+	ReadBlockStream(*ReadBlockRequest, grpc.ServerStreamingServer[ReadBlockResponse]) error
+	ListCorruptBlocks(context.Context, *ListCorruptBlocksRequest) (*ListCorruptBlocksResponse, error)
 	mustEmbedUnimplementedObjectStorageServiceServer()
 }
 
@@ -113,6 +164,15 @@ func (UnimplementedObjectStorageServiceServer) DeleteBlock(context.Context, *Del
 func (UnimplementedObjectStorageServiceServer) GetHealth(context.Context, *HealthRequest) (*HealthResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetHealth not implemented")
 }
+func (UnimplementedObjectStorageServiceServer) WriteBlockStream(grpc.ClientStreamingServer[WriteBlockStreamRequest, WriteBlockResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method WriteBlockStream not implemented")
+}
+func (UnimplementedObjectStorageServiceServer) ReadBlockStream(*ReadBlockRequest, grpc.ServerStreamingServer[ReadBlockResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method ReadBlockStream not implemented")
+}
+func (UnimplementedObjectStorageServiceServer) ListCorruptBlocks(context.Context, *ListCorruptBlocksRequest) (*ListCorruptBlocksResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCorruptBlocks not implemented")
+}
 func (UnimplementedObjectStorageServiceServer) mustEmbedUnimplementedObjectStorageServiceServer() {}
 func (UnimplementedObjectStorageServiceServer) testEmbeddedByValue()                              {}
 
@@ -206,6 +266,42 @@ func _ObjectStorageService_GetHealth_Handler(srv interface{}, ctx context.Contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ObjectStorageService_WriteBlockStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ObjectStorageServiceServer).WriteBlockStream(&grpc.GenericServerStream[WriteBlockStreamRequest, WriteBlockResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ObjectStorageService_WriteBlockStreamServer = grpc.ClientStreamingServer[WriteBlockStreamRequest, WriteBlockResponse]
+
+func _ObjectStorageService_ReadBlockStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ReadBlockRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ObjectStorageServiceServer).ReadBlockStream(m, &grpc.GenericServerStream[ReadBlockRequest, ReadBlockResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ObjectStorageService_ReadBlockStreamServer = grpc.ServerStreamingServer[ReadBlockResponse]
+
+func _ObjectStorageService_ListCorruptBlocks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCorruptBlocksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ObjectStorageServiceServer).ListCorruptBlocks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ObjectStorageService_ListCorruptBlocks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ObjectStorageServiceServer).ListCorruptBlocks(ctx, req.(*ListCorruptBlocksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ObjectStorageService_ServiceDesc is the grpc.ServiceDesc for ObjectStorageService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -229,7 +325,22 @@ var ObjectStorageService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetHealth",
 			Handler:    _ObjectStorageService_GetHealth_Handler,
 		},
+		{
+			MethodName: "ListCorruptBlocks",
+			Handler:    _ObjectStorageService_ListCorruptBlocks_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WriteBlockStream",
+			Handler:       _ObjectStorageService_WriteBlockStream_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "ReadBlockStream",
+			Handler:       _ObjectStorageService_ReadBlockStream_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "object_storage.proto",
 }
@@ -0,0 +1,219 @@
+package raft
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	protogen "github.com/rachanaanugandula/kube-pfs/pkg/proto/gen"
+	"go.etcd.io/bbolt"
+	gproto "google.golang.org/protobuf/proto"
+)
+
+// The log, keyed by big-endian index, and the term and vote live in bolt
+// next to the state they replicate. Every write is its own transaction, so
+// it is on disk before the node answers an RPC or counts its own entry.
+// Compaction drops the applied start of the log and keeps the index and
+// term of the last entry dropped, which the entry after it is checked
+// against.
+const (
+	bucketLog         = "raft_log"
+	bucketState       = "raft_state"
+	stateTerm         = "term"
+	stateVote         = "vote"
+	stateCompactIndex = "compact_index"
+	stateCompactTerm  = "compact_term"
+)
+
+// errCompacted is returned for entries dropped from the log.
+var errCompacted = errors.New("raft entry was compacted away")
+
+func indexKey(index uint64) []byte {
+	var k [8]byte
+	binary.BigEndian.PutUint64(k[:], index)
+	return k[:]
+}
+
+// load creates the buckets and reads the persisted state.
+func (n *Node) load() error {
+	return n.cfg.DB.Update(func(tx *bbolt.Tx) error {
+		logB, err := tx.CreateBucketIfNotExists([]byte(bucketLog))
+		if err != nil {
+			return err
+		}
+		stateB, err := tx.CreateBucketIfNotExists([]byte(bucketState))
+		if err != nil {
+			return err
+		}
+		if v := stateB.Get([]byte(stateTerm)); len(v) == 8 {
+			n.term = binary.BigEndian.Uint64(v)
+		}
+		n.votedFor = string(stateB.Get([]byte(stateVote)))
+		if v := stateB.Get([]byte(stateCompactIndex)); len(v) == 8 {
+			n.compactIndex = binary.BigEndian.Uint64(v)
+		}
+		if v := stateB.Get([]byte(stateCompactTerm)); len(v) == 8 {
+			n.compactTerm = binary.BigEndian.Uint64(v)
+		}
+		n.lastIndex, n.lastTerm = n.compactIndex, n.compactTerm
+		if k, v := logB.Cursor().Last(); k != nil {
+			e := &protogen.RaftEntry{}
+			if err := gproto.Unmarshal(v, e); err != nil {
+				return fmt.Errorf("decode raft entry %d: %w", binary.BigEndian.Uint64(k), err)
+			}
+			n.lastIndex, n.lastTerm = e.GetIndex(), e.GetTerm()
+		}
+		return nil
+	})
+}
+
+// persistState stores the current term and vote. Callers hold n.mu.
+func (n *Node) persistState() error {
+	return n.cfg.DB.Update(func(tx *bbolt.Tx) error {
+		stateB := tx.Bucket([]byte(bucketState))
+		if err := stateB.Put([]byte(stateTerm), indexKey(n.term)); err != nil {
+			return err
+		}
+		return stateB.Put([]byte(stateVote), []byte(n.votedFor))
+	})
+}
+
+// entries returns the entries from first to last, inclusive, stopping early
+// once they add up to maxBytes. At least one entry is returned when the
+// range is not empty. Callers hold n.mu.
+func (n *Node) entries(first, last uint64, maxBytes int) ([]*protogen.RaftEntry, error) {
+	if first <= n.compactIndex {
+		return nil, fmt.Errorf("raft entry %d: %w", first, errCompacted)
+	}
+	var out []*protogen.RaftEntry
+	size := 0
+	err := n.cfg.DB.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket([]byte(bucketLog)).Cursor()
+		for k, v := c.Seek(indexKey(first)); k != nil && binary.BigEndian.Uint64(k) <= last; k, v = c.Next() {
+			if len(out) > 0 && size+len(v) > maxBytes {
+				return nil
+			}
+			e := &protogen.RaftEntry{}
+			if err := gproto.Unmarshal(v, e); err != nil {
+				return err
+			}
+			out = append(out, e)
+			size += len(v)
+		}
+		return nil
+	})
+	if err == nil && first <= last && len(out) == 0 {
+		err = fmt.Errorf("raft entry %d is missing", first)
+	}
+	return out, err
+}
+
+// entry returns the entry at index. Callers hold n.mu.
+func (n *Node) entry(index uint64) (*protogen.RaftEntry, error) {
+	es, err := n.entries(index, index, 0)
+	if err != nil {
+		return nil, err
+	}
+	return es[0], nil
+}
+
+// termAt returns the term of the entry at index, 0 for index 0. Callers
+// hold n.mu.
+func (n *Node) termAt(index uint64) (uint64, error) {
+	switch {
+	case index == 0:
+		return 0, nil
+	case index == n.lastIndex:
+		return n.lastTerm, nil
+	case index == n.compactIndex:
+		return n.compactTerm, nil
+	case index < n.compactIndex:
+		return 0, fmt.Errorf("raft entry %d: %w", index, errCompacted)
+	}
+	e, err := n.entry(index)
+	if err != nil {
+		return 0, err
+	}
+	return e.GetTerm(), nil
+}
+
+// storeEntries drops the log from truncateFrom on, if it is not 0, and
+// appends es, which must continue the log. Callers hold n.mu.
+func (n *Node) storeEntries(es []*protogen.RaftEntry, truncateFrom uint64) error {
+	if truncateFrom != 0 && truncateFrom <= n.commitIndex {
+		return errors.New("refusing to truncate committed raft entries")
+	}
+	err := n.cfg.DB.Update(func(tx *bbolt.Tx) error {
+		logB := tx.Bucket([]byte(bucketLog))
+		if truncateFrom != 0 {
+			// Deleting under a cursor skips keys, so collect them first.
+			var stale [][]byte
+			c := logB.Cursor()
+			for k, _ := c.Seek(indexKey(truncateFrom)); k != nil; k, _ = c.Next() {
+				stale = append(stale, append([]byte(nil), k...))
+			}
+			for _, k := range stale {
+				if err := logB.Delete(k); err != nil {
+					return err
+				}
+			}
+		}
+		for _, e := range es {
+			blob, err := gproto.Marshal(e)
+			if err != nil {
+				return err
+			}
+			if err := logB.Put(indexKey(e.GetIndex()), blob); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if truncateFrom != 0 {
+		t, err := n.termAt(truncateFrom - 1)
+		if err != nil {
+			return err
+		}
+		n.lastIndex, n.lastTerm = truncateFrom-1, t
+	}
+	if len(es) > 0 {
+		n.lastIndex, n.lastTerm = es[len(es)-1].GetIndex(), es[len(es)-1].GetTerm()
+	}
+	return nil
+}
+
+// compact drops the entries up to index, which must be applied, from the
+// log. Callers hold n.mu.
+func (n *Node) compact(index uint64) error {
+	term, err := n.termAt(index)
+	if err != nil {
+		return err
+	}
+	err = n.cfg.DB.Update(func(tx *bbolt.Tx) error {
+		logB := tx.Bucket([]byte(bucketLog))
+		// Deleting under a cursor skips keys, so collect them first.
+		var done [][]byte
+		c := logB.Cursor()
+		for k, _ := c.First(); k != nil && binary.BigEndian.Uint64(k) <= index; k, _ = c.Next() {
+			done = append(done, append([]byte(nil), k...))
+		}
+		for _, k := range done {
+			if err := logB.Delete(k); err != nil {
+				return err
+			}
+		}
+		stateB := tx.Bucket([]byte(bucketState))
+		if err := stateB.Put([]byte(stateCompactIndex), indexKey(index)); err != nil {
+			return err
+		}
+		return stateB.Put([]byte(stateCompactTerm), indexKey(term))
+	})
+	if err != nil {
+		return err
+	}
+	n.compactIndex, n.compactTerm = index, term
+	return nil
+}
@@ -0,0 +1,790 @@
+// Package raft replicates a log between a fixed group of nodes with the Raft
+// consensus algorithm: a leader is elected by a majority, appends entries to
+// its log, copies them to the others and commits an entry once a majority
+// stores it. Every node applies committed entries in log order.
+//
+// It covers leader election, log replication, leader-confirmed reads and
+// log compaction. Membership is fixed when the nodes start. There are no
+// snapshots to send a member that falls behind, so applied entries are only
+// dropped from the log once every member stores them.
+package raft
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	protogen "github.com/rachanaanugandula/kube-pfs/pkg/proto/gen"
+	"go.etcd.io/bbolt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultElectionTimeout   = time.Second
+	defaultHeartbeatInterval = 100 * time.Millisecond
+	defaultCompactEvery      = 1024
+	// maxAppendBytes bounds the entries of one AppendEntries, well below
+	// gRPC's 4 MiB message limit.
+	maxAppendBytes = 1 << 20
+)
+
+// ErrStopped is returned by calls that were waiting when the node stopped.
+var ErrStopped = errors.New("raft node stopped")
+
+// ErrLeadershipLost is returned by Propose when the node stopped being
+// leader before its entry was applied. The entry may still commit under the
+// next leader.
+var ErrLeadershipLost = errors.New("raft leadership lost before the entry was applied")
+
+// NotLeaderError is returned to calls only the leader serves. Leader is the
+// node believed to lead, with an empty ID when none is known.
+type NotLeaderError struct {
+	Leader Peer
+}
+
+func (e *NotLeaderError) Error() string {
+	if e.Leader.ID == "" {
+		return "not the raft leader, and no leader is known"
+	}
+	return fmt.Sprintf("not the raft leader; %s at %s is", e.Leader.ID, e.Leader.Address)
+}
+
+type Peer struct {
+	ID string
+	// Address is where the node serves RaftService.
+	Address string
+}
+
+type Config struct {
+	// ID names this node and must be one of Peers, which lists every member
+	// of the group.
+	ID    string
+	Peers []Peer
+	// DB stores the log, term and vote in the raft_log and raft_state
+	// buckets.
+	DB *bbolt.DB
+	// Apply is called with every committed entry after Applied, one at a time
+	// and in log order. An entry that fails to apply is retried, since the
+	// entries after it cannot be applied before it.
+	Apply   func(index uint64, data []byte) error
+	Applied uint64
+	// OnLeader, if set, is called in a goroutine of its own each time this
+	// node becomes leader and has applied every entry committed before it.
+	OnLeader func()
+	// ElectionTimeout is how long a follower waits to hear from a leader
+	// before it stands for election, plus a random part up to as long again.
+	// The leader heartbeats every HeartbeatInterval. They default to 1s and
+	// 100ms.
+	ElectionTimeout   time.Duration
+	HeartbeatInterval time.Duration
+	// CompactEvery is how many applied entries build up before they are
+	// dropped from the log. Only entries every member stores are dropped, so
+	// a member that is down holds compaction back until it is back. It
+	// defaults to 1024.
+	CompactEvery uint64
+}
+
+type role int
+
+const (
+	follower role = iota
+	candidate
+	leader
+)
+
+// Node is one member of a raft group. It implements RaftService, which must
+// be served at its Peer address.
+type Node struct {
+	protogen.UnimplementedRaftServiceServer
+
+	cfg     Config
+	peers   []Peer
+	conns   []*grpc.ClientConn
+	clients map[string]protogen.RaftServiceClient
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+
+	mu       sync.Mutex
+	role     role
+	term     uint64
+	votedFor string
+	leaderID string
+	// lastIndex and lastTerm describe the end of the log.
+	lastIndex   uint64
+	lastTerm    uint64
+	commitIndex uint64
+	lastApplied uint64
+	// compactIndex and compactTerm describe the last entry dropped from the
+	// log. compactBound is the highest committed entry every member is known
+	// to store; the log is never compacted past it.
+	compactIndex uint64
+	compactTerm  uint64
+	compactBound uint64
+	deadline     time.Time
+	// changed is closed and replaced whenever the role, the commit index or
+	// the applied index moves, waking everyone waiting on one of them.
+	changed chan struct{}
+
+	// Leader state, reset on every election won. readyIndex is the entry the
+	// leader appended on taking over; it serves once that is applied.
+	readyIndex uint64
+	nextIndex  map[string]uint64
+	matchIndex map[string]uint64
+	wake       map[string]chan struct{}
+	leading    chan struct{}
+	// readRound counts the leadership checks reads asked for; ackedRound is
+	// the latest one each peer answered in the current term.
+	readRound  uint64
+	ackedRound map[string]uint64
+}
+
+// New starts a node. It stands for election once it has not heard from a
+// leader for an election timeout.
+func New(cfg Config) (*Node, error) {
+	if cfg.DB == nil || cfg.Apply == nil {
+		return nil, errors.New("raft needs a database and an apply function")
+	}
+	if cfg.ElectionTimeout <= 0 {
+		cfg.ElectionTimeout = defaultElectionTimeout
+	}
+	if cfg.HeartbeatInterval <= 0 {
+		cfg.HeartbeatInterval = defaultHeartbeatInterval
+	}
+	if cfg.CompactEvery == 0 {
+		cfg.CompactEvery = defaultCompactEvery
+	}
+	if cfg.HeartbeatInterval >= cfg.ElectionTimeout {
+		return nil, fmt.Errorf("heartbeat interval %s must be shorter than the election timeout %s", cfg.HeartbeatInterval, cfg.ElectionTimeout)
+	}
+	n := &Node{cfg: cfg, clients: map[string]protogen.RaftServiceClient{}, changed: make(chan struct{})}
+	seen := map[string]bool{}
+	for _, p := range cfg.Peers {
+		if p.ID == "" || seen[p.ID] {
+			return nil, fmt.Errorf("raft peer IDs must be unique and non-empty, got %q twice or empty", p.ID)
+		}
+		seen[p.ID] = true
+		if p.ID == cfg.ID {
+			continue
+		}
+		n.peers = append(n.peers, p)
+	}
+	if !seen[cfg.ID] {
+		return nil, fmt.Errorf("raft node %q is not among its peers", cfg.ID)
+	}
+	if err := n.load(); err != nil {
+		return nil, fmt.Errorf("load raft state: %w", err)
+	}
+	if cfg.Applied > n.lastIndex {
+		return nil, fmt.Errorf("state has applied raft entry %d but the log ends at %d", cfg.Applied, n.lastIndex)
+	}
+	if cfg.Applied < n.compactIndex {
+		return nil, fmt.Errorf("state has applied raft entry %d but the log was compacted up to %d", cfg.Applied, n.compactIndex)
+	}
+	for _, p := range n.peers {
+		conn, err := grpc.NewClient(p.Address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			n.closeConns()
+			return nil, fmt.Errorf("dial raft peer %s: %w", p.ID, err)
+		}
+		n.conns = append(n.conns, conn)
+		n.clients[p.ID] = protogen.NewRaftServiceClient(conn)
+	}
+	n.commitIndex, n.lastApplied = cfg.Applied, cfg.Applied
+	n.resetDeadline()
+	n.ctx, n.cancel = context.WithCancel(context.Background())
+	n.wg.Add(2)
+	go n.tick()
+	go n.applyLoop()
+	return n, nil
+}
+
+// Stop halts the node and fails calls waiting on it.
+func (n *Node) Stop() {
+	n.cancel()
+	n.mu.Lock()
+	n.becomeFollower(n.term)
+	n.mu.Unlock()
+	n.wg.Wait()
+	n.closeConns()
+}
+
+func (n *Node) closeConns() {
+	for _, conn := range n.conns {
+		conn.Close()
+	}
+}
+
+// Leader returns the node believed to lead, if one is known.
+func (n *Node) Leader() (Peer, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.peer(n.leaderID)
+}
+
+func (n *Node) peer(id string) (Peer, bool) {
+	for _, p := range n.cfg.Peers {
+		if p.ID == id && id != "" {
+			return p, true
+		}
+	}
+	return Peer{}, false
+}
+
+// Applied returns the index of the last entry applied on this node.
+func (n *Node) Applied() uint64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.lastApplied
+}
+
+// Compacted returns the index of the last entry dropped from the log.
+func (n *Node) Compacted() uint64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.compactIndex
+}
+
+// IsLeader reports whether this node leads and has applied every entry
+// committed before it took over, so its state is current.
+func (n *Node) IsLeader() bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.ready()
+}
+
+func (n *Node) ready() bool {
+	return n.role == leader && n.lastApplied >= n.readyIndex
+}
+
+// notLeader names the leader to retry with. A leader that is still
+// catching up names itself.
+func (n *Node) notLeader() error {
+	p, _ := n.peer(n.leaderID)
+	return &NotLeaderError{Leader: p}
+}
+
+// Propose appends data to the log and returns once it is committed and
+// applied on this node.
+func (n *Node) Propose(ctx context.Context, data []byte) error {
+	n.mu.Lock()
+	if !n.ready() {
+		err := n.notLeader()
+		n.mu.Unlock()
+		return err
+	}
+	term, index := n.term, n.lastIndex+1
+	if err := n.storeEntries([]*protogen.RaftEntry{{Index: index, Term: term, Data: data}}, 0); err != nil {
+		n.mu.Unlock()
+		return fmt.Errorf("append raft entry: %w", err)
+	}
+	n.broadcast()
+	n.advanceCommit()
+	n.mu.Unlock()
+
+	return n.waitFor(ctx, func() (bool, error) {
+		if n.lastApplied >= index {
+			// Applied entries never change, so the term tells whether the
+			// entry applied is ours. Once it is compacted away, only a
+			// later leader can have replaced it, and this node would have
+			// moved to that leader's term.
+			t, err := n.termAt(index)
+			if errors.Is(err, errCompacted) && n.term == term {
+				t, err = term, nil
+			}
+			if err != nil || t != term {
+				return true, ErrLeadershipLost
+			}
+			return true, nil
+		}
+		if n.term != term || n.role != leader {
+			return true, ErrLeadershipLost
+		}
+		return false, nil
+	})
+}
+
+// ReadIndex returns once this node has confirmed with a majority that it is
+// still the leader and has applied every entry committed before the call,
+// so a read from its state that follows sees every write acknowledged
+// before the call started.
+func (n *Node) ReadIndex(ctx context.Context) error {
+	n.mu.Lock()
+	if !n.ready() {
+		err := n.notLeader()
+		n.mu.Unlock()
+		return err
+	}
+	term, index := n.term, n.commitIndex
+	n.readRound++
+	round := n.readRound
+	n.broadcast()
+	n.mu.Unlock()
+
+	return n.waitFor(ctx, func() (bool, error) {
+		if n.term != term || n.role != leader {
+			return true, n.notLeader()
+		}
+		acks := 1
+		for _, p := range n.peers {
+			if n.ackedRound[p.ID] >= round {
+				acks++
+			}
+		}
+		return acks > len(n.cfg.Peers)/2 && n.lastApplied >= index, nil
+	})
+}
+
+// waitFor calls done with n.mu held whenever the node's state changes, until
+// it reports true.
+func (n *Node) waitFor(ctx context.Context, done func() (bool, error)) error {
+	for {
+		n.mu.Lock()
+		ok, err := done()
+		changed := n.changed
+		n.mu.Unlock()
+		if ok {
+			return err
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-n.ctx.Done():
+			return ErrStopped
+		}
+	}
+}
+
+// notify wakes everyone waiting on the node's state. Callers hold n.mu.
+func (n *Node) notify() {
+	close(n.changed)
+	n.changed = make(chan struct{})
+}
+
+func (n *Node) resetDeadline() {
+	n.deadline = time.Now().Add(n.cfg.ElectionTimeout + time.Duration(rand.Int63n(int64(n.cfg.ElectionTimeout))))
+}
+
+// tick starts an election whenever the deadline passes without a leader.
+func (n *Node) tick() {
+	defer n.wg.Done()
+	ticker := time.NewTicker(n.cfg.ElectionTimeout / 10)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-n.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		n.mu.Lock()
+		if n.role != leader && time.Now().After(n.deadline) {
+			n.startElection()
+		}
+		n.mu.Unlock()
+	}
+}
+
+// startElection votes for itself in a new term and asks the peers for
+// theirs. Callers hold n.mu.
+func (n *Node) startElection() {
+	n.resetDeadline()
+	n.role = candidate
+	n.term++
+	n.votedFor = n.cfg.ID
+	n.leaderID = ""
+	if err := n.persistState(); err != nil {
+		log.Printf("raft %s: persist vote: %v", n.cfg.ID, err)
+		n.role = follower
+		return
+	}
+	n.notify()
+	term := n.term
+	votes := 1
+	if votes > len(n.cfg.Peers)/2 {
+		n.becomeLeader()
+		return
+	}
+	req := &protogen.RequestVoteRequest{Term: term, CandidateId: n.cfg.ID, LastLogIndex: n.lastIndex, LastLogTerm: n.lastTerm}
+	for _, p := range n.peers {
+		client := n.clients[p.ID]
+		go func() {
+			ctx, cancel := context.WithTimeout(n.ctx, n.cfg.ElectionTimeout)
+			defer cancel()
+			resp, err := client.RequestVote(ctx, req)
+			if err != nil {
+				return
+			}
+			n.mu.Lock()
+			defer n.mu.Unlock()
+			if resp.GetTerm() > n.term {
+				n.becomeFollower(resp.GetTerm())
+				return
+			}
+			if n.term != term || n.role != candidate || !resp.GetVoteGranted() {
+				return
+			}
+			votes++
+			if votes > len(n.cfg.Peers)/2 {
+				n.becomeLeader()
+			}
+		}()
+	}
+}
+
+// becomeFollower moves to term, if it is newer, and stops leading or
+// campaigning. Callers hold n.mu.
+func (n *Node) becomeFollower(term uint64) {
+	if term > n.term {
+		n.term = term
+		n.votedFor = ""
+		n.leaderID = ""
+		if err := n.persistState(); err != nil {
+			log.Printf("raft %s: persist term: %v", n.cfg.ID, err)
+		}
+	}
+	if n.role == leader {
+		close(n.leading)
+		n.resetDeadline()
+	}
+	n.role = follower
+	n.notify()
+}
+
+// becomeLeader takes over and appends an empty entry of its own term, the
+// first one it can commit. Entries of earlier terms commit along with it.
+// Callers hold n.mu.
+func (n *Node) becomeLeader() {
+	if n.ctx.Err() != nil {
+		return
+	}
+	n.role = leader
+	n.leaderID = n.cfg.ID
+	n.readyIndex = n.lastIndex + 1
+	if err := n.storeEntries([]*protogen.RaftEntry{{Index: n.readyIndex, Term: n.term}}, 0); err != nil {
+		log.Printf("raft %s: append leader entry: %v", n.cfg.ID, err)
+		n.role = follower
+		n.resetDeadline()
+		return
+	}
+	log.Printf("raft %s: leading in term %d", n.cfg.ID, n.term)
+	n.nextIndex = map[string]uint64{}
+	n.matchIndex = map[string]uint64{}
+	n.ackedRound = map[string]uint64{}
+	n.wake = map[string]chan struct{}{}
+	n.leading = make(chan struct{})
+	for _, p := range n.peers {
+		n.nextIndex[p.ID] = n.readyIndex
+		n.wake[p.ID] = make(chan struct{}, 1)
+		n.wg.Add(1)
+		go n.replicate(p, n.term, n.leading, n.wake[p.ID])
+	}
+	n.advanceCommit()
+	n.notify()
+}
+
+// broadcast makes every replicator send at once. Callers hold n.mu.
+func (n *Node) broadcast() {
+	for _, ch := range n.wake {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// replicate keeps one peer's log in line with the leader's for as long as
+// the node leads in term, heartbeating when there is nothing to send.
+func (n *Node) replicate(p Peer, term uint64, leading <-chan struct{}, wake chan struct{}) {
+	defer n.wg.Done()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-leading:
+			return
+		case <-n.ctx.Done():
+			return
+		case <-wake:
+		case <-timer.C:
+		}
+		more := n.sendAppend(p, term)
+		timer.Reset(n.cfg.HeartbeatInterval)
+		if more {
+			select {
+			case wake <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// sendAppend sends the peer the entries it is missing, or a heartbeat, and
+// reports whether more remain to be sent.
+func (n *Node) sendAppend(p Peer, term uint64) bool {
+	n.mu.Lock()
+	if n.role != leader || n.term != term {
+		n.mu.Unlock()
+		return false
+	}
+	next := n.nextIndex[p.ID]
+	prevTerm, err := n.termAt(next - 1)
+	var es []*protogen.RaftEntry
+	if err == nil && next <= n.lastIndex {
+		es, err = n.entries(next, n.lastIndex, maxAppendBytes)
+	}
+	if err != nil {
+		n.mu.Unlock()
+		log.Printf("raft %s: read log for %s: %v", n.cfg.ID, p.ID, err)
+		return false
+	}
+	round := n.readRound
+	req := &protogen.AppendEntriesRequest{
+		Term:         term,
+		LeaderId:     n.cfg.ID,
+		PrevLogIndex: next - 1,
+		PrevLogTerm:  prevTerm,
+		Entries:      es,
+		LeaderCommit: n.commitIndex,
+		CompactIndex: n.compactBound,
+	}
+	n.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(n.ctx, n.cfg.ElectionTimeout)
+	resp, err := n.clients[p.ID].AppendEntries(ctx, req)
+	cancel()
+	if err != nil {
+		return false
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if resp.GetTerm() > n.term {
+		n.becomeFollower(resp.GetTerm())
+		return false
+	}
+	if n.role != leader || n.term != term {
+		return false
+	}
+	// Any answer in this term confirms the peer still follows this leader.
+	if round > n.ackedRound[p.ID] {
+		n.ackedRound[p.ID] = round
+		n.notify()
+	}
+	if !resp.GetSuccess() {
+		next = max(1, min(resp.GetNextIndex(), next-1))
+		n.nextIndex[p.ID] = next
+		return true
+	}
+	match := req.GetPrevLogIndex() + uint64(len(es))
+	if match > n.matchIndex[p.ID] {
+		n.matchIndex[p.ID] = match
+		n.advanceCommit()
+		n.maybeCompact()
+	}
+	n.nextIndex[p.ID] = match + 1
+	return match < n.lastIndex
+}
+
+// advanceCommit commits the newest entry of the current term a majority
+// stores. Callers hold n.mu.
+func (n *Node) advanceCommit() {
+	for index := n.lastIndex; index > n.commitIndex; index-- {
+		t, err := n.termAt(index)
+		if err != nil || t != n.term {
+			return
+		}
+		count := 1
+		for _, p := range n.peers {
+			if n.matchIndex[p.ID] >= index {
+				count++
+			}
+		}
+		if count > len(n.cfg.Peers)/2 {
+			n.commitIndex = index
+			n.notify()
+			return
+		}
+	}
+}
+
+// maybeCompact drops the applied entries every member stores from the log
+// once CompactEvery of them have built up. The leader works out how far
+// that is from what each peer has acknowledged, since committed entries
+// are never truncated and so no leader will send them to anyone again; the
+// other members learn it from the leader. Callers hold n.mu.
+func (n *Node) maybeCompact() {
+	if n.role == leader {
+		bound := n.commitIndex
+		for _, p := range n.peers {
+			bound = min(bound, n.matchIndex[p.ID])
+		}
+		n.compactBound = max(n.compactBound, bound)
+	}
+	through := min(n.lastApplied, n.compactBound)
+	if through < n.compactIndex+n.cfg.CompactEvery {
+		return
+	}
+	if err := n.compact(through); err != nil {
+		log.Printf("raft %s: compact log up to %d: %v", n.cfg.ID, through, err)
+	}
+}
+
+// applyLoop hands committed entries to Apply in order.
+func (n *Node) applyLoop() {
+	defer n.wg.Done()
+	for {
+		n.mu.Lock()
+		for n.lastApplied >= n.commitIndex {
+			changed := n.changed
+			n.mu.Unlock()
+			select {
+			case <-changed:
+			case <-n.ctx.Done():
+				return
+			}
+			n.mu.Lock()
+		}
+		index := n.lastApplied + 1
+		e, err := n.entry(index)
+		n.mu.Unlock()
+		if err == nil {
+			err = n.cfg.Apply(index, e.GetData())
+		}
+		if err != nil {
+			log.Printf("raft %s: apply entry %d: %v", n.cfg.ID, index, err)
+			select {
+			case <-time.After(n.cfg.HeartbeatInterval):
+			case <-n.ctx.Done():
+				return
+			}
+			continue
+		}
+
+		n.mu.Lock()
+		n.lastApplied = index
+		tookOver := n.role == leader && index == n.readyIndex
+		n.notify()
+		n.maybeCompact()
+		n.mu.Unlock()
+		if tookOver && n.cfg.OnLeader != nil {
+			go n.cfg.OnLeader()
+		}
+	}
+}
+
+func (n *Node) RequestVote(_ context.Context, req *protogen.RequestVoteRequest) (*protogen.RequestVoteResponse, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.ctx.Err() != nil {
+		return nil, status.Error(codes.Unavailable, ErrStopped.Error())
+	}
+	if req.GetTerm() > n.term {
+		n.becomeFollower(req.GetTerm())
+	}
+	resp := &protogen.RequestVoteResponse{Term: n.term}
+	if req.GetTerm() < n.term {
+		return resp, nil
+	}
+	// Only a candidate whose log holds everything this node stores can have
+	// every committed entry.
+	upToDate := req.GetLastLogTerm() > n.lastTerm ||
+		(req.GetLastLogTerm() == n.lastTerm && req.GetLastLogIndex() >= n.lastIndex)
+	if !upToDate || (n.votedFor != "" && n.votedFor != req.GetCandidateId()) {
+		return resp, nil
+	}
+	n.votedFor = req.GetCandidateId()
+	if err := n.persistState(); err != nil {
+		return nil, status.Errorf(codes.Internal, "persist vote: %v", err)
+	}
+	n.resetDeadline()
+	resp.VoteGranted = true
+	return resp, nil
+}
+
+func (n *Node) AppendEntries(_ context.Context, req *protogen.AppendEntriesRequest) (*protogen.AppendEntriesResponse, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.ctx.Err() != nil {
+		return nil, status.Error(codes.Unavailable, ErrStopped.Error())
+	}
+	if req.GetTerm() < n.term {
+		return &protogen.AppendEntriesResponse{Term: n.term}, nil
+	}
+	if req.GetTerm() > n.term || n.role != follower {
+		n.becomeFollower(req.GetTerm())
+	}
+	if n.leaderID != req.GetLeaderId() {
+		n.leaderID = req.GetLeaderId()
+		n.notify()
+	}
+	n.resetDeadline()
+
+	resp := &protogen.AppendEntriesResponse{Term: n.term}
+	prev, es := req.GetPrevLogIndex(), req.GetEntries()
+	switch {
+	case prev < n.compactIndex:
+		// Compacted entries are committed, so they match the leader's.
+		for len(es) > 0 && es[0].GetIndex() <= n.compactIndex {
+			es = es[1:]
+		}
+	case prev > n.lastIndex:
+		resp.NextIndex = n.lastIndex + 1
+		return resp, nil
+	default:
+		prevTerm, err := n.termAt(prev)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "read raft log: %v", err)
+		}
+		if prevTerm != req.GetPrevLogTerm() {
+			resp.NextIndex = prev
+			return resp, nil
+		}
+	}
+
+	// Entries this node already has are skipped; the first that conflicts
+	// drops it and everything after it.
+	var truncateFrom uint64
+	for i, e := range es {
+		if e.GetIndex() > n.lastIndex {
+			es = es[i:]
+			break
+		}
+		t, err := n.termAt(e.GetIndex())
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "read raft log: %v", err)
+		}
+		if t != e.GetTerm() {
+			truncateFrom = e.GetIndex()
+			es = es[i:]
+			break
+		}
+		if i == len(es)-1 {
+			es = nil
+		}
+	}
+	if len(es) > 0 || truncateFrom != 0 {
+		if err := n.storeEntries(es, truncateFrom); err != nil {
+			return nil, status.Errorf(codes.Internal, "store raft entries: %v", err)
+		}
+	}
+
+	lastNew := prev + uint64(len(req.GetEntries()))
+	if req.GetLeaderCommit() > n.commitIndex {
+		n.commitIndex = max(n.commitIndex, min(req.GetLeaderCommit(), lastNew))
+		n.notify()
+	}
+	if bound := min(req.GetCompactIndex(), lastNew); bound > n.compactBound {
+		n.compactBound = bound
+		n.maybeCompact()
+	}
+	resp.Success = true
+	return resp, nil
+}
@@ -0,0 +1,50 @@
+// Package shardmap tells which shard of a sharded MDS holds an inode. Both
+// the MDS shards and the clients that route calls to them use it.
+package shardmap
+
+import (
+	"strconv"
+	"strings"
+
+	protogen "github.com/rachanaanugandula/kube-pfs/pkg/proto/gen"
+)
+
+// inoBits is how many low bits of an inode number a shard numbers its
+// inodes with; the bits above are the shard's place in the map.
+const inoBits = 48
+
+// FirstIno returns the first inode number of the i'th shard in a map.
+func FirstIno(i int) uint64 {
+	return uint64(i) << inoBits
+}
+
+// Ino returns the number in an inode ID: inode-<ino>, or root for ino 1.
+func Ino(inodeID string) (uint64, bool) {
+	if inodeID == "root" {
+		return 1, true
+	}
+	rest, ok := strings.CutPrefix(inodeID, "inode-")
+	if !ok {
+		return 0, false
+	}
+	ino, err := strconv.ParseUint(rest, 10, 64)
+	return ino, err == nil
+}
+
+// Owner returns the shard holding the inode: the last one, in a map sorted
+// by first_ino, that starts at or below its number. It returns nil for an
+// empty map or an ID that is not an inode ID.
+func Owner(shards []*protogen.MDSShard, inodeID string) *protogen.MDSShard {
+	ino, ok := Ino(inodeID)
+	if !ok {
+		return nil
+	}
+	var owner *protogen.MDSShard
+	for _, s := range shards {
+		if s.GetFirstIno() > ino {
+			break
+		}
+		owner = s
+	}
+	return owner
+}
@@ -28,6 +28,6 @@ protoc \
   --proto_path=proto \
   --go_out=pkg/proto/gen --go_opt=paths=source_relative \
   --go-grpc_out=pkg/proto/gen --go-grpc_opt=paths=source_relative \
-  proto/metadata.proto proto/object_storage.proto
+  proto/metadata.proto proto/object_storage.proto proto/raft.proto proto/shard.proto
 
 echo "protobuf generation completed: pkg/proto/gen"
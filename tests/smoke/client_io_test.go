@@ -0,0 +1,375 @@
+package smoke
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/rachanaanugandula/kube-pfs/pkg/client"
+	"github.com/rachanaanugandula/kube-pfs/pkg/mds"
+	"github.com/rachanaanugandula/kube-pfs/pkg/ost"
+	protogen "github.com/rachanaanugandula/kube-pfs/pkg/proto/gen"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type testCluster struct {
+	mds        *mds.Service
+	osts       map[string]*ost.Service
+	ostServers map[string]*grpc.Server
+	ostClients map[string]protogen.ObjectStorageServiceClient
+	client     *client.Client
+}
+
+// serveInProcess starts a gRPC server on an in-memory listener and returns a
+// connection to it, so tests exercise real RPC framing without ports.
+func serveInProcess(t *testing.T, register func(*grpc.Server)) *grpc.ClientConn {
+	t.Helper()
+	_, conn := startInProcess(t, register)
+	return conn
+}
+
+func startInProcess(t *testing.T, register func(*grpc.Server)) (*grpc.Server, *grpc.ClientConn) {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	register(srv)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dial in-process server: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return srv, conn
+}
+
+func startCluster(t *testing.T, cfg mds.Config, ostCount int) *testCluster {
+	t.Helper()
+	workDir := t.TempDir()
+	cfg.OSTIDs = nil
+	for i := 0; i < ostCount; i++ {
+		cfg.OSTIDs = append(cfg.OSTIDs, fmt.Sprintf("ost-%d", i))
+	}
+	if cfg.BoltPath == "" {
+		cfg.BoltPath = filepath.Join(workDir, "mds.db")
+	}
+	mdsSvc, err := mds.NewService(cfg)
+	if err != nil {
+		t.Fatalf("new mds service: %v", err)
+	}
+	t.Cleanup(func() { _ = mdsSvc.Close() })
+
+	cluster := &testCluster{mds: mdsSvc, osts: map[string]*ost.Service{}, ostServers: map[string]*grpc.Server{}}
+	mdsConn := serveInProcess(t, func(s *grpc.Server) { protogen.RegisterMetadataServiceServer(s, mdsSvc) })
+	ostClients := map[string]protogen.ObjectStorageServiceClient{}
+	for _, id := range cfg.OSTIDs {
+		ostSvc, err := ost.NewService(id, filepath.Join(workDir, id))
+		if err != nil {
+			t.Fatalf("new %s: %v", id, err)
+		}
+		cluster.osts[id] = ostSvc
+		srv, conn := startInProcess(t, func(s *grpc.Server) { protogen.RegisterObjectStorageServiceServer(s, ostSvc) })
+		cluster.ostServers[id] = srv
+		ostClients[id] = protogen.NewObjectStorageServiceClient(conn)
+	}
+	cluster.ostClients = ostClients
+	cluster.client = client.New(protogen.NewMetadataServiceClient(mdsConn), ostClients)
+	return cluster
+}
+
+func randomPayload(seed int64, n int) []byte {
+	buf := make([]byte, n)
+	rand.New(rand.NewSource(seed)).Read(buf)
+	return buf
+}
+
+func TestClientStripedReadWrite(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	const stripe = 64 * 1024
+	cluster := startCluster(t, mds.Config{DefaultStripeSz: stripe}, 3)
+
+	f, err := cluster.client.Create(ctx, "root", "striped.bin", 0644)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	payload := randomPayload(1, 5*stripe+123)
+	if _, err := f.WriteAt(ctx, payload, 0); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	// An unaligned overwrite that straddles a chunk boundary.
+	patch := bytes.Repeat([]byte{0xAB}, 1000)
+	if _, err := f.WriteAt(ctx, patch, 2*stripe-500); err != nil {
+		t.Fatalf("patch write: %v", err)
+	}
+	copy(payload[2*stripe-500:], patch)
+	if err := f.Close(ctx); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	stat, err := cluster.mds.Stat(ctx, &protogen.StatRequest{InodeId: f.InodeID()})
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if stat.GetInode().GetSizeBytes() != uint64(len(payload)) {
+		t.Fatalf("mds size %d, want %d", stat.GetInode().GetSizeBytes(), len(payload))
+	}
+
+	// Every OST should hold two of the six chunks.
+	for id, ostSvc := range cluster.osts {
+		health, _ := ostSvc.GetHealth(ctx, &protogen.HealthRequest{})
+		if health.GetIopsTotal() == 0 {
+			t.Fatalf("%s received no I/O, striping is not spreading chunks", id)
+		}
+	}
+
+	reopened, err := cluster.client.Open(ctx, "root", "striped.bin")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	got := make([]byte, len(payload)+10)
+	n, err := reopened.ReadAt(ctx, got, 0)
+	if err != io.EOF || n != len(payload) {
+		t.Fatalf("read: n=%d err=%v, want n=%d io.EOF", n, err, len(payload))
+	}
+	if !bytes.Equal(got[:n], payload) {
+		t.Fatalf("read back does not match written payload")
+	}
+
+	if err := reopened.Truncate(ctx, stripe+10); err != nil {
+		t.Fatalf("truncate: %v", err)
+	}
+	if err := reopened.Truncate(ctx, 3*stripe); err != nil {
+		t.Fatalf("extend: %v", err)
+	}
+	got = make([]byte, 3*stripe)
+	if _, err := reopened.ReadAt(ctx, got, 0); err != nil {
+		t.Fatalf("read after truncate: %v", err)
+	}
+	if !bytes.Equal(got[:stripe+10], payload[:stripe+10]) {
+		t.Fatalf("bytes before the truncation point changed")
+	}
+	if !bytes.Equal(got[stripe+10:], make([]byte, 2*stripe-10)) {
+		t.Fatalf("expected zeros past the old truncation point")
+	}
+}
+
+func TestClientLargeStripeUsesStreams(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	// 8 MiB chunks are past gRPC's default 4 MiB message limit, so this only
+	// passes if chunk I/O goes over the streaming RPCs.
+	const stripe = 8 * 1024 * 1024
+	cluster := startCluster(t, mds.Config{DefaultStripeSz: stripe}, 2)
+
+	f, err := cluster.client.Create(ctx, "root", "checkpoint.bin", 0644)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	payload := randomPayload(2, stripe+4096)
+	if _, err := f.WriteAt(ctx, payload, 0); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := f.Close(ctx); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	got := make([]byte, len(payload))
+	if _, err := f.ReadAt(ctx, got, 0); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Fatalf("read back does not match written payload")
+	}
+}
+
+func TestClientReplicatedLayoutSurvivesOSTLoss(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	const stripe = 64 * 1024
+	cluster := startCluster(t, mds.Config{DefaultStripeSz: stripe, ReplicaCount: 2}, 3)
+
+	f, err := cluster.client.Create(ctx, "root", "mirrored.bin", 0644)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if got := f.Layout().GetReplicaCount(); got != 2 {
+		t.Fatalf("layout replica count %d, want 2", got)
+	}
+	payload := randomPayload(5, 6*stripe+77)
+	if _, err := f.WriteAt(ctx, payload, 0); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := f.Close(ctx); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	// Take one OST offline, as when fault-injector deletes its pod.
+	cluster.ostServers["ost-1"].Stop()
+	got := make([]byte, len(payload))
+	if _, err := f.ReadAt(ctx, got, 0); err != nil {
+		t.Fatalf("read with one OST down: %v", err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Fatalf("read back with one OST down does not match written payload")
+	}
+
+	if _, err := mds.NewService(mds.Config{BoltPath: filepath.Join(t.TempDir(), "mds.db"), OSTIDs: []string{"ost-0"}, ReplicaCount: 2}); err == nil {
+		t.Fatalf("expected an error for more replicas than OSTs")
+	}
+}
+
+func TestClientErasureCodedLayoutSurvivesTwoOSTLosses(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	const stripe = 16 * 1024
+	const group = 4 * stripe
+	cluster := startCluster(t, mds.Config{DefaultStripeSz: stripe}, 6)
+
+	ec := &protogen.ErasureCode{DataChunks: 4, ParityChunks: 2}
+	dir, err := cluster.mds.Create(ctx, &protogen.CreateRequest{ParentInodeId: "root", Name: "ec", IsDir: true, ErasureCode: ec})
+	if err != nil {
+		t.Fatalf("mkdir ec: %v", err)
+	}
+	dirID := dir.GetInode().GetInodeId()
+	if _, err := cluster.mds.Create(ctx, &protogen.CreateRequest{ParentInodeId: "root", Name: "f", ErasureCode: ec}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("erasure code on a file: got %v, want InvalidArgument", err)
+	}
+	wide := &protogen.ErasureCode{DataChunks: 5, ParityChunks: 2}
+	if _, err := cluster.mds.Create(ctx, &protogen.CreateRequest{ParentInodeId: "root", Name: "wide", IsDir: true, ErasureCode: wide}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("5+2 on 6 OSTs: got %v, want InvalidArgument", err)
+	}
+
+	f, err := cluster.client.Create(ctx, dirID, "coded.bin", 0644)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if got := f.Layout().GetErasureCode(); got.GetDataChunks() != 4 || got.GetParityChunks() != 2 {
+		t.Fatalf("file did not inherit the directory's erasure code: %v", got)
+	}
+
+	// Group 0 in full, group 1 left as a hole, group 2 partly written.
+	payload := make([]byte, 2*group+3*stripe+123)
+	copy(payload, randomPayload(6, group))
+	copy(payload[2*group:], randomPayload(7, 3*stripe+123))
+	if _, err := f.WriteAt(ctx, payload[:group], 0); err != nil {
+		t.Fatalf("write group 0: %v", err)
+	}
+	if _, err := f.WriteAt(ctx, payload[2*group:], 2*group); err != nil {
+		t.Fatalf("write group 2: %v", err)
+	}
+	// An unaligned overwrite inside a group has to re-encode its parity.
+	patch := bytes.Repeat([]byte{0xCD}, 3000)
+	if _, err := f.WriteAt(ctx, patch, stripe-1000); err != nil {
+		t.Fatalf("patch write: %v", err)
+	}
+	copy(payload[stripe-1000:], patch)
+	if err := f.Truncate(ctx, int64(2*group+stripe+50)); err != nil {
+		t.Fatalf("truncate: %v", err)
+	}
+	payload = payload[:2*group+stripe+50]
+	if err := f.Close(ctx); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	cluster.ostServers["ost-1"].Stop()
+	cluster.ostServers["ost-4"].Stop()
+	got := make([]byte, len(payload))
+	if _, err := f.ReadAt(ctx, got, 0); err != nil {
+		t.Fatalf("read with two OSTs down: %v", err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Fatalf("degraded read does not match written payload")
+	}
+	small := make([]byte, 100)
+	if n, err := f.ReadAt(ctx, small, int64(2*group+stripe-20)); err != io.EOF || n != 70 {
+		t.Fatalf("small degraded read: n=%d err=%v, want n=70 io.EOF", n, err)
+	}
+	if !bytes.Equal(small[:70], payload[2*group+stripe-20:]) {
+		t.Fatalf("small degraded read does not match written payload")
+	}
+
+	cluster.ostServers["ost-2"].Stop()
+	if _, err := f.ReadAt(ctx, got, 0); err == nil {
+		t.Fatalf("expected a read error with three of six cells lost")
+	}
+}
+
+// flakyOST fails block writes or reads on request.
+type flakyOST struct {
+	protogen.ObjectStorageServiceClient
+	failWrites, failReads atomic.Bool
+}
+
+func (o *flakyOST) WriteBlock(ctx context.Context, in *protogen.WriteBlockRequest, opts ...grpc.CallOption) (*protogen.WriteBlockResponse, error) {
+	if o.failWrites.Load() {
+		return nil, status.Error(codes.Unavailable, "injected: write failed")
+	}
+	return o.ObjectStorageServiceClient.WriteBlock(ctx, in, opts...)
+}
+
+func (o *flakyOST) ReadBlock(ctx context.Context, in *protogen.ReadBlockRequest, opts ...grpc.CallOption) (*protogen.ReadBlockResponse, error) {
+	if o.failReads.Load() {
+		return nil, status.Error(codes.Unavailable, "injected: read failed")
+	}
+	return o.ObjectStorageServiceClient.ReadBlock(ctx, in, opts...)
+}
+
+func TestClientErasureCodedGroupsNeverMixGenerations(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	const stripe = 16 * 1024
+	const group = 4 * stripe
+	cluster := startCluster(t, mds.Config{DefaultStripeSz: stripe}, 6)
+	ec := &protogen.ErasureCode{DataChunks: 4, ParityChunks: 2}
+	dir, err := cluster.mds.Create(ctx, &protogen.CreateRequest{ParentInodeId: "root", Name: "ec", IsDir: true, ErasureCode: ec})
+	if err != nil {
+		t.Fatalf("mkdir ec: %v", err)
+	}
+	osts := map[string]*flakyOST{}
+	clients := map[string]protogen.ObjectStorageServiceClient{}
+	for id, c := range cluster.ostClients {
+		osts[id] = &flakyOST{ObjectStorageServiceClient: c}
+		clients[id] = osts[id]
+	}
+	mdsConn := serveInProcess(t, func(s *grpc.Server) { protogen.RegisterMetadataServiceServer(s, cluster.mds) })
+	c := client.New(protogen.NewMetadataServiceClient(mdsConn), clients)
+
+	f, err := c.Create(ctx, dir.GetInode().GetInodeId(), "coded.bin", 0644)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := f.WriteAt(ctx, randomPayload(8, group), 0); err != nil {
+		t.Fatalf("write group: %v", err)
+	}
+
+	// An overwrite that reaches only three of the six cells leaves the
+	// group split three and three between two generations.
+	for _, id := range []string{"ost-0", "ost-2", "ost-4"} {
+		osts[id].failWrites.Store(true)
+	}
+	if _, err := f.WriteAt(ctx, randomPayload(9, group), 0); err == nil {
+		t.Fatalf("expected the overwrite to fail with three OSTs refusing writes")
+	}
+
+	// With one cell unreadable, neither generation has the four cells a
+	// rebuild needs, so the read must fail instead of mixing them.
+	osts["ost-1"].failReads.Store(true)
+	if _, err := f.ReadAt(ctx, make([]byte, group), 0); err == nil {
+		t.Fatalf("expected a degraded read of a group split between generations to fail")
+	}
+}
@@ -0,0 +1,102 @@
+package smoke
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rachanaanugandula/kube-pfs/pkg/client"
+	protogen "github.com/rachanaanugandula/kube-pfs/pkg/proto/gen"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestMDSClientFollowsLeader(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	members := startReplicatedMDS(t, 3)
+	leader := waitForLeader(t, members)
+
+	// List the followers first, so the client has to be pointed at the leader.
+	var endpoints []string
+	for _, m := range members {
+		if m != leader {
+			endpoints = append(endpoints, m.addr)
+		}
+	}
+	endpoints = append(endpoints, leader.addr)
+	mdsClient, err := client.DialMDS(endpoints, client.MDSConfig{
+		AttemptTimeout: time.Second,
+		CallTimeout:    10 * time.Second,
+		MinBackoff:     10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("dial mds: %v", err)
+	}
+	defer mdsClient.Close()
+
+	dir, err := mdsClient.Create(ctx, &protogen.CreateRequest{ParentInodeId: "root", Name: "dir", IsDir: true})
+	if err != nil {
+		t.Fatalf("create through a follower: %v", err)
+	}
+	mustExist(t, leader.svc, "root", "dir", true)
+
+	// Repeating a request ID gets the original response instead of
+	// AlreadyExists or deleted=false.
+	createFile := &protogen.CreateRequest{
+		ParentInodeId: dir.GetInode().GetInodeId(),
+		Name:          "file",
+		RequestId:     &protogen.RequestID{ClientId: "smoke", Seq: 1},
+	}
+	first, err := mdsClient.Create(ctx, createFile)
+	if err != nil {
+		t.Fatalf("create file: %v", err)
+	}
+	again, err := mdsClient.Create(ctx, createFile)
+	if err != nil || again.GetInode().GetInodeId() != first.GetInode().GetInodeId() {
+		t.Fatalf("repeated create returned %v, %v; want inode %s", again.GetInode(), err, first.GetInode().GetInodeId())
+	}
+	unlinkFile := &protogen.UnlinkRequest{
+		ParentInodeId: dir.GetInode().GetInodeId(),
+		Name:          "file",
+		RequestId:     &protogen.RequestID{ClientId: "smoke", Seq: 2},
+	}
+	for i := 0; i < 2; i++ {
+		res, err := mdsClient.Unlink(ctx, unlinkFile)
+		if err != nil || !res.GetDeleted() {
+			t.Fatalf("unlink attempt %d: deleted=%t, %v", i+1, res.GetDeleted(), err)
+		}
+	}
+	// A new request for the same name is not a repeat.
+	if _, err := mdsClient.Create(ctx, &protogen.CreateRequest{
+		ParentInodeId: dir.GetInode().GetInodeId(),
+		Name:          "dir",
+		RequestId:     &protogen.RequestID{ClientId: "smoke", Seq: 3},
+	}); err != nil {
+		t.Fatalf("create with a new request id: %v", err)
+	}
+	if _, err := mdsClient.Create(ctx, &protogen.CreateRequest{
+		ParentInodeId: "root",
+		Name:          "dir",
+		RequestId:     &protogen.RequestID{ClientId: "smoke", Seq: 4},
+	}); status.Code(err) != codes.AlreadyExists {
+		t.Fatalf("create over an existing entry: expected ALREADY_EXISTS, got %v", err)
+	}
+
+	// Calls carry on through a failover, and the replies were replicated.
+	leader.stop()
+	res, err := mdsClient.Lookup(ctx, &protogen.LookupRequest{ParentInodeId: "root", Name: "dir"})
+	if err != nil || res.GetInode().GetInodeId() != dir.GetInode().GetInodeId() {
+		t.Fatalf("lookup after failover: %v, %v", res.GetInode(), err)
+	}
+	again, err = mdsClient.Create(ctx, createFile)
+	if err != nil || again.GetInode().GetInodeId() != first.GetInode().GetInodeId() {
+		t.Fatalf("create repeated on the new leader returned %v, %v; want inode %s", again.GetInode(), err, first.GetInode().GetInodeId())
+	}
+	if _, err := mdsClient.Create(ctx, &protogen.CreateRequest{ParentInodeId: "root", Name: "after"}); err != nil {
+		t.Fatalf("create after failover: %v", err)
+	}
+	next := waitForLeader(t, members)
+	mustExist(t, next.svc, "root", "after", true)
+	mustExist(t, next.svc, dir.GetInode().GetInodeId(), "file", false)
+}
@@ -0,0 +1,314 @@
+package smoke
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/rachanaanugandula/kube-pfs/pkg/mds"
+	protogen "github.com/rachanaanugandula/kube-pfs/pkg/proto/gen"
+	"go.etcd.io/bbolt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestMDSDirLayoutInheritance(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	boltPath := filepath.Join(t.TempDir(), "mds.db")
+	cfg := mds.Config{
+		BoltPath:        boltPath,
+		OSTIDs:          []string{"ost-0", "ost-1", "ost-2", "ost-3"},
+		DefaultStripeSz: 1 << 20,
+	}
+	svc, err := mds.NewService(cfg)
+	if err != nil {
+		t.Fatalf("new mds service: %v", err)
+	}
+
+	// Without a directory layout files take the MDS defaults.
+	plain := mustCreate(t, svc, "root", "plain", false).GetStripeLayout()
+	if plain.GetStripeSizeBytes() != 1<<20 || plain.GetStripeCount() != 4 || len(plain.GetOstIds()) != 4 {
+		t.Fatalf("default file layout %+v", plain)
+	}
+
+	dir := mustCreate(t, svc, "root", "narrow", true)
+	pool := []string{"ost-1", "ost-2", "ost-3"}
+	if _, err := svc.SetDirLayout(ctx, &protogen.SetDirLayoutRequest{
+		InodeId: dir.GetInodeId(),
+		Layout:  &protogen.StripeLayout{StripeSizeBytes: 64 * 1024, StripeCount: 2, OstIds: pool},
+	}); err != nil {
+		t.Fatalf("set dir layout: %v", err)
+	}
+	got, err := svc.GetDirLayout(ctx, &protogen.GetDirLayoutRequest{InodeId: dir.GetInodeId()})
+	if err != nil {
+		t.Fatalf("get dir layout: %v", err)
+	}
+	if got.GetLayout().GetStripeCount() != 2 || got.GetLayout().GetStripeSizeBytes() != 64*1024 {
+		t.Fatalf("dir layout read back as %+v", got.GetLayout())
+	}
+
+	checkFile := func(l *protogen.StripeLayout, stripeSz uint32, count int) {
+		t.Helper()
+		if l.GetStripeSizeBytes() != stripeSz || int(l.GetStripeCount()) != count || len(l.GetOstIds()) != count {
+			t.Fatalf("file layout %+v, want stripe size %d over %d OSTs", l, stripeSz, count)
+		}
+		for _, id := range l.GetOstIds() {
+			if !slices.Contains(pool, id) {
+				t.Fatalf("file layout %v uses %s outside the directory pool", l.GetOstIds(), id)
+			}
+		}
+	}
+	checkFile(mustCreate(t, svc, dir.GetInodeId(), "f", false).GetStripeLayout(), 64*1024, 2)
+
+	// Subdirectories inherit the layout and can override parts of it.
+	sub := mustCreate(t, svc, dir.GetInodeId(), "sub", true)
+	checkFile(mustCreate(t, svc, sub.GetInodeId(), "f", false).GetStripeLayout(), 64*1024, 2)
+	res, err := svc.Create(ctx, &protogen.CreateRequest{
+		ParentInodeId: sub.GetInodeId(),
+		Name:          "wide",
+		Mode:          0644,
+		Layout:        &protogen.StripeLayout{StripeCount: 3},
+	})
+	if err != nil {
+		t.Fatalf("create with layout override: %v", err)
+	}
+	checkFile(res.GetInode().GetStripeLayout(), 64*1024, 3)
+
+	// The layout survives a restart.
+	if err := svc.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	svc, err = mds.NewService(cfg)
+	if err != nil {
+		t.Fatalf("reopen mds service: %v", err)
+	}
+	t.Cleanup(func() { _ = svc.Close() })
+	checkFile(mustCreate(t, svc, dir.GetInodeId(), "after-restart", false).GetStripeLayout(), 64*1024, 2)
+
+	file := mustCreate(t, svc, "root", "file", false)
+	for name, req := range map[string]*protogen.SetDirLayoutRequest{
+		"file":          {InodeId: file.GetInodeId(), Layout: &protogen.StripeLayout{StripeCount: 1}},
+		"unknown ost":   {InodeId: dir.GetInodeId(), Layout: &protogen.StripeLayout{OstIds: []string{"ost-9"}}},
+		"wide stripe":   {InodeId: dir.GetInodeId(), Layout: &protogen.StripeLayout{StripeCount: 3, OstIds: []string{"ost-0", "ost-1"}}},
+		"ec and copies": {InodeId: dir.GetInodeId(), Layout: &protogen.StripeLayout{ReplicaCount: 2, ErasureCode: &protogen.ErasureCode{DataChunks: 2, ParityChunks: 1}}},
+	} {
+		_, err := svc.SetDirLayout(ctx, req)
+		want := codes.InvalidArgument
+		if name == "file" {
+			want = codes.FailedPrecondition
+		}
+		if status.Code(err) != want {
+			t.Fatalf("set dir layout (%s): got %v, want %v", name, err, want)
+		}
+	}
+	_, err = svc.Create(ctx, &protogen.CreateRequest{
+		ParentInodeId: "root",
+		Name:          "ec-file",
+		Mode:          0644,
+		Layout:        &protogen.StripeLayout{ErasureCode: &protogen.ErasureCode{DataChunks: 2, ParityChunks: 1}},
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("create file with erasure_code override: got %v, want InvalidArgument", err)
+	}
+}
+
+func TestMDSLegacyDirLayouts(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	boltPath := filepath.Join(t.TempDir(), "mds.db")
+	cfg := mds.Config{
+		BoltPath: boltPath,
+		OSTIDs:   []string{"ost-0", "ost-1"},
+	}
+	svc, err := mds.NewService(cfg)
+	if err != nil {
+		t.Fatalf("new mds service: %v", err)
+	}
+	old := mustCreate(t, svc, "root", "old", true)
+	pinned := mustCreate(t, svc, "root", "pinned", true)
+	if _, err := svc.SetDirLayout(ctx, &protogen.SetDirLayoutRequest{
+		InodeId: pinned.GetInodeId(),
+		Layout:  &protogen.StripeLayout{StripeCount: 1, OstIds: []string{"ost-1"}},
+	}); err != nil {
+		t.Fatalf("set dir layout: %v", err)
+	}
+	if err := svc.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	// Directories made before layouts were handed down recorded every OST
+	// configured at the time, and databases from then lack the marker.
+	ec := &protogen.ErasureCode{DataChunks: 1, ParityChunks: 1}
+	db, err := bbolt.Open(boltPath, 0600, nil)
+	if err != nil {
+		t.Fatalf("open bolt: %v", err)
+	}
+	err = db.Update(func(tx *bbolt.Tx) error {
+		inodesB := tx.Bucket([]byte("inodes"))
+		for _, id := range []string{"root", old.GetInodeId()} {
+			inode := &protogen.Inode{}
+			if err := proto.Unmarshal(inodesB.Get([]byte(id)), inode); err != nil {
+				return err
+			}
+			inode.StripeLayout = &protogen.StripeLayout{StripeSizeBytes: 1 << 20, OstIds: []string{"ost-0", "ost-1"}, ReplicaCount: 1}
+			if id != "root" {
+				inode.StripeLayout.ReplicaCount = 0
+				inode.StripeLayout.ErasureCode = ec
+			}
+			blob, err := proto.Marshal(inode)
+			if err != nil {
+				return err
+			}
+			if err := inodesB.Put([]byte(id), blob); err != nil {
+				return err
+			}
+		}
+		return tx.Bucket([]byte("meta")).Delete([]byte("dir_layouts"))
+	})
+	if err != nil {
+		t.Fatalf("write legacy layouts: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("close bolt: %v", err)
+	}
+
+	// Two OSTs later, new files below them spread over all four, and the
+	// erasure code is still handed down.
+	cfg.OSTIDs = []string{"ost-0", "ost-1", "ost-2", "ost-3"}
+	svc, err = mds.NewService(cfg)
+	if err != nil {
+		t.Fatalf("restart mds service: %v", err)
+	}
+	t.Cleanup(func() { _ = svc.Close() })
+	got, err := svc.GetDirLayout(ctx, &protogen.GetDirLayoutRequest{InodeId: "root"})
+	if err != nil {
+		t.Fatalf("get root layout: %v", err)
+	}
+	if !proto.Equal(got.GetLayout(), &protogen.StripeLayout{}) {
+		t.Fatalf("legacy root layout migrated as %+v", got.GetLayout())
+	}
+	if l := mustCreate(t, svc, "root", "f", false).GetStripeLayout(); len(l.GetOstIds()) != 4 {
+		t.Fatalf("file under the legacy root striped over %v", l.GetOstIds())
+	}
+	got, err = svc.GetDirLayout(ctx, &protogen.GetDirLayoutRequest{InodeId: old.GetInodeId()})
+	if err != nil {
+		t.Fatalf("get old layout: %v", err)
+	}
+	if !proto.Equal(got.GetLayout(), &protogen.StripeLayout{ErasureCode: ec}) {
+		t.Fatalf("legacy directory layout migrated as %+v", got.GetLayout())
+	}
+	if l := mustCreate(t, svc, old.GetInodeId(), "f", false).GetStripeLayout(); len(l.GetOstIds()) != 4 || !proto.Equal(l.GetErasureCode(), ec) {
+		t.Fatalf("file under the legacy directory got layout %+v", l)
+	}
+
+	// Layouts set on purpose are left alone.
+	if l := mustCreate(t, svc, pinned.GetInodeId(), "f", false).GetStripeLayout(); !slices.Equal(l.GetOstIds(), []string{"ost-1"}) {
+		t.Fatalf("file under the pinned directory striped over %v", l.GetOstIds())
+	}
+}
+
+func TestMDSOSTPools(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	if _, err := mds.NewService(mds.Config{
+		BoltPath: filepath.Join(t.TempDir(), "bad.db"),
+		OSTIDs:   []string{"ost-0"},
+		Pools:    map[string][]string{"fast": {"ost-7"}},
+	}); err == nil {
+		t.Fatalf("expected an error for a pool listing an unknown OST")
+	}
+
+	svc, err := mds.NewService(mds.Config{
+		BoltPath: filepath.Join(t.TempDir(), "mds.db"),
+		OSTIDs:   []string{"ost-0", "ost-1", "ost-2"},
+		Pools:    map[string][]string{"fast": {"ost-0", "ost-1"}, "capacity": nil},
+	})
+	if err != nil {
+		t.Fatalf("new mds service: %v", err)
+	}
+	t.Cleanup(func() { _ = svc.Close() })
+
+	// OSTs join a pool when they register, but only one the MDS defines.
+	for _, id := range []string{"ost-3", "ost-4"} {
+		if _, err := svc.RegisterOST(ctx, &protogen.RegisterOSTRequest{OstId: id, Pool: "capacity"}); err != nil {
+			t.Fatalf("register %s: %v", id, err)
+		}
+	}
+	if _, err := svc.RegisterOST(ctx, &protogen.RegisterOSTRequest{OstId: "ost-5", Pool: "archive"}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("register into an undefined pool: got %v, want InvalidArgument", err)
+	}
+	// An OST the MDS configures in a pool cannot register into another one,
+	// and one that names no pool stays in it.
+	if _, err := svc.RegisterOST(ctx, &protogen.RegisterOSTRequest{OstId: "ost-0", Pool: "capacity"}); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("register into a pool other than the configured one: got %v, want FailedPrecondition", err)
+	}
+	if info := ostInfo(t, svc, "ost-0"); info.GetPool() != "fast" || info.GetLastHeartbeatUnix() != 0 {
+		t.Fatalf("refused registration left ost-0 as %+v", info)
+	}
+	for _, pool := range []string{"fast", ""} {
+		if _, err := svc.RegisterOST(ctx, &protogen.RegisterOSTRequest{OstId: "ost-0", Pool: pool}); err != nil {
+			t.Fatalf("register ost-0 with pool %q: %v", pool, err)
+		}
+		if got := ostInfo(t, svc, "ost-0").GetPool(); got != "fast" {
+			t.Fatalf("ost-0 registered with pool %q listed in pool %q, want fast", pool, got)
+		}
+	}
+	if got := ostInfo(t, svc, "ost-3").GetPool(); got != "capacity" {
+		t.Fatalf("ost-3 listed in pool %q, want capacity", got)
+	}
+	if got := ostInfo(t, svc, "ost-1").GetPool(); got != "fast" {
+		t.Fatalf("ost-1 listed in pool %q, want fast", got)
+	}
+
+	for pool, members := range map[string][]string{"fast": {"ost-0", "ost-1"}, "capacity": {"ost-3", "ost-4"}} {
+		dir := mustCreate(t, svc, "root", pool, true)
+		if _, err := svc.SetDirLayout(ctx, &protogen.SetDirLayoutRequest{
+			InodeId: dir.GetInodeId(),
+			Layout:  &protogen.StripeLayout{Pool: pool},
+		}); err != nil {
+			t.Fatalf("set %s dir layout: %v", pool, err)
+		}
+		for i := 0; i < 4; i++ {
+			file := mustCreate(t, svc, dir.GetInodeId(), fmt.Sprintf("f%d", i), false)
+			l := file.GetStripeLayout()
+			if l.GetPool() != pool || len(l.GetOstIds()) != 2 {
+				t.Fatalf("file in %s dir got layout %+v", pool, l)
+			}
+			for _, id := range l.GetOstIds() {
+				if !slices.Contains(members, id) {
+					t.Fatalf("file in %s dir placed on %s", pool, id)
+				}
+			}
+			stat, err := svc.Stat(ctx, &protogen.StatRequest{InodeId: file.GetInodeId()})
+			if err != nil || stat.GetInode().GetStripeLayout().GetPool() != pool {
+				t.Fatalf("stat %s file: pool %q, err %v", pool, stat.GetInode().GetStripeLayout().GetPool(), err)
+			}
+		}
+		list, err := svc.ListDir(ctx, &protogen.ListDirRequest{InodeId: dir.GetInodeId()})
+		if err != nil {
+			t.Fatalf("list %s dir: %v", pool, err)
+		}
+		for _, entry := range list.GetEntries() {
+			if entry.GetStripeLayout().GetPool() != pool {
+				t.Fatalf("listed %s in pool %q, want %s", entry.GetName(), entry.GetStripeLayout().GetPool(), pool)
+			}
+		}
+	}
+
+	if _, err := svc.SetDirLayout(ctx, &protogen.SetDirLayoutRequest{
+		InodeId: "root",
+		Layout:  &protogen.StripeLayout{Pool: "archive"},
+	}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("set layout with an undefined pool: got %v, want InvalidArgument", err)
+	}
+	if _, err := svc.SetDirLayout(ctx, &protogen.SetDirLayoutRequest{
+		InodeId: "root",
+		Layout:  &protogen.StripeLayout{Pool: "fast", OstIds: []string{"ost-0", "ost-3"}},
+	}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("set layout with an OST outside its pool: got %v, want InvalidArgument", err)
+	}
+}
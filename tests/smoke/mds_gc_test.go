@@ -0,0 +1,179 @@
+package smoke
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rachanaanugandula/kube-pfs/pkg/layout"
+	"github.com/rachanaanugandula/kube-pfs/pkg/mds"
+	protogen "github.com/rachanaanugandula/kube-pfs/pkg/proto/gen"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestMDSGarbageCollectsUnlinkedChunks(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	const stripe = 64 * 1024
+	boltPath := filepath.Join(t.TempDir(), "mds.db")
+	cluster := startCluster(t, mds.Config{BoltPath: boltPath, DefaultStripeSz: stripe, ReplicaCount: 2}, 3)
+
+	ostClients := map[string]protogen.ObjectStorageServiceClient{}
+	for id, svc := range cluster.osts {
+		conn := serveInProcess(t, func(s *grpc.Server) { protogen.RegisterObjectStorageServiceServer(s, svc) })
+		ostClients["bufconn-"+id] = protogen.NewObjectStorageServiceClient(conn)
+		if _, err := cluster.mds.RegisterOST(ctx, &protogen.RegisterOSTRequest{OstId: id, Address: "bufconn-" + id}); err != nil {
+			t.Fatalf("register %s: %v", id, err)
+		}
+	}
+	var ost1Down atomic.Bool
+	dial := func(address string) (protogen.ObjectStorageServiceClient, error) {
+		if address == "bufconn-ost-1" && ost1Down.Load() {
+			return nil, errors.New("connection refused")
+		}
+		client, ok := ostClients[address]
+		if !ok {
+			return nil, fmt.Errorf("unexpected address %q", address)
+		}
+		return client, nil
+	}
+
+	// One file is unlinked and another is replaced by a rename; both leave
+	// chunks behind that only the collector removes.
+	var refs []*protogen.BlockRef
+	for _, name := range []string{"unlinked.bin", "replaced.bin", "survivor.bin"} {
+		f, err := cluster.client.Create(ctx, "root", name, 0644)
+		if err != nil {
+			t.Fatalf("create %s: %v", name, err)
+		}
+		if _, err := f.WriteAt(ctx, randomPayload(9, 3*stripe+10), 0); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+		if err := f.Sync(ctx); err != nil {
+			t.Fatalf("sync %s: %v", name, err)
+		}
+		if name != "survivor.bin" {
+			refs = append(refs, layout.TruncatedBlocks(f.InodeID(), f.Layout(), 3*stripe+10, 0)...)
+		}
+	}
+	if _, err := cluster.mds.Unlink(ctx, &protogen.UnlinkRequest{ParentInodeId: "root", Name: "unlinked.bin"}); err != nil {
+		t.Fatalf("unlink: %v", err)
+	}
+	if _, err := cluster.mds.Rename(ctx, &protogen.RenameRequest{
+		SrcParentInodeId: "root", SrcName: "survivor.bin",
+		DstParentInodeId: "root", DstName: "replaced.bin",
+	}); err != nil {
+		t.Fatalf("rename over replaced.bin: %v", err)
+	}
+	if len(refs) != 16 {
+		t.Fatalf("expected 2 files x 4 chunks x 2 replicas, got %d refs", len(refs))
+	}
+
+	blockExists := func(ref *protogen.BlockRef) bool {
+		_, err := cluster.osts[ref.GetOstId()].ReadBlock(ctx, &protogen.ReadBlockRequest{Block: ref})
+		if status.Code(err) == codes.NotFound {
+			return false
+		}
+		if err != nil {
+			t.Fatalf("read chunk %d on %s: %v", ref.GetChunkId(), ref.GetOstId(), err)
+		}
+		return true
+	}
+	for _, ref := range refs {
+		if !blockExists(ref) {
+			t.Fatalf("chunk %d on %s gone before garbage collection", ref.GetChunkId(), ref.GetOstId())
+		}
+	}
+
+	// With ost-1 unreachable the pass fails, deletes what it can and keeps
+	// both files queued.
+	ost1Down.Store(true)
+	gc := mds.NewGarbageCollector(cluster.mds, dial, mds.GCConfig{Interval: time.Millisecond, MaxBackoff: 10 * time.Millisecond})
+	if err := gc.CollectOnce(ctx); err == nil {
+		t.Fatalf("expected an error while ost-1 is unreachable")
+	}
+	for _, ref := range refs {
+		if want := ref.GetOstId() == "ost-1"; blockExists(ref) != want {
+			t.Fatalf("after failed pass chunk %d on %s exists=%v, want %v", ref.GetChunkId(), ref.GetOstId(), !want, want)
+		}
+	}
+
+	// Once it is back, a retry after the backoff finishes the job.
+	ost1Down.Store(false)
+	time.Sleep(20 * time.Millisecond)
+	if err := gc.CollectOnce(ctx); err != nil {
+		t.Fatalf("retry pass: %v", err)
+	}
+	for _, ref := range refs {
+		if blockExists(ref) {
+			t.Fatalf("chunk %d on %s survived garbage collection", ref.GetChunkId(), ref.GetOstId())
+		}
+	}
+
+	// The renamed survivor keeps its data.
+	f, err := cluster.client.Open(ctx, "root", "replaced.bin")
+	if err != nil {
+		t.Fatalf("open survivor: %v", err)
+	}
+	buf := make([]byte, 3*stripe+10)
+	if _, err := f.ReadAt(ctx, buf, 0); err != nil {
+		t.Fatalf("read survivor: %v", err)
+	}
+}
+
+func TestMDSPendingDeletesSurviveRestart(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	boltPath := filepath.Join(t.TempDir(), "mds.db")
+	svc := newTestMDS(t, boltPath)
+	file := mustCreate(t, svc, "root", "doomed", false)
+	if _, err := svc.SetAttr(ctx, &protogen.SetAttrRequest{InodeId: file.GetInodeId(), Mask: uint32(protogen.SetAttrMask_SETATTR_SIZE), SizeBytes: 10}); err != nil {
+		t.Fatalf("set size: %v", err)
+	}
+	if _, err := svc.Unlink(ctx, &protogen.UnlinkRequest{ParentInodeId: "root", Name: "doomed"}); err != nil {
+		t.Fatalf("unlink: %v", err)
+	}
+	if err := svc.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	svc = newTestMDS(t, boltPath)
+	t.Cleanup(func() { _ = svc.Close() })
+	var deletes []*protogen.BlockRef
+	dial := func(address string) (protogen.ObjectStorageServiceClient, error) {
+		return &recordingOST{deletes: &deletes}, nil
+	}
+	for _, id := range []string{"ost-0", "ost-1", "ost-2"} {
+		if _, err := svc.RegisterOST(ctx, &protogen.RegisterOSTRequest{OstId: id, Address: id}); err != nil {
+			t.Fatalf("register %s: %v", id, err)
+		}
+	}
+	gc := mds.NewGarbageCollector(svc, dial, mds.GCConfig{})
+	if err := gc.CollectOnce(ctx); err != nil {
+		t.Fatalf("collect: %v", err)
+	}
+	if len(deletes) != 1 || deletes[0].GetFileId() != file.GetInodeId() {
+		t.Fatalf("deletes after restart: %v", deletes)
+	}
+	if err := gc.CollectOnce(ctx); err != nil || len(deletes) != 1 {
+		t.Fatalf("second pass: err %v, %d deletes", err, len(deletes))
+	}
+}
+
+// recordingOST records DeleteBlock calls. Any other RPC hits the nil
+// embedded client and panics.
+type recordingOST struct {
+	protogen.ObjectStorageServiceClient
+	deletes *[]*protogen.BlockRef
+}
+
+func (o *recordingOST) DeleteBlock(_ context.Context, req *protogen.DeleteBlockRequest, _ ...grpc.CallOption) (*protogen.DeleteBlockResponse, error) {
+	*o.deletes = append(*o.deletes, req.GetBlock())
+	return &protogen.DeleteBlockResponse{Deleted: true}, nil
+}
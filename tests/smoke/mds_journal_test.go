@@ -0,0 +1,298 @@
+package smoke
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rachanaanugandula/kube-pfs/pkg/mds"
+	protogen "github.com/rachanaanugandula/kube-pfs/pkg/proto/gen"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newJournaledMDS(t *testing.T, boltPath, journalPath string) *mds.Service {
+	t.Helper()
+	svc, err := mds.NewService(mds.Config{
+		BoltPath:    boltPath,
+		OSTIDs:      []string{"ost-0", "ost-1", "ost-2"},
+		JournalPath: journalPath,
+	})
+	if err != nil {
+		t.Fatalf("new mds service: %v", err)
+	}
+	return svc
+}
+
+func mustExist(t *testing.T, svc *mds.Service, parentID, name string, want bool) {
+	t.Helper()
+	_, err := svc.Lookup(context.Background(), &protogen.LookupRequest{ParentInodeId: parentID, Name: name})
+	switch {
+	case want && err != nil:
+		t.Fatalf("lookup %s: %v", name, err)
+	case !want && status.Code(err) != codes.NotFound:
+		t.Fatalf("expected %s to be absent, got %v", name, err)
+	}
+}
+
+func TestMDSJournalReplay(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	dir := t.TempDir()
+	boltPath := filepath.Join(dir, "mds.db")
+	journalPath := filepath.Join(dir, "mds.journal")
+	snapPath := filepath.Join(dir, "snap.db")
+
+	svc := newJournaledMDS(t, boltPath, journalPath)
+	mustCreate(t, svc, "root", "before", false)
+	if _, err := svc.Snapshot(snapPath); err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	after := mustCreate(t, svc, "root", "after", false)
+	if _, err := svc.Unlink(ctx, &protogen.UnlinkRequest{ParentInodeId: "root", Name: "before"}); err != nil {
+		t.Fatalf("unlink: %v", err)
+	}
+	svc.Close()
+
+	// Losing the bolt commits after the snapshot is what a crash between
+	// journaling and committing looks like; startup replays them.
+	if err := os.Rename(snapPath, boltPath); err != nil {
+		t.Fatalf("roll back database: %v", err)
+	}
+	// A torn record at the end of the journal is dropped.
+	f, err := os.OpenFile(journalPath, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatalf("open journal: %v", err)
+	}
+	if _, err := f.Write([]byte{0, 0, 1, 0, 0xde, 0xad}); err != nil {
+		t.Fatalf("tear journal: %v", err)
+	}
+	f.Close()
+
+	svc = newJournaledMDS(t, boltPath, journalPath)
+	mustExist(t, svc, "root", "after", true)
+	mustExist(t, svc, "root", "before", false)
+	// The inode counter is read after replay, so new inodes are numbered
+	// past the replayed ones instead of overwriting them.
+	later := mustCreate(t, svc, "root", "later", false)
+	if later.GetIno() <= after.GetIno() {
+		t.Fatalf("inode created after replay got number %d, not past the replayed %d", later.GetIno(), after.GetIno())
+	}
+	if got, err := svc.Lookup(ctx, &protogen.LookupRequest{ParentInodeId: "root", Name: "after"}); err != nil || got.GetInode().GetInodeId() != after.GetInodeId() {
+		t.Fatalf("lookup replayed entry: %v, %v", got.GetInode(), err)
+	}
+	svc.Close()
+
+	svc = newJournaledMDS(t, boltPath, journalPath)
+	defer svc.Close()
+	mustExist(t, svc, "root", "later", true)
+
+	// A journal that belongs to another database is refused.
+	other := filepath.Join(dir, "other.db")
+	if _, err := mds.NewService(mds.Config{BoltPath: other, JournalPath: journalPath}); err == nil {
+		t.Fatalf("expected a database that never used the journal to refuse it")
+	}
+}
+
+func TestMDSJournalRefusesDamagedRecords(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	boltPath := filepath.Join(dir, "mds.db")
+	journalPath := filepath.Join(dir, "mds.journal")
+
+	snapPath := filepath.Join(dir, "snap.db")
+
+	svc := newJournaledMDS(t, boltPath, journalPath)
+	if _, err := svc.Snapshot(snapPath); err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	mustCreate(t, svc, "root", "a", false)
+	mustCreate(t, svc, "root", "b", false)
+	svc.Close()
+
+	// Startup only reads the journal after what bolt has applied, so roll
+	// bolt back to before both records. Then flip a byte in the checksum
+	// of the first record, just past the 24-byte header. The record after
+	// it is intact, so this is not a torn tail and must not be truncated
+	// away.
+	if err := os.Rename(snapPath, boltPath); err != nil {
+		t.Fatalf("roll back database: %v", err)
+	}
+	data, err := os.ReadFile(journalPath)
+	if err != nil {
+		t.Fatalf("read journal: %v", err)
+	}
+	data[30] ^= 0xff
+	if err := os.WriteFile(journalPath, data, 0600); err != nil {
+		t.Fatalf("damage journal: %v", err)
+	}
+	if svc, err := mds.NewService(mds.Config{BoltPath: boltPath, JournalPath: journalPath}); err == nil {
+		svc.Close()
+		t.Fatalf("expected a journal damaged before its last record to be refused")
+	}
+	info, err := os.Stat(journalPath)
+	if err != nil {
+		t.Fatalf("stat journal: %v", err)
+	}
+	if info.Size() != int64(len(data)) {
+		t.Fatalf("journal shrank from %d to %d bytes", len(data), info.Size())
+	}
+
+	// Nothing was lost, so undoing the damage lets the MDS start again.
+	data[30] ^= 0xff
+	if err := os.WriteFile(journalPath, data, 0600); err != nil {
+		t.Fatalf("repair journal: %v", err)
+	}
+	svc = newJournaledMDS(t, boltPath, journalPath)
+	defer svc.Close()
+	mustExist(t, svc, "root", "a", true)
+	mustExist(t, svc, "root", "b", true)
+}
+
+func TestMDSRestoreToPointInTime(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	boltPath := filepath.Join(dir, "mds.db")
+	journalPath := filepath.Join(dir, "mds.journal")
+	snapPath := filepath.Join(dir, "snap.db")
+
+	svc := newJournaledMDS(t, boltPath, journalPath)
+	mustCreate(t, svc, "root", "a", false)
+	snapSeq, err := svc.Snapshot(snapPath)
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	mustCreate(t, svc, "root", "b", false)
+	mustCreate(t, svc, "root", "c", false)
+	time.Sleep(20 * time.Millisecond)
+	cutoff := time.Now()
+	time.Sleep(20 * time.Millisecond)
+	d := mustCreate(t, svc, "root", "d", false)
+	svc.Close()
+
+	bySeq := filepath.Join(dir, "by-seq.db")
+	res, err := mds.Restore(mds.RestoreConfig{SnapshotPath: snapPath, JournalPath: journalPath, OutPath: bySeq, ToSeq: snapSeq + 1})
+	if err != nil {
+		t.Fatalf("restore to seq: %v", err)
+	}
+	if res.Seq != snapSeq+1 || res.Replayed != 1 {
+		t.Fatalf("restore to seq %d reached seq %d after %d records", snapSeq+1, res.Seq, res.Replayed)
+	}
+	restored := newJournaledMDS(t, bySeq, filepath.Join(dir, "by-seq.journal"))
+	mustExist(t, restored, "root", "a", true)
+	mustExist(t, restored, "root", "b", true)
+	mustExist(t, restored, "root", "c", false)
+	// New inodes do not reuse numbers handed out after the restore point.
+	e := mustCreate(t, restored, "root", "e", false)
+	restored.Close()
+	if e.GetIno() <= d.GetIno() {
+		t.Fatalf("restored mds numbered a new inode %d, not past %d", e.GetIno(), d.GetIno())
+	}
+
+	byTime := filepath.Join(dir, "by-time.db")
+	if _, err := mds.Restore(mds.RestoreConfig{SnapshotPath: snapPath, JournalPath: journalPath, OutPath: byTime, ToTime: cutoff}); err != nil {
+		t.Fatalf("restore to time: %v", err)
+	}
+	restored = newJournaledMDS(t, byTime, filepath.Join(dir, "by-time.journal"))
+	defer restored.Close()
+	mustExist(t, restored, "root", "c", true)
+	mustExist(t, restored, "root", "d", false)
+
+	if _, err := mds.Restore(mds.RestoreConfig{SnapshotPath: snapPath, JournalPath: journalPath, OutPath: byTime}); err == nil {
+		t.Fatalf("expected restore to refuse an existing output")
+	}
+}
+
+// journalStart reads where the first record left in a journal was before
+// any trimming, from its header.
+func journalStart(t *testing.T, journalPath string) uint64 {
+	t.Helper()
+	f, err := os.Open(journalPath)
+	if err != nil {
+		t.Fatalf("open journal: %v", err)
+	}
+	defer f.Close()
+	header := make([]byte, 24)
+	if _, err := io.ReadFull(f, header); err != nil {
+		t.Fatalf("read journal header: %v", err)
+	}
+	return binary.BigEndian.Uint64(header[16:])
+}
+
+func TestMDSSnapshotRetentionTrimsJournal(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	boltPath := filepath.Join(dir, "mds.db")
+	journalPath := filepath.Join(dir, "mds.journal")
+	snapDir := filepath.Join(dir, "snapshots")
+	if err := os.Mkdir(snapDir, 0700); err != nil {
+		t.Fatalf("create snapshot dir: %v", err)
+	}
+	early := filepath.Join(dir, "early.db")
+
+	svc := newJournaledMDS(t, boltPath, journalPath)
+	mustCreate(t, svc, "root", "first", false)
+	if _, err := svc.Snapshot(early); err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	start := journalStart(t, journalPath)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		svc.RunSnapshots(ctx, mds.SnapshotConfig{Dir: snapDir, Interval: 20 * time.Millisecond, Keep: 2})
+	}()
+	n := 0
+	taken := map[string]bool{}
+	waitFor(t, "old snapshots to be deleted and the journal trimmed", func() bool {
+		for range 10 {
+			mustCreate(t, svc, "root", fmt.Sprintf("f%d", n), false)
+			n++
+		}
+		snaps, _ := filepath.Glob(filepath.Join(snapDir, "mds-*.db"))
+		for _, snap := range snaps {
+			taken[snap] = true
+		}
+		return len(taken) > 2 && journalStart(t, journalPath) > start
+	})
+	cancel()
+	<-done
+
+	snaps, err := filepath.Glob(filepath.Join(snapDir, "mds-*.db"))
+	if err != nil {
+		t.Fatalf("list snapshots: %v", err)
+	}
+	if len(snaps) != 2 {
+		t.Fatalf("expected 2 snapshots kept, found %v", snaps)
+	}
+	// The journal no longer reaches back to the early snapshot, but it
+	// still does to the snapshots kept.
+	if _, err := mds.Restore(mds.RestoreConfig{SnapshotPath: early, JournalPath: journalPath, OutPath: filepath.Join(dir, "from-early.db")}); err == nil {
+		t.Fatalf("expected restoring from before the trimmed journal to fail")
+	}
+	restoredPath := filepath.Join(dir, "restored.db")
+	if _, err := mds.Restore(mds.RestoreConfig{SnapshotPath: snaps[0], JournalPath: journalPath, OutPath: restoredPath}); err != nil {
+		t.Fatalf("restore from the oldest snapshot kept: %v", err)
+	}
+	restored := newJournaledMDS(t, restoredPath, filepath.Join(dir, "restored.journal"))
+	mustExist(t, restored, "root", "first", true)
+	mustExist(t, restored, "root", fmt.Sprintf("f%d", n-1), true)
+	restored.Close()
+
+	// Appends after the trim land where a restart reads them back.
+	mustCreate(t, svc, "root", "after-trim", false)
+	svc.Close()
+	svc = newJournaledMDS(t, boltPath, journalPath)
+	mustExist(t, svc, "root", "after-trim", true)
+	mustCreate(t, svc, "root", "after-restart", false)
+	svc.Close()
+	svc = newJournaledMDS(t, boltPath, journalPath)
+	defer svc.Close()
+	mustExist(t, svc, "root", "after-restart", true)
+}
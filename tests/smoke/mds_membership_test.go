@@ -0,0 +1,216 @@
+package smoke
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/rachanaanugandula/kube-pfs/pkg/mds"
+	"github.com/rachanaanugandula/kube-pfs/pkg/ost"
+	protogen "github.com/rachanaanugandula/kube-pfs/pkg/proto/gen"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func ostInfo(t *testing.T, svc *mds.Service, ostID string) *protogen.OSTInfo {
+	t.Helper()
+	res, err := svc.ListOSTs(context.Background(), &protogen.ListOSTsRequest{})
+	if err != nil {
+		t.Fatalf("list osts: %v", err)
+	}
+	for _, info := range res.GetOsts() {
+		if info.GetOstId() == ostID {
+			return info
+		}
+	}
+	return nil
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestMDSOSTMembership(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	svc, err := mds.NewService(mds.Config{
+		BoltPath:          filepath.Join(t.TempDir(), "mds.db"),
+		OSTIDs:            []string{"ost-0", "ost-1", "ost-2"},
+		ReplicaCount:      3,
+		HeartbeatInterval: 20 * time.Millisecond,
+		HeartbeatTimeout:  100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("new mds service: %v", err)
+	}
+	t.Cleanup(func() { _ = svc.Close() })
+	mdsConn := serveInProcess(t, func(s *grpc.Server) { protogen.RegisterMetadataServiceServer(s, svc) })
+
+	if _, err := svc.Heartbeat(ctx, &protogen.HeartbeatRequest{OstId: "ost-9"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("heartbeat from unregistered ost: got %v, want NotFound", err)
+	}
+	join := func(id string) (leave func()) {
+		o, err := ost.NewService(id, t.TempDir())
+		if err != nil {
+			t.Fatalf("new %s: %v", id, err)
+		}
+		hbCtx, stop := context.WithCancel(ctx)
+		hb := ost.NewHeartbeater(o, protogen.NewMetadataServiceClient(mdsConn), ost.HeartbeatConfig{Address: id + ":50061"})
+		done := make(chan struct{})
+		go func() {
+			hb.Run(hbCtx)
+			close(done)
+		}()
+		return func() {
+			stop()
+			<-done
+		}
+	}
+
+	// Configured OSTs count as up for one heartbeat timeout after the start,
+	// then only if they registered and keep heartbeating.
+	if !ostInfo(t, svc, "ost-0").GetUp() {
+		t.Fatalf("configured ost-0 is down before it had the time to register")
+	}
+	defer join("ost-1")()
+	defer join("ost-2")()
+	waitFor(t, "ost-0 to be marked down without registering", func() bool { return !ostInfo(t, svc, "ost-0").GetUp() })
+
+	// A new OST joins by registering and stays up while it heartbeats.
+	leave := join("ost-3")
+	waitFor(t, "ost-3 to register", func() bool { return ostInfo(t, svc, "ost-3").GetUp() })
+	info := ostInfo(t, svc, "ost-3")
+	if info.GetAddress() != "ost-3:50061" || info.GetCapacityBytes() == 0 || info.GetLastHeartbeatUnix() == 0 {
+		t.Fatalf("unexpected ost-3 entry: %+v", info)
+	}
+	// Several timeouts' worth of heartbeats keep it up.
+	time.Sleep(300 * time.Millisecond)
+	for _, id := range []string{"ost-1", "ost-2", "ost-3"} {
+		if !ostInfo(t, svc, id).GetUp() {
+			t.Fatalf("%s marked down while heartbeating", id)
+		}
+	}
+	file := mustCreate(t, svc, "root", "after-join", false)
+	if ids := file.GetStripeLayout().GetOstIds(); len(ids) != 3 || !slices.Contains(ids, "ost-3") || slices.Contains(ids, "ost-0") {
+		t.Fatalf("new file layout %v does not use exactly the three heartbeating osts", ids)
+	}
+
+	// Once the heartbeats stop it drops out of new layouts.
+	leave()
+	waitFor(t, "ost-3 to be marked down", func() bool { return !ostInfo(t, svc, "ost-3").GetUp() })
+	_, err = svc.Create(ctx, &protogen.CreateRequest{ParentInodeId: "root", Name: "too-few", Mode: 0644})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("create with 2 of 3 replica OSTs up: got %v, want Unavailable", err)
+	}
+
+	// A configured OST that registers late is up from then on while it
+	// heartbeats.
+	if _, err := svc.RegisterOST(ctx, &protogen.RegisterOSTRequest{OstId: "ost-0", Address: "ost-0:50061"}); err != nil {
+		t.Fatalf("register ost-0: %v", err)
+	}
+	file = mustCreate(t, svc, "root", "after-register", false)
+	if ids := file.GetStripeLayout().GetOstIds(); !slices.Contains(ids, "ost-0") || slices.Contains(ids, "ost-3") {
+		t.Fatalf("new file layout %v does not use the late ost-0 in place of ost-3", ids)
+	}
+	waitFor(t, "ost-0 to be marked down", func() bool { return !ostInfo(t, svc, "ost-0").GetUp() })
+}
+
+func TestMDSPlacementPolicies(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	if _, err := mds.NewPlacementPolicy("fastest"); err == nil {
+		t.Fatalf("expected an error for an unknown placement policy")
+	}
+
+	// Free space: the full OST is always last and the emptiest almost always
+	// first.
+	policy, err := mds.NewPlacementPolicy(mds.PlacementFreeSpace)
+	if err != nil {
+		t.Fatalf("free-space policy: %v", err)
+	}
+	svc, err := mds.NewService(mds.Config{
+		BoltPath:          filepath.Join(t.TempDir(), "mds.db"),
+		OSTIDs:            []string{"ost-0", "ost-1", "ost-2"},
+		HeartbeatInterval: time.Minute,
+		Placement:         policy,
+	})
+	if err != nil {
+		t.Fatalf("new mds service: %v", err)
+	}
+	t.Cleanup(func() { _ = svc.Close() })
+	for id, free := range map[string]uint64{"ost-0": 1 << 40, "ost-1": 0, "ost-2": 1 << 20} {
+		if _, err := svc.RegisterOST(ctx, &protogen.RegisterOSTRequest{OstId: id, CapacityBytes: 1 << 40, FreeBytes: free}); err != nil {
+			t.Fatalf("register %s: %v", id, err)
+		}
+	}
+	first := map[string]int{}
+	for i := 0; i < 50; i++ {
+		ids := mustCreate(t, svc, "root", fmt.Sprintf("f%d", i), false).GetStripeLayout().GetOstIds()
+		if len(ids) != 3 || ids[2] != "ost-1" {
+			t.Fatalf("layout %v does not put the full ost-1 last", ids)
+		}
+		first[ids[0]]++
+	}
+	if first["ost-0"] < 45 {
+		t.Fatalf("ost-0 with the most free space led only %d of 50 layouts", first["ost-0"])
+	}
+
+	// Latency: fastest first, ties take turns.
+	latency := &mds.LatencyPlacement{}
+	osts := []*protogen.OSTInfo{
+		{OstId: "ost-0", RecentLatencyUs: 900},
+		{OstId: "ost-1", RecentLatencyUs: 100},
+		{OstId: "ost-2", RecentLatencyUs: 100},
+	}
+	a, b := latency.Order(osts), latency.Order(osts)
+	if a[2] != "ost-0" || b[2] != "ost-0" || a[0] == b[0] {
+		t.Fatalf("latency orders %v then %v, want ost-0 last and the tied OSTs alternating", a, b)
+	}
+}
+
+func TestMDSHealthPollerMeasuresLatency(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	svc := newTestMDS(t, filepath.Join(t.TempDir(), "mds.db"))
+	t.Cleanup(func() { _ = svc.Close() })
+	ostSvc := newTestOST(t, t.TempDir())
+	ostConn := serveInProcess(t, func(s *grpc.Server) { protogen.RegisterObjectStorageServiceServer(s, ostSvc) })
+	ostClient := protogen.NewObjectStorageServiceClient(ostConn)
+	if _, err := svc.RegisterOST(ctx, &protogen.RegisterOSTRequest{OstId: "ost-0", Address: "bufconn-ost-0"}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	dial := func(address string) (protogen.ObjectStorageServiceClient, error) {
+		if address != "bufconn-ost-0" {
+			return nil, fmt.Errorf("unexpected address %q", address)
+		}
+		return ostClient, nil
+	}
+	poller := mds.NewHealthPoller(svc, dial, mds.HealthPollConfig{})
+
+	if err := poller.PollOnce(ctx); err != nil {
+		t.Fatalf("first poll: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		ref := &protogen.BlockRef{FileId: "f", ChunkId: uint64(i), OstId: "ost-0"}
+		if _, err := ostClient.WriteBlock(ctx, &protogen.WriteBlockRequest{Block: ref, Data: randomPayload(int64(i), 64*1024)}); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	if err := poller.PollOnce(ctx); err != nil {
+		t.Fatalf("second poll: %v", err)
+	}
+	if got := ostInfo(t, svc, "ost-0").GetRecentLatencyUs(); got == 0 {
+		t.Fatalf("expected a recent latency after I/O between polls")
+	}
+}
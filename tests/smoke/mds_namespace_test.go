@@ -0,0 +1,513 @@
+package smoke
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rachanaanugandula/kube-pfs/pkg/mds"
+	protogen "github.com/rachanaanugandula/kube-pfs/pkg/proto/gen"
+	"go.etcd.io/bbolt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func newTestMDS(t *testing.T, boltPath string) *mds.Service {
+	t.Helper()
+	svc, err := mds.NewService(mds.Config{
+		BoltPath:        boltPath,
+		OSTIDs:          []string{"ost-0", "ost-1", "ost-2"},
+		DefaultMode:     0644,
+		DefaultStripeSz: 1024 * 1024,
+	})
+	if err != nil {
+		t.Fatalf("new mds service: %v", err)
+	}
+	return svc
+}
+
+func mustCreate(t *testing.T, svc *mds.Service, parentID, name string, isDir bool) *protogen.Inode {
+	t.Helper()
+	res, err := svc.Create(context.Background(), &protogen.CreateRequest{ParentInodeId: parentID, Name: name, IsDir: isDir, Mode: 0644})
+	if err != nil {
+		t.Fatalf("create %s: %v", name, err)
+	}
+	return res.GetInode()
+}
+
+func TestMDSRenameSemantics(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	boltPath := filepath.Join(t.TempDir(), "mds.db")
+	svc := newTestMDS(t, boltPath)
+
+	dirA := mustCreate(t, svc, "root", "a", true)
+	dirB := mustCreate(t, svc, dirA.GetInodeId(), "b", true)
+	tmp := mustCreate(t, svc, dirA.GetInodeId(), "ckpt.tmp", false)
+	old := mustCreate(t, svc, "root", "ckpt", false)
+
+	// Write-to-temp then rename-into-place across directories.
+	res, err := svc.Rename(ctx, &protogen.RenameRequest{
+		SrcParentInodeId: dirA.GetInodeId(), SrcName: "ckpt.tmp",
+		DstParentInodeId: "root", DstName: "ckpt",
+	})
+	if err != nil {
+		t.Fatalf("rename replace: %v", err)
+	}
+	if res.GetInode().GetParentInodeId() != "root" || res.GetInode().GetName() != "ckpt" {
+		t.Fatalf("moved inode not updated: %+v", res.GetInode())
+	}
+	if res.GetTarget().GetInodeId() != old.GetInodeId() {
+		t.Fatalf("expected replaced target %s, got %s", old.GetInodeId(), res.GetTarget().GetInodeId())
+	}
+	if _, err := svc.Stat(ctx, &protogen.StatRequest{InodeId: old.GetInodeId()}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected replaced inode to be gone, got %v", err)
+	}
+
+	other := mustCreate(t, svc, "root", "other", false)
+	_, err = svc.Rename(ctx, &protogen.RenameRequest{
+		SrcParentInodeId: "root", SrcName: "ckpt",
+		DstParentInodeId: "root", DstName: "other",
+		Flags: protogen.RenameFlags_RENAME_NOREPLACE,
+	})
+	if status.Code(err) != codes.AlreadyExists {
+		t.Fatalf("expected AlreadyExists for noreplace, got %v", err)
+	}
+
+	_, err = svc.Rename(ctx, &protogen.RenameRequest{
+		SrcParentInodeId: "root", SrcName: "ckpt",
+		DstParentInodeId: "root", DstName: "other",
+		Flags: protogen.RenameFlags_RENAME_EXCHANGE,
+	})
+	if err != nil {
+		t.Fatalf("rename exchange: %v", err)
+	}
+
+	_, err = svc.Rename(ctx, &protogen.RenameRequest{
+		SrcParentInodeId: "root", SrcName: "a",
+		DstParentInodeId: dirB.GetInodeId(), DstName: "loop",
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument for move into own subtree, got %v", err)
+	}
+
+	// The rename must survive a restart, which proves bolt was updated too.
+	if err := svc.Close(); err != nil {
+		t.Fatalf("close mds: %v", err)
+	}
+	svc = newTestMDS(t, boltPath)
+	t.Cleanup(func() { _ = svc.Close() })
+
+	lookup, err := svc.Lookup(ctx, &protogen.LookupRequest{ParentInodeId: "root", Name: "ckpt"})
+	if err != nil {
+		t.Fatalf("lookup after reopen: %v", err)
+	}
+	if lookup.GetInode().GetInodeId() != other.GetInodeId() {
+		t.Fatalf("exchange not persisted: ckpt -> %s, want %s", lookup.GetInode().GetInodeId(), other.GetInodeId())
+	}
+	lookup, err = svc.Lookup(ctx, &protogen.LookupRequest{ParentInodeId: "root", Name: "other"})
+	if err != nil {
+		t.Fatalf("lookup other after reopen: %v", err)
+	}
+	if lookup.GetInode().GetInodeId() != tmp.GetInodeId() {
+		t.Fatalf("exchange not persisted: other -> %s, want %s", lookup.GetInode().GetInodeId(), tmp.GetInodeId())
+	}
+	if _, err := svc.Lookup(ctx, &protogen.LookupRequest{ParentInodeId: dirA.GetInodeId(), Name: "ckpt.tmp"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected source name to be gone after reopen, got %v", err)
+	}
+}
+
+func TestMDSSetAttrTruncate(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	boltPath := filepath.Join(t.TempDir(), "mds.db")
+	svc := newTestMDS(t, boltPath)
+	t.Cleanup(func() { _ = svc.Close() })
+
+	file := mustCreate(t, svc, "root", "data.bin", false)
+	const mib = 1024 * 1024
+
+	res, err := svc.SetAttr(ctx, &protogen.SetAttrRequest{
+		InodeId:   file.GetInodeId(),
+		Mask:      uint32(protogen.SetAttrMask_SETATTR_SIZE | protogen.SetAttrMask_SETATTR_MODE | protogen.SetAttrMask_SETATTR_UID),
+		SizeBytes: 5*mib + 10,
+		Mode:      0600,
+		Uid:       1000,
+	})
+	if err != nil {
+		t.Fatalf("grow file: %v", err)
+	}
+	if res.GetInode().GetSizeBytes() != 5*mib+10 || res.GetInode().GetMode() != 0600 || res.GetInode().GetUid() != 1000 {
+		t.Fatalf("attributes not applied: %+v", res.GetInode())
+	}
+	if len(res.GetTruncatedBlocks()) != 0 {
+		t.Fatalf("growing a file should not truncate blocks, got %d", len(res.GetTruncatedBlocks()))
+	}
+
+	res, err = svc.SetAttr(ctx, &protogen.SetAttrRequest{
+		InodeId:   file.GetInodeId(),
+		Mask:      uint32(protogen.SetAttrMask_SETATTR_SIZE),
+		SizeBytes: mib + 1,
+	})
+	if err != nil {
+		t.Fatalf("shrink file: %v", err)
+	}
+	// Chunks 0 and 1 still hold data; chunks 2..5 are past the new EOF.
+	blocks := res.GetTruncatedBlocks()
+	if len(blocks) != 4 || blocks[0].GetChunkId() != 2 || blocks[3].GetChunkId() != 5 {
+		t.Fatalf("unexpected truncated blocks: %v", blocks)
+	}
+	ostIDs := file.GetStripeLayout().GetOstIds()
+	if blocks[0].GetOstId() != ostIDs[2%len(ostIDs)] {
+		t.Fatalf("chunk 2 mapped to %s, want %s", blocks[0].GetOstId(), ostIDs[2%len(ostIDs)])
+	}
+
+	stat, err := svc.Stat(ctx, &protogen.StatRequest{InodeId: file.GetInodeId()})
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if stat.GetInode().GetSizeBytes() != mib+1 || stat.GetInode().GetMode() != 0600 {
+		t.Fatalf("stat does not reflect setattr: %+v", stat.GetInode())
+	}
+
+	if _, err := svc.SetAttr(ctx, &protogen.SetAttrRequest{InodeId: "root", Mask: uint32(protogen.SetAttrMask_SETATTR_SIZE)}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument when sizing a directory, got %v", err)
+	}
+}
+
+func TestMDSInodeNumbering(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	boltPath := filepath.Join(t.TempDir(), "mds.db")
+	svc := newTestMDS(t, boltPath)
+
+	stat, err := svc.Stat(ctx, &protogen.StatRequest{InodeId: "root"})
+	if err != nil || stat.GetInode().GetIno() != 1 {
+		t.Fatalf("root stat %+v, err %v", stat.GetInode(), err)
+	}
+	a := mustCreate(t, svc, "root", "a", false)
+	b := mustCreate(t, svc, "root", "b", true)
+	if a.GetIno() != 2 || a.GetInodeId() != "inode-2" || b.GetIno() != 3 || b.GetInodeId() != "inode-3" {
+		t.Fatalf("first inodes numbered %s/%d and %s/%d", a.GetInodeId(), a.GetIno(), b.GetInodeId(), b.GetIno())
+	}
+	if a.GetGeneration() == 0 || a.GetGeneration() != b.GetGeneration() {
+		t.Fatalf("generations %d and %d, want the same non-zero value", a.GetGeneration(), b.GetGeneration())
+	}
+	if _, err := svc.Stat(ctx, &protogen.StatRequest{InodeId: a.GetInodeId(), Generation: a.GetGeneration()}); err != nil {
+		t.Fatalf("stat with matching generation: %v", err)
+	}
+	if _, err := svc.Stat(ctx, &protogen.StatRequest{InodeId: a.GetInodeId(), Generation: a.GetGeneration() + 1}); status.Code(err) != codes.NotFound {
+		t.Fatalf("stat with stale generation: got %v, want NotFound", err)
+	}
+
+	// Unlinked numbers are not handed out again, and a restart continues the
+	// counter in a new generation.
+	if _, err := svc.Unlink(ctx, &protogen.UnlinkRequest{ParentInodeId: "root", Name: "a"}); err != nil {
+		t.Fatalf("unlink: %v", err)
+	}
+	if err := svc.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	svc = newTestMDS(t, boltPath)
+	c := mustCreate(t, svc, "root", "c", false)
+	if c.GetIno() != 4 || c.GetGeneration() <= a.GetGeneration() {
+		t.Fatalf("after restart got ino %d generation %d, want 4 and above %d", c.GetIno(), c.GetGeneration(), a.GetGeneration())
+	}
+	if err := svc.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	// Inodes stored before numbering existed are numbered from their
+	// inode-<nanos> IDs, and new inodes are numbered past them. Databases
+	// from then have no meta bucket, which is what triggers the migration.
+	const legacyID = "inode-1700000000123456789"
+	db, err := bbolt.Open(boltPath, 0600, nil)
+	if err != nil {
+		t.Fatalf("open bolt: %v", err)
+	}
+	err = db.Update(func(tx *bbolt.Tx) error {
+		blob, err := proto.Marshal(&protogen.Inode{InodeId: legacyID, ParentInodeId: "root", Name: "legacy", Mode: 0644})
+		if err != nil {
+			return err
+		}
+		if err := tx.Bucket([]byte("inodes")).Put([]byte(legacyID), blob); err != nil {
+			return err
+		}
+		if err := tx.Bucket([]byte("dirents")).Put([]byte("root\x00legacy"), []byte(legacyID)); err != nil {
+			return err
+		}
+		return tx.DeleteBucket([]byte("meta"))
+	})
+	if err != nil {
+		t.Fatalf("write legacy inode: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("close bolt: %v", err)
+	}
+	svc = newTestMDS(t, boltPath)
+	t.Cleanup(func() { _ = svc.Close() })
+	legacy, err := svc.Lookup(ctx, &protogen.LookupRequest{ParentInodeId: "root", Name: "legacy"})
+	if err != nil {
+		t.Fatalf("lookup legacy: %v", err)
+	}
+	if legacy.GetInode().GetIno() != 1700000000123456789 || legacy.GetInode().GetGeneration() == 0 {
+		t.Fatalf("legacy inode migrated as %+v", legacy.GetInode())
+	}
+	if d := mustCreate(t, svc, "root", "d", false); d.GetIno() != 1700000000123456790 {
+		t.Fatalf("inode after migration numbered %d", d.GetIno())
+	}
+}
+
+func TestMDSBoundedCache(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	boltPath := filepath.Join(t.TempDir(), "mds.db")
+	open := func() *mds.Service {
+		svc, err := mds.NewService(mds.Config{
+			BoltPath:        boltPath,
+			OSTIDs:          []string{"ost-0", "ost-1", "ost-2"},
+			DefaultMode:     0644,
+			DefaultStripeSz: 1024 * 1024,
+			CacheEntries:    4,
+		})
+		if err != nil {
+			t.Fatalf("new mds service: %v", err)
+		}
+		return svc
+	}
+
+	// Far more entries than the cache holds, so most requests go to bolt.
+	svc := open()
+	dir := mustCreate(t, svc, "root", "dir", true)
+	var files []*protogen.Inode
+	for _, name := range []string{"e", "a", "d", "c", "b", "g", "f", "h"} {
+		files = append(files, mustCreate(t, svc, dir.GetInodeId(), name, false))
+	}
+	if _, err := svc.Create(ctx, &protogen.CreateRequest{ParentInodeId: dir.GetInodeId(), Name: "a"}); status.Code(err) != codes.AlreadyExists {
+		t.Fatalf("expected AlreadyExists for evicted entry, got %v", err)
+	}
+	if _, err := svc.Rename(ctx, &protogen.RenameRequest{
+		SrcParentInodeId: dir.GetInodeId(), SrcName: "e",
+		DstParentInodeId: "root", DstName: "moved",
+	}); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+	if _, err := svc.Unlink(ctx, &protogen.UnlinkRequest{ParentInodeId: dir.GetInodeId(), Name: "h"}); err != nil {
+		t.Fatalf("unlink: %v", err)
+	}
+	if _, err := svc.Unlink(ctx, &protogen.UnlinkRequest{ParentInodeId: "root", Name: "dir"}); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected FailedPrecondition unlinking non-empty dir, got %v", err)
+	}
+	svc.Close()
+
+	// A restart loads nothing, and everything is still served from bolt.
+	svc = open()
+	defer svc.Close()
+	list, err := svc.ListDir(ctx, &protogen.ListDirRequest{InodeId: dir.GetInodeId()})
+	if err != nil {
+		t.Fatalf("listdir: %v", err)
+	}
+	var names []string
+	for _, e := range list.GetEntries() {
+		names = append(names, e.GetName())
+	}
+	if got, want := strings.Join(names, ","), "a,b,c,d,f,g"; got != want {
+		t.Fatalf("listdir after restart: got %s, want %s", got, want)
+	}
+	res, err := svc.Lookup(ctx, &protogen.LookupRequest{ParentInodeId: "root", Name: "moved"})
+	if err != nil {
+		t.Fatalf("lookup moved: %v", err)
+	}
+	if res.GetInode().GetInodeId() != files[0].GetInodeId() {
+		t.Fatalf("lookup moved returned %s, want %s", res.GetInode().GetInodeId(), files[0].GetInodeId())
+	}
+	for _, f := range files[1:7] {
+		if _, err := svc.Stat(ctx, &protogen.StatRequest{InodeId: f.GetInodeId()}); err != nil {
+			t.Fatalf("stat %s: %v", f.GetName(), err)
+		}
+	}
+	if _, err := svc.Stat(ctx, &protogen.StatRequest{InodeId: files[7].GetInodeId()}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected unlinked inode to be gone, got %v", err)
+	}
+}
+
+func TestMDSConcurrentNamespaceOps(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	svc, err := mds.NewService(mds.Config{
+		BoltPath:   filepath.Join(t.TempDir(), "mds.db"),
+		OSTIDs:     []string{"ost-0", "ost-1", "ost-2"},
+		LockShards: 4,
+	})
+	if err != nil {
+		t.Fatalf("new mds service: %v", err)
+	}
+	defer svc.Close()
+
+	const dirs, filesPerDir = 8, 20
+	var dirIDs []string
+	for i := 0; i < dirs; i++ {
+		dirIDs = append(dirIDs, mustCreate(t, svc, "root", fmt.Sprintf("d%d", i), true).GetInodeId())
+	}
+
+	// Every worker creates files in its own directory and renames each one
+	// into the next worker's, so renames cross shards in both orders.
+	var wg sync.WaitGroup
+	errs := make(chan error, dirs)
+	for i := 0; i < dirs; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < filesPerDir; j++ {
+				name := fmt.Sprintf("f%d-%d", i, j)
+				if _, err := svc.Create(ctx, &protogen.CreateRequest{ParentInodeId: dirIDs[i], Name: name}); err != nil {
+					errs <- fmt.Errorf("create %s: %w", name, err)
+					return
+				}
+				if _, err := svc.Rename(ctx, &protogen.RenameRequest{
+					SrcParentInodeId: dirIDs[i], SrcName: name,
+					DstParentInodeId: dirIDs[(i+1)%dirs], DstName: name,
+				}); err != nil {
+					errs <- fmt.Errorf("rename %s: %w", name, err)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	inos := map[uint64]bool{}
+	for i, dirID := range dirIDs {
+		list, err := svc.ListDir(ctx, &protogen.ListDirRequest{InodeId: dirID})
+		if err != nil {
+			t.Fatalf("listdir d%d: %v", i, err)
+		}
+		if len(list.GetEntries()) != filesPerDir {
+			t.Fatalf("d%d has %d entries, want %d", i, len(list.GetEntries()), filesPerDir)
+		}
+		for _, e := range list.GetEntries() {
+			if e.GetParentInodeId() != dirID {
+				t.Fatalf("%s lists parent %s, want %s", e.GetName(), e.GetParentInodeId(), dirID)
+			}
+			if inos[e.GetIno()] {
+				t.Fatalf("inode number %d handed out twice", e.GetIno())
+			}
+			inos[e.GetIno()] = true
+		}
+	}
+}
+
+func TestMDSGroupCommit(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	boltPath := filepath.Join(t.TempDir(), "mds.db")
+	const window = 50 * time.Millisecond
+	svc, err := mds.NewService(mds.Config{
+		BoltPath:       boltPath,
+		OSTIDs:         []string{"ost-0", "ost-1", "ost-2"},
+		CommitMaxBatch: 64,
+		CommitMaxDelay: window,
+	})
+	if err != nil {
+		t.Fatalf("new mds service: %v", err)
+	}
+
+	// Handlers hold their directory's lock until the commit is durable, so
+	// only creates in different directories can share a commit. Committed
+	// one by one, these would take a window each.
+	const creates = 32
+	var dirIDs []string
+	for i := 0; i < creates; i++ {
+		dirIDs = append(dirIDs, mustCreate(t, svc, "root", fmt.Sprintf("d%d", i), true).GetInodeId())
+	}
+	start := time.Now()
+	var wg sync.WaitGroup
+	errs := make(chan error, creates)
+	for i := 0; i < creates; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("f%d", i)
+			if _, err := svc.Create(ctx, &protogen.CreateRequest{ParentInodeId: dirIDs[i], Name: name}); err != nil {
+				errs <- fmt.Errorf("create %s: %w", name, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > creates*window/4 {
+		t.Fatalf("%d concurrent creates took %v, commits were not grouped", creates, elapsed)
+	}
+
+	svc.Close()
+
+	svc = newTestMDS(t, boltPath)
+	defer svc.Close()
+	for i, dirID := range dirIDs {
+		if _, err := svc.Lookup(ctx, &protogen.LookupRequest{ParentInodeId: dirID, Name: fmt.Sprintf("f%d", i)}); err != nil {
+			t.Fatalf("lookup f%d after restart: %v", i, err)
+		}
+	}
+}
+
+func TestMDSGroupCommitSingleDirectory(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	const window = time.Second
+	svc, err := mds.NewService(mds.Config{
+		BoltPath:       filepath.Join(t.TempDir(), "mds.db"),
+		OSTIDs:         []string{"ost-0", "ost-1", "ost-2"},
+		CommitMaxBatch: 64,
+		CommitMaxDelay: window,
+	})
+	if err != nil {
+		t.Fatalf("new mds service: %v", err)
+	}
+	defer svc.Close()
+
+	// Creates in one directory commit one after another under its lock, so
+	// each has no other write to share its commit with and must not wait
+	// out the window for one.
+	dirID := mustCreate(t, svc, "root", "d", true).GetInodeId()
+	const creates = 16
+	start := time.Now()
+	var wg sync.WaitGroup
+	errs := make(chan error, creates)
+	for i := 0; i < creates; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("f%d", i)
+			if _, err := svc.Create(ctx, &protogen.CreateRequest{ParentInodeId: dirID, Name: name}); err != nil {
+				errs <- fmt.Errorf("create %s: %w", name, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > window {
+		t.Fatalf("%d creates in one directory took %v, commits waited for writes that were not coming", creates, elapsed)
+	}
+	res, err := svc.ListDir(ctx, &protogen.ListDirRequest{InodeId: dirID})
+	if err != nil {
+		t.Fatalf("list dir: %v", err)
+	}
+	if len(res.GetEntries()) != creates {
+		t.Fatalf("directory has %d entries, want %d", len(res.GetEntries()), creates)
+	}
+}
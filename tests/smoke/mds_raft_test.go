@@ -0,0 +1,617 @@
+package smoke
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rachanaanugandula/kube-pfs/pkg/mds"
+	protogen "github.com/rachanaanugandula/kube-pfs/pkg/proto/gen"
+	"github.com/rachanaanugandula/kube-pfs/pkg/raft"
+	"go.etcd.io/bbolt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+type raftMember struct {
+	id          string
+	addr        string
+	boltPath    string
+	journalPath string
+	peers       []raft.Peer
+	svc         *mds.Service
+	srv         *grpc.Server
+	stopped     bool
+}
+
+func (m *raftMember) stop() {
+	if m.stopped {
+		return
+	}
+	m.stopped = true
+	m.srv.Stop()
+	_ = m.svc.Close()
+}
+
+// start runs the member's MDS on lis. The raft log is compacted often, so
+// the tests run over a compacted log.
+func (m *raftMember) start(t *testing.T, lis net.Listener) {
+	t.Helper()
+	svc, err := mds.NewService(mds.Config{
+		BoltPath:    m.boltPath,
+		OSTIDs:      []string{"ost-0", "ost-1", "ost-2"},
+		JournalPath: m.journalPath,
+		Replication: &mds.ReplicationConfig{
+			NodeID:            m.id,
+			Peers:             m.peers,
+			ElectionTimeout:   300 * time.Millisecond,
+			HeartbeatInterval: 30 * time.Millisecond,
+			CompactEvery:      16,
+		},
+	})
+	if err != nil {
+		lis.Close()
+		t.Fatalf("new %s: %v", m.id, err)
+	}
+	srv := grpc.NewServer()
+	protogen.RegisterMetadataServiceServer(srv, svc)
+	protogen.RegisterRaftServiceServer(srv, svc.Raft())
+	m.svc, m.srv, m.stopped = svc, srv, false
+	go func() { _ = srv.Serve(lis) }()
+}
+
+// restart starts a stopped member again on its address.
+func (m *raftMember) restart(t *testing.T) {
+	t.Helper()
+	lis, err := net.Listen("tcp", m.addr)
+	if err != nil {
+		t.Fatalf("listen again on %s: %v", m.addr, err)
+	}
+	m.start(t, lis)
+}
+
+// startReplicatedMDS runs a raft group of MDS services in this process, each
+// serving MetadataService and RaftService on a loopback port.
+func startReplicatedMDS(t *testing.T, size int) []*raftMember {
+	t.Helper()
+	dir := t.TempDir()
+	members := make([]*raftMember, size)
+	listeners := make([]net.Listener, size)
+	var peers []raft.Peer
+	for i := range members {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("listen: %v", err)
+		}
+		listeners[i] = lis
+		id := fmt.Sprintf("mds-%d", i)
+		members[i] = &raftMember{
+			id:          id,
+			addr:        lis.Addr().String(),
+			boltPath:    filepath.Join(dir, id+".db"),
+			journalPath: filepath.Join(dir, id+".journal"),
+		}
+		peers = append(peers, raft.Peer{ID: members[i].id, Address: members[i].addr})
+	}
+	for i, m := range members {
+		m.peers = peers
+		m.start(t, listeners[i])
+		t.Cleanup(m.stop)
+	}
+	return members
+}
+
+// raftLink forwards the raft traffic of one member to another over TCP,
+// unless it is cut.
+type raftLink struct {
+	lis    net.Listener
+	target string
+
+	mu    sync.Mutex
+	cut   bool
+	conns []net.Conn
+}
+
+func (l *raftLink) serve() {
+	for {
+		c, err := l.lis.Accept()
+		if err != nil {
+			return
+		}
+		go l.forward(c)
+	}
+}
+
+func (l *raftLink) forward(c net.Conn) {
+	d, err := net.Dial("tcp", l.target)
+	if err != nil {
+		c.Close()
+		return
+	}
+	l.mu.Lock()
+	if l.cut {
+		l.mu.Unlock()
+		c.Close()
+		d.Close()
+		return
+	}
+	l.conns = append(l.conns, c, d)
+	l.mu.Unlock()
+	pipe := func(dst, src net.Conn) {
+		_, _ = io.Copy(dst, src)
+		dst.Close()
+		src.Close()
+	}
+	go pipe(d, c)
+	go pipe(c, d)
+}
+
+// setCut drops the connections over the link and refuses new ones, or lets
+// them through again.
+func (l *raftLink) setCut(cut bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cut = cut
+	if cut {
+		for _, c := range l.conns {
+			c.Close()
+		}
+		l.conns = nil
+	}
+}
+
+// raftNet holds the links between the members of a partitionable group,
+// keyed by the IDs of the member sending and the member listening.
+type raftNet struct {
+	links map[[2]string]*raftLink
+}
+
+// isolate cuts every link to and from the member, or heals them.
+func (n *raftNet) isolate(id string, cut bool) {
+	for ends, l := range n.links {
+		if ends[0] == id || ends[1] == id {
+			l.setCut(cut)
+		}
+	}
+}
+
+// startPartitionableMDS is startReplicatedMDS with the members reaching each
+// other through links the test can cut.
+func startPartitionableMDS(t *testing.T, size int) ([]*raftMember, *raftNet) {
+	t.Helper()
+	dir := t.TempDir()
+	members := make([]*raftMember, size)
+	listeners := make([]net.Listener, size)
+	for i := range members {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("listen: %v", err)
+		}
+		listeners[i] = lis
+		id := fmt.Sprintf("mds-%d", i)
+		members[i] = &raftMember{
+			id:          id,
+			addr:        lis.Addr().String(),
+			boltPath:    filepath.Join(dir, id+".db"),
+			journalPath: filepath.Join(dir, id+".journal"),
+		}
+	}
+	rn := &raftNet{links: map[[2]string]*raftLink{}}
+	for _, from := range members {
+		for _, to := range members {
+			if from == to {
+				from.peers = append(from.peers, raft.Peer{ID: from.id, Address: from.addr})
+				continue
+			}
+			lis, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("listen: %v", err)
+			}
+			l := &raftLink{lis: lis, target: to.addr}
+			t.Cleanup(func() {
+				lis.Close()
+				l.setCut(true)
+			})
+			go l.serve()
+			rn.links[[2]string{from.id, to.id}] = l
+			from.peers = append(from.peers, raft.Peer{ID: to.id, Address: lis.Addr().String()})
+		}
+	}
+	for i, m := range members {
+		m.start(t, listeners[i])
+		t.Cleanup(m.stop)
+	}
+	return members, rn
+}
+
+// others returns the members other than m.
+func others(members []*raftMember, m *raftMember) []*raftMember {
+	var out []*raftMember
+	for _, o := range members {
+		if o != m {
+			out = append(out, o)
+		}
+	}
+	return out
+}
+
+// waitForLeader returns the live member that leads and serves the root.
+func waitForLeader(t *testing.T, members []*raftMember) *raftMember {
+	t.Helper()
+	var found *raftMember
+	waitFor(t, "a raft leader", func() bool {
+		for _, m := range members {
+			if m.stopped || !m.svc.Raft().IsLeader() {
+				continue
+			}
+			if _, err := m.svc.Stat(context.Background(), &protogen.StatRequest{InodeId: "root"}); err == nil {
+				found = m
+				return true
+			}
+		}
+		return false
+	})
+	return found
+}
+
+func TestMDSRaftFailover(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	members := startReplicatedMDS(t, 3)
+	leader := waitForLeader(t, members)
+
+	dir := mustCreate(t, leader.svc, "root", "dir", true)
+	file := mustCreate(t, leader.svc, dir.GetInodeId(), "file", false)
+
+	// Followers turn metadata RPCs away and say who leads.
+	var follower *raftMember
+	for _, m := range members {
+		if m != leader {
+			follower = m
+			break
+		}
+	}
+	waitFor(t, follower.id+" to learn the leader", func() bool {
+		p, ok := follower.svc.Raft().Leader()
+		return ok && p.ID == leader.id
+	})
+	conn, err := grpc.NewClient(follower.addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial follower: %v", err)
+	}
+	defer conn.Close()
+	client := protogen.NewMetadataServiceClient(conn)
+	for name, call := range map[string]func() error{
+		"create": func() error {
+			_, err := client.Create(ctx, &protogen.CreateRequest{ParentInodeId: "root", Name: "nope"})
+			return err
+		},
+		"lookup": func() error {
+			_, err := client.Lookup(ctx, &protogen.LookupRequest{ParentInodeId: "root", Name: "dir"})
+			return err
+		},
+	} {
+		err := call()
+		if status.Code(err) != codes.Unavailable {
+			t.Fatalf("%s on a follower: expected UNAVAILABLE, got %v", name, err)
+		}
+		var hint *protogen.LeaderHint
+		for _, d := range status.Convert(err).Details() {
+			if h, ok := d.(*protogen.LeaderHint); ok {
+				hint = h
+			}
+		}
+		if hint.GetLeaderId() != leader.id || hint.GetLeaderAddress() != leader.addr {
+			t.Fatalf("%s on a follower hinted %v, want %s at %s", name, hint, leader.id, leader.addr)
+		}
+	}
+
+	leader.stop()
+	next := waitForLeader(t, members)
+	if next == leader {
+		t.Fatalf("stopped leader still leads")
+	}
+
+	// Everything the old leader acknowledged survives, and the new one
+	// carries on numbering inodes past it.
+	mustExist(t, next.svc, "root", "dir", true)
+	mustExist(t, next.svc, dir.GetInodeId(), "file", true)
+	later := mustCreate(t, next.svc, dir.GetInodeId(), "later", false)
+	if later.GetIno() <= file.GetIno() {
+		t.Fatalf("new leader numbered an inode %d, not past %d", later.GetIno(), file.GetIno())
+	}
+	if _, err := next.svc.Unlink(ctx, &protogen.UnlinkRequest{ParentInodeId: dir.GetInodeId(), Name: "file"}); err != nil {
+		t.Fatalf("unlink on new leader: %v", err)
+	}
+	mustExist(t, next.svc, dir.GetInodeId(), "file", false)
+
+	// The remaining follower applied the same changes to its own database.
+	for _, m := range members {
+		if m == next || m.stopped {
+			continue
+		}
+		waitFor(t, m.id+" to catch up", func() bool { return m.svc.Raft().Applied() >= next.svc.Raft().Applied() })
+		m.stop()
+		standalone := newJournaledMDS(t, m.boltPath, m.journalPath)
+		mustExist(t, standalone, dir.GetInodeId(), "later", true)
+		mustExist(t, standalone, dir.GetInodeId(), "file", false)
+		standalone.Close()
+	}
+}
+
+func TestMDSRaftCompactsLog(t *testing.T) {
+	t.Parallel()
+	members := startReplicatedMDS(t, 3)
+	leader := waitForLeader(t, members)
+	var follower *raftMember
+	for _, m := range members {
+		if m != leader {
+			follower = m
+			break
+		}
+	}
+
+	for i := range 40 {
+		mustCreate(t, leader.svc, "root", fmt.Sprintf("a%d", i), false)
+	}
+	for _, m := range members {
+		waitFor(t, m.id+" to compact its log", func() bool { return m.svc.Raft().Compacted() > 0 })
+	}
+
+	// A member that is down may need any entry after what it stores, so
+	// the others stop compacting there.
+	follower.stop()
+	for i := range 40 {
+		mustCreate(t, leader.svc, "root", fmt.Sprintf("b%d", i), false)
+	}
+	held := leader.svc.Raft().Compacted()
+	for i := range 40 {
+		mustCreate(t, leader.svc, "root", fmt.Sprintf("c%d", i), false)
+	}
+	if got := leader.svc.Raft().Compacted(); got != held {
+		t.Fatalf("leader compacted its log from %d to %d while %s was down", held, got, follower.id)
+	}
+
+	// Back up, it catches up from the leader's log, and then compaction
+	// moves on. The member may force an election when it comes back, so
+	// find the leader again.
+	follower.restart(t)
+	leader = waitForLeader(t, members)
+	for i := range 20 {
+		mustCreate(t, leader.svc, "root", fmt.Sprintf("d%d", i), false)
+	}
+	waitFor(t, "compaction past "+follower.id+"'s downtime", func() bool {
+		for _, m := range members {
+			if m.svc.Raft().Compacted() <= held {
+				return false
+			}
+		}
+		return true
+	})
+
+	// A restart over a compacted log picks up where it left off.
+	follower.stop()
+	follower.restart(t)
+	leader = waitForLeader(t, members)
+	mustCreate(t, leader.svc, "root", "e", false)
+	waitFor(t, follower.id+" to catch up after restarting", func() bool {
+		return follower.svc.Raft().Applied() >= leader.svc.Raft().Applied()
+	})
+	follower.stop()
+	standalone := newJournaledMDS(t, follower.boltPath, follower.journalPath)
+	defer standalone.Close()
+	for _, name := range []string{"a0", "b39", "c0", "d19", "e"} {
+		mustExist(t, standalone, "root", name, true)
+	}
+}
+
+func TestMDSRaftDivergentLeaderRejoins(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	members, rn := startPartitionableMDS(t, 3)
+	old := waitForLeader(t, members)
+	mustCreate(t, old.svc, "root", "before", false)
+
+	// Cut off, the leader still appends a write to its own log, but it can
+	// never commit there.
+	rn.isolate(old.id, true)
+	lost := make(chan error, 1)
+	go func() {
+		_, err := old.svc.Create(ctx, &protogen.CreateRequest{ParentInodeId: "root", Name: "lost"})
+		lost <- err
+	}()
+	next := waitForLeader(t, others(members, old))
+	for i := range 5 {
+		mustCreate(t, next.svc, "root", fmt.Sprintf("after%d", i), false)
+	}
+	old.stop()
+	if err := <-lost; err == nil {
+		t.Fatalf("write to a leader cut off from the group succeeded")
+	}
+
+	// Back, it takes the new leader's entries over its own.
+	rn.isolate(old.id, false)
+	old.restart(t)
+	leader := waitForLeader(t, members)
+	mustCreate(t, leader.svc, "root", "rejoined", false)
+	waitFor(t, old.id+" to catch up", func() bool {
+		return old.svc.Raft().Applied() >= leader.svc.Raft().Applied()
+	})
+	old.stop()
+	standalone := newJournaledMDS(t, old.boltPath, old.journalPath)
+	defer standalone.Close()
+	for _, name := range []string{"before", "after0", "after4", "rejoined"} {
+		mustExist(t, standalone, "root", name, true)
+	}
+	mustExist(t, standalone, "root", "lost", false)
+}
+
+func TestMDSRaftPartitionedFollowerCatchesUp(t *testing.T) {
+	t.Parallel()
+	members, rn := startPartitionableMDS(t, 3)
+	leader := waitForLeader(t, members)
+	follower := others(members, leader)[0]
+
+	// The other two still make a majority. The follower keeps running and
+	// standing for election on its own.
+	rn.isolate(follower.id, true)
+	for i := range 40 {
+		mustCreate(t, leader.svc, "root", fmt.Sprintf("a%d", i), false)
+	}
+	if follower.svc.Raft().IsLeader() {
+		t.Fatalf("%s leads while cut off from the group", follower.id)
+	}
+
+	// Healed, its higher term may force an election it cannot win with a
+	// shorter log, so find the leader again.
+	rn.isolate(follower.id, false)
+	leader = waitForLeader(t, members)
+	if leader == follower {
+		t.Fatalf("%s was elected with a log missing committed entries", follower.id)
+	}
+	mustCreate(t, leader.svc, "root", "healed", false)
+	waitFor(t, follower.id+" to catch up", func() bool {
+		return follower.svc.Raft().Applied() >= leader.svc.Raft().Applied()
+	})
+	follower.stop()
+	standalone := newJournaledMDS(t, follower.boltPath, follower.journalPath)
+	defer standalone.Close()
+	for _, name := range []string{"a0", "a39", "healed"} {
+		mustExist(t, standalone, "root", name, true)
+	}
+}
+
+func TestMDSRaftReadIndexOnDeposedLeader(t *testing.T) {
+	t.Parallel()
+	members, rn := startPartitionableMDS(t, 3)
+	old := waitForLeader(t, members)
+
+	// Cut off, the old leader still believes it leads while the others
+	// elect a new one and write past it. It must not answer reads from its
+	// stale state.
+	rn.isolate(old.id, true)
+	next := waitForLeader(t, others(members, old))
+	mustCreate(t, next.svc, "root", "fresh", false)
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	_, err := old.svc.Lookup(ctx, &protogen.LookupRequest{ParentInodeId: "root", Name: "fresh"})
+	cancel()
+	if code := status.Code(err); code != codes.DeadlineExceeded && code != codes.Unavailable {
+		t.Fatalf("read on a deposed leader: got %v, want DEADLINE_EXCEEDED or UNAVAILABLE", err)
+	}
+
+	// Once it hears the new term it steps down and points at the leader.
+	rn.isolate(old.id, false)
+	waitFor(t, old.id+" to step down", func() bool { return !old.svc.Raft().IsLeader() })
+	_, err = old.svc.Lookup(context.Background(), &protogen.LookupRequest{ParentInodeId: "root", Name: "fresh"})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("read on a former leader: got %v, want UNAVAILABLE", err)
+	}
+}
+
+func TestMDSRaftRestartDoesNotReapply(t *testing.T) {
+	t.Parallel()
+	members := startReplicatedMDS(t, 3)
+	leader := waitForLeader(t, members)
+	follower := others(members, leader)[0]
+	caughtUp := func(m *raftMember) {
+		t.Helper()
+		waitFor(t, m.id+" to catch up", func() bool { return m.svc.Raft().Applied() >= leader.svc.Raft().Applied() })
+	}
+
+	for i := range 5 {
+		mustCreate(t, leader.svc, "root", fmt.Sprintf("a%d", i), false)
+	}
+	caughtUp(follower)
+	snap := filepath.Join(t.TempDir(), "before.db")
+	if _, err := follower.svc.Snapshot(snap); err != nil {
+		t.Fatalf("snapshot %s: %v", follower.id, err)
+	}
+	last := mustCreate(t, leader.svc, "root", "last", false)
+	caughtUp(follower)
+	follower.stop()
+
+	// Put the follower back to where it crashed after journaling the last
+	// entry but before bolt committed it: the journal and raft log hold it,
+	// bolt does not. Replay must restore the applied index with it, or raft
+	// applies the entry a second time.
+	var meta [][2][]byte
+	readMeta := func(db *bbolt.DB) error {
+		return db.View(func(tx *bbolt.Tx) error {
+			metaB := tx.Bucket([]byte("meta"))
+			for _, key := range []string{"journal_seq", "journal_offset", "raft_applied"} {
+				meta = append(meta, [2][]byte{[]byte(key), append([]byte(nil), metaB.Get([]byte(key))...)})
+			}
+			return nil
+		})
+	}
+	db, err := bbolt.Open(snap, 0600, nil)
+	if err != nil {
+		t.Fatalf("open snapshot: %v", err)
+	}
+	if err := readMeta(db); err != nil {
+		t.Fatalf("read snapshot meta: %v", err)
+	}
+	db.Close()
+	db, err = bbolt.Open(follower.boltPath, 0600, nil)
+	if err != nil {
+		t.Fatalf("open bolt: %v", err)
+	}
+	err = db.Update(func(tx *bbolt.Tx) error {
+		metaB := tx.Bucket([]byte("meta"))
+		for _, kv := range meta {
+			if err := metaB.Put(kv[0], kv[1]); err != nil {
+				return err
+			}
+		}
+		if err := tx.Bucket([]byte("inodes")).Delete([]byte(last.GetInodeId())); err != nil {
+			return err
+		}
+		return tx.Bucket([]byte("dirents")).Delete([]byte("root\x00last"))
+	})
+	db.Close()
+	if err != nil {
+		t.Fatalf("roll back bolt: %v", err)
+	}
+
+	follower.restart(t)
+	leader = waitForLeader(t, members)
+	mustCreate(t, leader.svc, "root", "after", false)
+	for _, m := range members {
+		caughtUp(m)
+	}
+
+	// Every member journals each entry once, so they end at the same
+	// sequence number.
+	seqs := map[string]uint64{}
+	for _, m := range members {
+		m.stop()
+		standalone := newJournaledMDS(t, m.boltPath, m.journalPath)
+		for _, name := range []string{"a0", "last", "after"} {
+			mustExist(t, standalone, "root", name, true)
+		}
+		standalone.Close()
+		db, err := bbolt.Open(m.boltPath, 0600, &bbolt.Options{ReadOnly: true})
+		if err != nil {
+			t.Fatalf("open %s bolt: %v", m.id, err)
+		}
+		_ = db.View(func(tx *bbolt.Tx) error {
+			v := tx.Bucket([]byte("meta")).Get([]byte("journal_seq"))
+			if len(v) == 8 {
+				seqs[m.id] = binary.BigEndian.Uint64(v)
+			}
+			return nil
+		})
+		db.Close()
+	}
+	if seqs[follower.id] == 0 || seqs[follower.id] != seqs[leader.id] {
+		t.Fatalf("journal sequence numbers %v differ, %s applied an entry twice", seqs, follower.id)
+	}
+}
@@ -0,0 +1,108 @@
+package smoke
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/rachanaanugandula/kube-pfs/pkg/mds"
+	protogen "github.com/rachanaanugandula/kube-pfs/pkg/proto/gen"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func newReplyCacheMDS(t *testing.T, boltPath, journalPath string, entries int) *mds.Service {
+	t.Helper()
+	svc, err := mds.NewService(mds.Config{
+		BoltPath:          boltPath,
+		OSTIDs:            []string{"ost-0", "ost-1", "ost-2"},
+		JournalPath:       journalPath,
+		ReplyCacheEntries: entries,
+	})
+	if err != nil {
+		t.Fatalf("new mds service: %v", err)
+	}
+	return svc
+}
+
+func TestMDSReplyCache(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	dir := t.TempDir()
+	boltPath, journalPath := filepath.Join(dir, "mds.db"), filepath.Join(dir, "mds.journal")
+	svc := newReplyCacheMDS(t, boltPath, journalPath, 4)
+	reqID := func(seq uint64) *protogen.RequestID {
+		return &protogen.RequestID{ClientId: "client-a", Seq: seq}
+	}
+
+	// Every mutating call answers a repeat with its first response.
+	create := &protogen.CreateRequest{ParentInodeId: "root", Name: "a", RequestId: reqID(1)}
+	created, err := svc.Create(ctx, create)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	const mib = 1024 * 1024
+	if _, err := svc.SetAttr(ctx, &protogen.SetAttrRequest{
+		InodeId:   created.GetInode().GetInodeId(),
+		Mask:      uint32(protogen.SetAttrMask_SETATTR_SIZE),
+		SizeBytes: 3 * mib,
+	}); err != nil {
+		t.Fatalf("grow: %v", err)
+	}
+	truncate := &protogen.SetAttrRequest{
+		InodeId:   created.GetInode().GetInodeId(),
+		Mask:      uint32(protogen.SetAttrMask_SETATTR_SIZE),
+		RequestId: reqID(2),
+	}
+	rename := &protogen.RenameRequest{SrcParentInodeId: "root", SrcName: "a", DstParentInodeId: "root", DstName: "b", RequestId: reqID(3)}
+	calls := []struct {
+		name string
+		call func() (proto.Message, error)
+	}{
+		{"create", func() (proto.Message, error) { return svc.Create(ctx, create) }},
+		{"truncate", func() (proto.Message, error) { return svc.SetAttr(ctx, truncate) }},
+		{"rename", func() (proto.Message, error) { return svc.Rename(ctx, rename) }},
+	}
+	first := map[string]proto.Message{"create": created}
+	for _, c := range calls[1:] {
+		res, err := c.call()
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		first[c.name] = res
+	}
+	if n := len(first["truncate"].(*protogen.SetAttrResponse).GetTruncatedBlocks()); n != 3 {
+		t.Fatalf("truncate reported %d blocks, want 3", n)
+	}
+	for _, c := range calls {
+		res, err := c.call()
+		if err != nil || !proto.Equal(res, first[c.name]) {
+			t.Fatalf("repeated %s returned %v, %v; want %v", c.name, res, err, first[c.name])
+		}
+	}
+	if _, err := svc.Unlink(ctx, &protogen.UnlinkRequest{ParentInodeId: "root", Name: "b", RequestId: reqID(3)}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("reusing a request id for another operation: expected INVALID_ARGUMENT, got %v", err)
+	}
+
+	// Replies survive a restart, up to the configured number.
+	svc.Close()
+	svc = newReplyCacheMDS(t, boltPath, journalPath, 4)
+	t.Cleanup(func() { _ = svc.Close() })
+	if res, err := svc.Create(ctx, create); err != nil || !proto.Equal(res, created) {
+		t.Fatalf("create repeated after restart returned %v, %v", res, err)
+	}
+	for seq := uint64(4); seq <= 5; seq++ {
+		if _, err := svc.SetDirLayout(ctx, &protogen.SetDirLayoutRequest{InodeId: "root", RequestId: reqID(seq)}); err != nil {
+			t.Fatalf("set dir layout: %v", err)
+		}
+	}
+	// The oldest reply is gone, so the create runs again: "a" was renamed
+	// away, and a new inode takes its name.
+	if res, err := svc.Create(ctx, create); err != nil || res.GetInode().GetInodeId() == created.GetInode().GetInodeId() {
+		t.Fatalf("create repeated after its reply was evicted returned %v, %v; want a new inode", res.GetInode(), err)
+	}
+	if res, err := svc.Rename(ctx, rename); err != nil || !proto.Equal(res, first["rename"]) {
+		t.Fatalf("rename repeated within the cache returned %v, %v", res, err)
+	}
+}
@@ -0,0 +1,377 @@
+package smoke
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rachanaanugandula/kube-pfs/pkg/client"
+	"github.com/rachanaanugandula/kube-pfs/pkg/mds"
+	protogen "github.com/rachanaanugandula/kube-pfs/pkg/proto/gen"
+	"github.com/rachanaanugandula/kube-pfs/pkg/shardmap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+const shardTxnTimeout = 200 * time.Millisecond
+
+// shardFaults breaks the calls shards make on each other.
+type shardFaults struct {
+	failCommits atomic.Bool
+	// losePrepares lets prepares run but loses their answers, and the
+	// aborts sent after them.
+	losePrepares atomic.Bool
+}
+
+type faultyShardClient struct {
+	protogen.ShardServiceClient
+	faults *shardFaults
+}
+
+func (c *faultyShardClient) PrepareShardTxn(ctx context.Context, in *protogen.PrepareShardTxnRequest, opts ...grpc.CallOption) (*protogen.PrepareShardTxnResponse, error) {
+	res, err := c.ShardServiceClient.PrepareShardTxn(ctx, in, opts...)
+	if err == nil && c.faults.losePrepares.Load() {
+		return nil, status.Error(codes.Unavailable, "injected: prepare answer lost")
+	}
+	return res, err
+}
+
+func (c *faultyShardClient) CommitShardTxn(ctx context.Context, in *protogen.ShardTxnRequest, opts ...grpc.CallOption) (*protogen.ShardTxnResponse, error) {
+	if c.faults.failCommits.Load() {
+		return nil, status.Error(codes.Unavailable, "injected: commit lost")
+	}
+	return c.ShardServiceClient.CommitShardTxn(ctx, in, opts...)
+}
+
+func (c *faultyShardClient) AbortShardTxn(ctx context.Context, in *protogen.ShardTxnRequest, opts ...grpc.CallOption) (*protogen.ShardTxnResponse, error) {
+	if c.faults.losePrepares.Load() {
+		return nil, status.Error(codes.Unavailable, "injected: abort lost")
+	}
+	return c.ShardServiceClient.AbortShardTxn(ctx, in, opts...)
+}
+
+type shardMember struct {
+	cfg     mds.Config
+	addr    string
+	svc     *mds.Service
+	srv     *grpc.Server
+	stopped bool
+}
+
+func (m *shardMember) start(t *testing.T, lis net.Listener) {
+	t.Helper()
+	svc, err := mds.NewService(m.cfg)
+	if err != nil {
+		t.Fatalf("new %s: %v", m.cfg.Sharding.ShardID, err)
+	}
+	srv := grpc.NewServer()
+	protogen.RegisterMetadataServiceServer(srv, svc)
+	protogen.RegisterShardServiceServer(srv, svc.ShardService())
+	m.svc, m.srv, m.stopped = svc, srv, false
+	go func() { _ = srv.Serve(lis) }()
+}
+
+func (m *shardMember) stop() {
+	if m.stopped {
+		return
+	}
+	m.stopped = true
+	m.srv.Stop()
+	_ = m.svc.Close()
+}
+
+// restart stops the shard and starts it again on the same database and
+// address.
+func (m *shardMember) restart(t *testing.T) {
+	t.Helper()
+	m.stop()
+	lis, err := net.Listen("tcp", m.addr)
+	if err != nil {
+		t.Fatalf("listen again on %s: %v", m.addr, err)
+	}
+	m.start(t, lis)
+}
+
+// startShardedMDS runs the shards of one namespace in this process, each
+// serving MetadataService and ShardService on a loopback port.
+func startShardedMDS(t *testing.T, size int, faults *shardFaults) []*shardMember {
+	t.Helper()
+	dir := t.TempDir()
+	members := make([]*shardMember, size)
+	listeners := make([]net.Listener, size)
+	var shards []mds.Shard
+	for i := range members {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("listen: %v", err)
+		}
+		listeners[i] = lis
+		members[i] = &shardMember{addr: lis.Addr().String()}
+		shards = append(shards, mds.Shard{ID: fmt.Sprintf("shard-%d", i), Address: members[i].addr})
+	}
+	dial := func(address string) (protogen.ShardServiceClient, error) {
+		conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			return nil, err
+		}
+		t.Cleanup(func() { _ = conn.Close() })
+		return &faultyShardClient{ShardServiceClient: protogen.NewShardServiceClient(conn), faults: faults}, nil
+	}
+	for i, m := range members {
+		m.cfg = mds.Config{
+			BoltPath: filepath.Join(dir, shards[i].ID+".db"),
+			OSTIDs:   []string{"ost-0", "ost-1", "ost-2"},
+			Sharding: &mds.ShardingConfig{
+				ShardID:    shards[i].ID,
+				Shards:     shards,
+				Dial:       dial,
+				TxnTimeout: shardTxnTimeout,
+			},
+		}
+		m.start(t, listeners[i])
+		t.Cleanup(m.stop)
+	}
+	return members
+}
+
+func shardOf(t *testing.T, mdsClient *client.ShardedMDSClient, inodeID string) string {
+	t.Helper()
+	res, err := mdsClient.GetShardMap(context.Background(), &protogen.GetShardMapRequest{})
+	if err != nil {
+		t.Fatalf("get shard map: %v", err)
+	}
+	return shardmap.Owner(res.GetShards(), inodeID).GetShardId()
+}
+
+func TestMDSShardedNamespace(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	members := startShardedMDS(t, 3, &shardFaults{})
+
+	// The map can be read from any shard.
+	mdsClient, err := client.DialShardedMDS([]string{members[2].addr}, client.MDSConfig{})
+	if err != nil {
+		t.Fatalf("dial sharded mds: %v", err)
+	}
+	defer mdsClient.Close()
+	shardMap, err := mdsClient.GetShardMap(ctx, &protogen.GetShardMapRequest{})
+	if err != nil || len(shardMap.GetShards()) != 3 || shardMap.GetShards()[0].GetShardId() != "shard-0" {
+		t.Fatalf("shard map %v, %v", shardMap.GetShards(), err)
+	}
+
+	// A directory created on another shard takes what is created below it.
+	proj, err := mdsClient.Create(ctx, &protogen.CreateRequest{ParentInodeId: "root", Name: "proj", IsDir: true, ShardId: "shard-1"})
+	if err != nil {
+		t.Fatalf("create remote directory: %v", err)
+	}
+	projID := proj.GetInode().GetInodeId()
+	if got := shardOf(t, mdsClient, projID); got != "shard-1" {
+		t.Fatalf("proj is on %s, want shard-1", got)
+	}
+	file, err := mdsClient.Create(ctx, &protogen.CreateRequest{ParentInodeId: projID, Name: "a"})
+	if err != nil {
+		t.Fatalf("create file in remote directory: %v", err)
+	}
+	fileID := file.GetInode().GetInodeId()
+	if got := shardOf(t, mdsClient, fileID); got != "shard-1" {
+		t.Fatalf("file is on %s, want shard-1", got)
+	}
+	if _, err := members[0].svc.Stat(ctx, &protogen.StatRequest{InodeId: fileID}); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("stat on a shard not holding the inode: expected FAILED_PRECONDITION, got %v", err)
+	}
+	if _, err := mdsClient.Create(ctx, &protogen.CreateRequest{ParentInodeId: "root", Name: "f", ShardId: "shard-2"}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("create a file on another shard: expected INVALID_ARGUMENT, got %v", err)
+	}
+	listed, err := mdsClient.ListDir(ctx, &protogen.ListDirRequest{InodeId: "root"})
+	if err != nil || len(listed.GetEntries()) != 1 || !listed.GetEntries()[0].GetIsDir() || listed.GetEntries()[0].GetInodeId() != projID {
+		t.Fatalf("list root: %v, %v", listed.GetEntries(), err)
+	}
+
+	// Files move between directories on different shards, in both
+	// directions, and keep their inode.
+	moved, err := mdsClient.Rename(ctx, &protogen.RenameRequest{SrcParentInodeId: projID, SrcName: "a", DstParentInodeId: "root", DstName: "a"})
+	if err != nil || moved.GetInode().GetInodeId() != fileID || moved.GetInode().GetParentInodeId() != "root" {
+		t.Fatalf("rename into root: %v, %v", moved.GetInode(), err)
+	}
+	mustExist(t, members[0].svc, "root", "a", true)
+	mustExist(t, members[1].svc, projID, "a", false)
+	back, err := mdsClient.Rename(ctx, &protogen.RenameRequest{SrcParentInodeId: "root", SrcName: "a", DstParentInodeId: projID, DstName: "b"})
+	if err != nil || back.GetInode().GetParentInodeId() != projID || back.GetInode().GetName() != "b" {
+		t.Fatalf("rename back into proj: %v, %v", back.GetInode(), err)
+	}
+	stat, err := mdsClient.Stat(ctx, &protogen.StatRequest{InodeId: fileID})
+	if err != nil || stat.GetInode().GetParentInodeId() != projID || stat.GetInode().GetName() != "b" {
+		t.Fatalf("stat renamed file: %v, %v", stat.GetInode(), err)
+	}
+	mustExist(t, members[0].svc, "root", "a", false)
+
+	// What the other shard would have to check or undo is refused.
+	mustCreate(t, members[0].svc, "root", "c", false)
+	for _, tc := range []struct {
+		name  string
+		req   *protogen.RenameRequest
+		wants codes.Code
+	}{
+		{"noreplace over a remote entry", &protogen.RenameRequest{SrcParentInodeId: "root", SrcName: "c", DstParentInodeId: projID, DstName: "b", Flags: protogen.RenameFlags_RENAME_NOREPLACE}, codes.AlreadyExists},
+		{"replace a remote entry", &protogen.RenameRequest{SrcParentInodeId: "root", SrcName: "c", DstParentInodeId: projID, DstName: "b"}, codes.FailedPrecondition},
+		{"exchange across shards", &protogen.RenameRequest{SrcParentInodeId: "root", SrcName: "c", DstParentInodeId: projID, DstName: "b", Flags: protogen.RenameFlags_RENAME_EXCHANGE}, codes.Unimplemented},
+		{"move a directory across shards", &protogen.RenameRequest{SrcParentInodeId: "root", SrcName: "proj", DstParentInodeId: projID, DstName: "proj"}, codes.Unimplemented},
+	} {
+		if _, err := mdsClient.Rename(ctx, tc.req); status.Code(err) != tc.wants {
+			t.Fatalf("%s: expected %v, got %v", tc.name, tc.wants, err)
+		}
+	}
+	// A remote directory can be renamed in place.
+	if _, err := mdsClient.Rename(ctx, &protogen.RenameRequest{SrcParentInodeId: "root", SrcName: "proj", DstParentInodeId: "root", DstName: "project"}); err != nil {
+		t.Fatalf("rename remote directory in place: %v", err)
+	}
+	if stat, err := mdsClient.Stat(ctx, &protogen.StatRequest{InodeId: projID}); err != nil || stat.GetInode().GetName() != "project" {
+		t.Fatalf("stat renamed directory: %v, %v", stat.GetInode(), err)
+	}
+
+	// Removing a remote directory is checked on its shard.
+	if _, err := mdsClient.Unlink(ctx, &protogen.UnlinkRequest{ParentInodeId: "root", Name: "project"}); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("rmdir of a non-empty remote directory: expected FAILED_PRECONDITION, got %v", err)
+	}
+	if res, err := mdsClient.Unlink(ctx, &protogen.UnlinkRequest{ParentInodeId: projID, Name: "b"}); err != nil || !res.GetDeleted() {
+		t.Fatalf("unlink file: %v, %v", res, err)
+	}
+	if res, err := mdsClient.Unlink(ctx, &protogen.UnlinkRequest{ParentInodeId: "root", Name: "project"}); err != nil || !res.GetDeleted() {
+		t.Fatalf("rmdir of an empty remote directory: %v, %v", res, err)
+	}
+	if _, err := mdsClient.Stat(ctx, &protogen.StatRequest{InodeId: projID}); status.Code(err) != codes.NotFound {
+		t.Fatalf("stat removed directory: expected NOT_FOUND, got %v", err)
+	}
+	mustExist(t, members[0].svc, "root", "project", false)
+	exist, err := mdsClient.InodesExist(ctx, &protogen.InodesExistRequest{InodeIds: []string{projID, "root", fileID}})
+	if err != nil || len(exist.GetExisting()) != 1 || exist.GetExisting()[0] != "root" {
+		t.Fatalf("inodes exist: %v, %v", exist.GetExisting(), err)
+	}
+}
+
+func TestMDSShardTxnRecovery(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	faults := &shardFaults{}
+	members := startShardedMDS(t, 2, faults)
+	coordinator, participant := members[0], members[1]
+
+	// A participant that misses the commit is told again by the coordinator.
+	faults.failCommits.Store(true)
+	dir, err := coordinator.svc.Create(ctx, &protogen.CreateRequest{ParentInodeId: "root", Name: "dir", IsDir: true, ShardId: "shard-1"})
+	if err != nil {
+		t.Fatalf("create remote directory: %v", err)
+	}
+	dirID := dir.GetInode().GetInodeId()
+	// The entry is already visible on the coordinator, so reading the inode
+	// waits for the participant to commit rather than missing it.
+	if err := statWithin(participant.svc, dirID, 50*time.Millisecond); status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("stat before the participant committed: expected DEADLINE_EXCEEDED, got %v", err)
+	}
+	faults.failCommits.Store(false)
+	if err := coordinator.svc.ResolveShardTxnsOnce(ctx); err != nil {
+		t.Fatalf("resolve on the coordinator: %v", err)
+	}
+	if _, err := participant.svc.Stat(ctx, &protogen.StatRequest{InodeId: dirID}); err != nil {
+		t.Fatalf("stat after the coordinator resolved: %v", err)
+	}
+
+	// A participant that never hears the outcome asks the coordinator, which
+	// has no record of it and so aborted it. The prepared part, and the
+	// pin on dir it holds, survive a restart until then.
+	mustCreate(t, coordinator.svc, "root", "file", false)
+	faults.losePrepares.Store(true)
+	if _, err := coordinator.svc.Rename(ctx, &protogen.RenameRequest{SrcParentInodeId: "root", SrcName: "file", DstParentInodeId: dirID, DstName: "file"}); status.Code(err) != codes.Unavailable {
+		t.Fatalf("rename with its prepare answer lost: expected UNAVAILABLE, got %v", err)
+	}
+	faults.losePrepares.Store(false)
+	participant.restart(t)
+	waitFor(t, "the coordinator to reach the restarted participant", func() bool {
+		_, err := coordinator.svc.ListDir(ctx, &protogen.ListDirRequest{InodeId: "root"})
+		return err == nil
+	})
+	if _, err := coordinator.svc.Rename(ctx, &protogen.RenameRequest{SrcParentInodeId: "root", SrcName: "file", DstParentInodeId: dirID, DstName: "other"}); status.Code(err) != codes.Aborted {
+		t.Fatalf("rename into a directory a prepared transaction holds: expected ABORTED, got %v", err)
+	}
+	time.Sleep(shardTxnTimeout)
+	if err := participant.svc.ResolveShardTxnsOnce(ctx); err != nil {
+		t.Fatalf("resolve on the participant: %v", err)
+	}
+	mustExist(t, participant.svc, dirID, "file", false)
+	mustExist(t, coordinator.svc, "root", "file", true)
+	if _, err := coordinator.svc.Rename(ctx, &protogen.RenameRequest{SrcParentInodeId: "root", SrcName: "file", DstParentInodeId: dirID, DstName: "file"}); err != nil {
+		t.Fatalf("rename after the participant aborted: %v", err)
+	}
+	mustExist(t, participant.svc, dirID, "file", true)
+}
+
+func statWithin(svc *mds.Service, inodeID string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	_, err := svc.Stat(ctx, &protogen.StatRequest{InodeId: inodeID})
+	return err
+}
+
+func TestMDSShardTxnOutlivesCoordinator(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	faults := &shardFaults{}
+	members := startShardedMDS(t, 2, faults)
+	coordinator, participant := members[0], members[1]
+	res, err := coordinator.svc.Create(ctx, &protogen.CreateRequest{ParentInodeId: "root", Name: "other", IsDir: true, ShardId: "shard-1"})
+	if err != nil {
+		t.Fatalf("create remote directory: %v", err)
+	}
+	other := res.GetInode()
+	// Every inode on the participant hashes to its one lock shard, so only
+	// the transaction's own inodes may wait on it.
+	participant.cfg.LockShards = 1
+	participant.restart(t)
+	waitFor(t, "the coordinator to reach the restarted participant", func() bool {
+		_, err := coordinator.svc.ListDir(ctx, &protogen.ListDirRequest{InodeId: "root"})
+		return err == nil
+	})
+
+	// The coordinator commits, then goes down before the participant hears.
+	faults.failCommits.Store(true)
+	held, err := coordinator.svc.Create(ctx, &protogen.CreateRequest{ParentInodeId: "root", Name: "held", IsDir: true, ShardId: "shard-1"})
+	if err != nil {
+		t.Fatalf("create remote directory: %v", err)
+	}
+	heldID := held.GetInode().GetInodeId()
+	coordinator.stop()
+	faults.failCommits.Store(false)
+
+	// Past TxnTimeout the participant asks for the outcome, gets no answer
+	// and keeps the transaction prepared: the coordinator may have
+	// committed, as it did here.
+	time.Sleep(2 * shardTxnTimeout)
+	if err := participant.svc.ResolveShardTxnsOnce(ctx); err == nil {
+		t.Fatalf("expected resolving with the coordinator down to fail")
+	}
+	if err := statWithin(participant.svc, heldID, 100*time.Millisecond); status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("stat of an inode the transaction pins: expected DEADLINE_EXCEEDED, got %v", err)
+	}
+	// Everything else on the participant carries on.
+	if err := statWithin(participant.svc, other.GetInodeId(), time.Second); err != nil {
+		t.Fatalf("stat of an unrelated inode: %v", err)
+	}
+	if _, err := participant.svc.Create(ctx, &protogen.CreateRequest{ParentInodeId: other.GetInodeId(), Name: "f"}); err != nil {
+		t.Fatalf("create in an unrelated directory: %v", err)
+	}
+
+	// Back up, the coordinator resends the outcome.
+	coordinator.restart(t)
+	waitFor(t, "the coordinator to resend the commit", func() bool {
+		return coordinator.svc.ResolveShardTxnsOnce(ctx) == nil
+	})
+	if err := statWithin(participant.svc, heldID, time.Second); err != nil {
+		t.Fatalf("stat after the transaction settled: %v", err)
+	}
+}
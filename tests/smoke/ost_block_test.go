@@ -0,0 +1,461 @@
+package smoke
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/rachanaanugandula/kube-pfs/pkg/ost"
+	protogen "github.com/rachanaanugandula/kube-pfs/pkg/proto/gen"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTestOST(t *testing.T, dataDir string) *ost.Service {
+	t.Helper()
+	svc, err := ost.NewService("ost-0", dataDir)
+	if err != nil {
+		t.Fatalf("new ost: %v", err)
+	}
+	return svc
+}
+
+func TestOSTPartialWrites(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	svc := newTestOST(t, filepath.Join(t.TempDir(), "ost-0"))
+	ref := &protogen.BlockRef{FileId: "inode-1", ChunkId: 0, OstId: "ost-0"}
+
+	res, err := svc.WriteBlock(ctx, &protogen.WriteBlockRequest{Block: ref, Data: []byte("tail"), Offset: 8})
+	if err != nil {
+		t.Fatalf("sparse write: %v", err)
+	}
+	if res.GetBlockLength() != 12 {
+		t.Fatalf("block length %d, want 12", res.GetBlockLength())
+	}
+
+	res, err = svc.WriteBlock(ctx, &protogen.WriteBlockRequest{Block: ref, Data: []byte("head"), Offset: 0})
+	if err != nil {
+		t.Fatalf("in-place write: %v", err)
+	}
+	if res.GetBlockLength() != 12 {
+		t.Fatalf("in-place write changed block length to %d", res.GetBlockLength())
+	}
+
+	read, err := svc.ReadBlock(ctx, &protogen.ReadBlockRequest{Block: ref})
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	want := append([]byte("head\x00\x00\x00\x00"), "tail"...)
+	if !bytes.Equal(read.GetData(), want) {
+		t.Fatalf("block = %q, want %q", read.GetData(), want)
+	}
+
+	res, err = svc.WriteBlock(ctx, &protogen.WriteBlockRequest{Block: ref, Offset: 4, Truncate: true})
+	if err != nil {
+		t.Fatalf("truncating write: %v", err)
+	}
+	if res.GetBlockLength() != 4 {
+		t.Fatalf("block length after truncate %d, want 4", res.GetBlockLength())
+	}
+}
+
+func TestOSTRejectsOutOfRangeWrites(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	dataDir := filepath.Join(t.TempDir(), "ost-0")
+	svc := newTestOST(t, dataDir)
+	ref := &protogen.BlockRef{FileId: "inode-9", ChunkId: 0, OstId: "ost-0"}
+
+	// A huge sparse block would make the checksum sidecar enormous, and an
+	// offset at 2^63 turns negative as a file offset.
+	for _, offset := range []uint64{1 << 43, 1 << 63, ^uint64(0) - 1} {
+		_, err := svc.WriteBlock(ctx, &protogen.WriteBlockRequest{Block: ref, Data: []byte("tail"), Offset: offset})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("write at offset %d: expected InvalidArgument, got %v", offset, err)
+		}
+	}
+
+	conn := serveInProcess(t, func(s *grpc.Server) { protogen.RegisterObjectStorageServiceServer(s, svc) })
+	stream, err := protogen.NewObjectStorageServiceClient(conn).WriteBlockStream(ctx)
+	if err != nil {
+		t.Fatalf("open stream: %v", err)
+	}
+	if err := stream.Send(&protogen.WriteBlockStreamRequest{Block: ref, Data: []byte("tail"), Offset: 1 << 63}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if _, err := stream.CloseAndRecv(); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("stream at offset 2^63: expected InvalidArgument, got %v", err)
+	}
+
+	// A stream that starts in range is cut off by the frame that crosses
+	// the limit, and leaves no block behind.
+	small, err := ost.NewServiceWithConfig(ost.Config{OSTID: "ost-0", DataDir: dataDir, MaxBlockBytes: 8})
+	if err != nil {
+		t.Fatalf("new ost: %v", err)
+	}
+	conn = serveInProcess(t, func(s *grpc.Server) { protogen.RegisterObjectStorageServiceServer(s, small) })
+	stream, err = protogen.NewObjectStorageServiceClient(conn).WriteBlockStream(ctx)
+	if err != nil {
+		t.Fatalf("open stream: %v", err)
+	}
+	for _, data := range []string{"head", "tail", "more"} {
+		if err := stream.Send(&protogen.WriteBlockStreamRequest{Block: ref, Data: []byte(data)}); err != nil {
+			break
+		}
+	}
+	if _, err := stream.CloseAndRecv(); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("stream past the limit: expected InvalidArgument, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dataDir, "inode-9", "0.blk")); !os.IsNotExist(err) {
+		t.Fatalf("rejected writes created a block: %v", err)
+	}
+}
+
+func TestOSTRejectsStreamsThatSwitchBlocks(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	dataDir := filepath.Join(t.TempDir(), "ost-0")
+	svc := newTestOST(t, dataDir)
+	conn := serveInProcess(t, func(s *grpc.Server) { protogen.RegisterObjectStorageServiceServer(s, svc) })
+	client := protogen.NewObjectStorageServiceClient(conn)
+	ref := &protogen.BlockRef{FileId: "inode-10", ChunkId: 0, OstId: "ost-0"}
+
+	for name, next := range map[string]*protogen.WriteBlockStreamRequest{
+		"other block":  {Block: &protogen.BlockRef{FileId: "inode-10", ChunkId: 1, OstId: "ost-0"}, Data: []byte("tail")},
+		"other offset": {Offset: 64, Data: []byte("tail")},
+	} {
+		stream, err := client.WriteBlockStream(ctx)
+		if err != nil {
+			t.Fatalf("%s: open stream: %v", name, err)
+		}
+		if err := stream.Send(&protogen.WriteBlockStreamRequest{Block: ref, Data: []byte("head")}); err != nil {
+			t.Fatalf("%s: send: %v", name, err)
+		}
+		_ = stream.Send(next)
+		if _, err := stream.CloseAndRecv(); status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("%s: expected InvalidArgument, got %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dataDir, "inode-10", "0.blk")); !os.IsNotExist(err) {
+		t.Fatalf("rejected streams left blocks behind: %v", err)
+	}
+
+	// Repeating the first message's block and offset is allowed.
+	stream, err := client.WriteBlockStream(ctx)
+	if err != nil {
+		t.Fatalf("open stream: %v", err)
+	}
+	for _, msg := range []*protogen.WriteBlockStreamRequest{
+		{Block: ref, Offset: 4, Data: []byte("head")},
+		{Block: ref, Offset: 4, Data: []byte("tail")},
+	} {
+		if err := stream.Send(msg); err != nil {
+			t.Fatalf("send: %v", err)
+		}
+	}
+	if res, err := stream.CloseAndRecv(); err != nil || res.GetBlockLength() != 12 {
+		t.Fatalf("repeated block and offset: length %d, err %v", res.GetBlockLength(), err)
+	}
+}
+
+func TestOSTDetectsCorruptBlocks(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	dataDir := filepath.Join(t.TempDir(), "ost-0")
+	svc := newTestOST(t, dataDir)
+	ref := &protogen.BlockRef{FileId: "inode-2", ChunkId: 3, OstId: "ost-0"}
+
+	payload := randomPayload(3, 200*1024)
+	if _, err := svc.WriteBlock(ctx, &protogen.WriteBlockRequest{Block: ref, Data: payload}); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	badSum := uint32(12345)
+	_, err := svc.WriteBlock(ctx, &protogen.WriteBlockRequest{Block: ref, Data: []byte("garbage"), Crc32C: &badSum})
+	if status.Code(err) != codes.DataLoss {
+		t.Fatalf("expected DataLoss for bad client checksum, got %v", err)
+	}
+	read, err := svc.ReadBlock(ctx, &protogen.ReadBlockRequest{Block: ref})
+	if err != nil {
+		t.Fatalf("read after rejected write: %v", err)
+	}
+	if !bytes.Equal(read.GetData(), payload) {
+		t.Fatalf("rejected write modified the block")
+	}
+
+	// Scribble over the second 64 KiB sector the way fault-injector does.
+	blockFile := filepath.Join(dataDir, "inode-2", "3.blk")
+	f, err := os.OpenFile(blockFile, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("open block file: %v", err)
+	}
+	if _, err := f.WriteAt([]byte("corrupted!"), 70*1024); err != nil {
+		t.Fatalf("corrupt block: %v", err)
+	}
+	f.Close()
+
+	// A read confined to the first sector is still served.
+	if _, err := svc.ReadBlock(ctx, &protogen.ReadBlockRequest{Block: ref, Length: 1024}); err != nil {
+		t.Fatalf("read of intact sector: %v", err)
+	}
+	if _, err := svc.ReadBlock(ctx, &protogen.ReadBlockRequest{Block: ref}); status.Code(err) != codes.DataLoss {
+		t.Fatalf("expected DataLoss for corrupt block, got %v", err)
+	}
+}
+
+func TestOSTScrubberQuarantinesCorruptBlocks(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	dataDir := filepath.Join(t.TempDir(), "ost-0")
+	svc := newTestOST(t, dataDir)
+
+	for chunk := uint64(0); chunk < 3; chunk++ {
+		ref := &protogen.BlockRef{FileId: "inode-9", ChunkId: chunk, OstId: "ost-0"}
+		if _, err := svc.WriteBlock(ctx, &protogen.WriteBlockRequest{Block: ref, Data: randomPayload(int64(chunk), 100*1024)}); err != nil {
+			t.Fatalf("write chunk %d: %v", chunk, err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dataDir, "inode-9", "1.blk"), []byte("short and wrong"), 0644); err != nil {
+		t.Fatalf("corrupt block: %v", err)
+	}
+
+	scrubber := ost.NewScrubber(svc, ost.ScrubConfig{RateBytesPerSec: 1 << 30})
+	stats, err := scrubber.ScrubOnce(ctx)
+	if err != nil {
+		t.Fatalf("scrub: %v", err)
+	}
+	if stats.BlocksScanned != 3 || stats.Errors != 1 {
+		t.Fatalf("scrub stats = %+v, want 3 scanned and 1 error", stats)
+	}
+
+	list, err := svc.ListCorruptBlocks(ctx, &protogen.ListCorruptBlocksRequest{})
+	if err != nil {
+		t.Fatalf("list corrupt blocks: %v", err)
+	}
+	if len(list.GetBlocks()) != 1 || list.GetBlocks()[0].GetBlock().GetChunkId() != 1 || list.GetBlocks()[0].GetDetectedBy() != "scrub" {
+		t.Fatalf("unexpected quarantine list: %v", list.GetBlocks())
+	}
+
+	if _, err := svc.DeleteBlock(ctx, &protogen.DeleteBlockRequest{Block: list.GetBlocks()[0].GetBlock()}); err != nil {
+		t.Fatalf("delete corrupt block: %v", err)
+	}
+	list, _ = svc.ListCorruptBlocks(ctx, &protogen.ListCorruptBlocksRequest{})
+	if len(list.GetBlocks()) != 0 {
+		t.Fatalf("deleted block still quarantined: %v", list.GetBlocks())
+	}
+}
+
+func TestOSTQuarantineSurvivesRestart(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	dataDir := filepath.Join(t.TempDir(), "ost-0")
+	svc := newTestOST(t, dataDir)
+	ref := &protogen.BlockRef{FileId: "inode-4", ChunkId: 2, OstId: "ost-0"}
+	if _, err := svc.WriteBlock(ctx, &protogen.WriteBlockRequest{Block: ref, Data: randomPayload(4, 200*1024)}); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	f, err := os.OpenFile(filepath.Join(dataDir, "inode-4", "2.blk"), os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("open block file: %v", err)
+	}
+	if _, err := f.WriteAt([]byte("corrupted!"), 70*1024); err != nil {
+		t.Fatalf("corrupt block: %v", err)
+	}
+	f.Close()
+	if _, err := svc.ReadBlock(ctx, &protogen.ReadBlockRequest{Block: ref}); status.Code(err) != codes.DataLoss {
+		t.Fatalf("expected DataLoss for corrupt block, got %v", err)
+	}
+
+	quarantined := func(svc *ost.Service) []*protogen.CorruptBlock {
+		t.Helper()
+		list, err := svc.ListCorruptBlocks(ctx, &protogen.ListCorruptBlocksRequest{})
+		if err != nil {
+			t.Fatalf("list corrupt blocks: %v", err)
+		}
+		return list.GetBlocks()
+	}
+	svc = newTestOST(t, dataDir)
+	if got := quarantined(svc); len(got) != 1 || got[0].GetBlock().GetFileId() != "inode-4" || got[0].GetBlock().GetChunkId() != 2 || got[0].GetDetectedBy() != "read" {
+		t.Fatalf("quarantine after restart: %v", got)
+	}
+
+	// A write that leaves old sectors in place leaves the block quarantined;
+	// one that replaces the whole block releases it, for good.
+	if _, err := svc.WriteBlock(ctx, &protogen.WriteBlockRequest{Block: ref, Data: []byte("head")}); err != nil {
+		t.Fatalf("partial write: %v", err)
+	}
+	if got := quarantined(svc); len(got) != 1 {
+		t.Fatalf("partial write released the block: %v", got)
+	}
+	payload := randomPayload(5, 100*1024)
+	if _, err := svc.WriteBlock(ctx, &protogen.WriteBlockRequest{Block: ref, Data: payload, Truncate: true}); err != nil {
+		t.Fatalf("rewrite: %v", err)
+	}
+	if got := quarantined(svc); len(got) != 0 {
+		t.Fatalf("rewritten block still quarantined: %v", got)
+	}
+	svc = newTestOST(t, dataDir)
+	if got := quarantined(svc); len(got) != 0 {
+		t.Fatalf("rewritten block quarantined again after restart: %v", got)
+	}
+	read, err := svc.ReadBlock(ctx, &protogen.ReadBlockRequest{Block: ref})
+	if err != nil || !bytes.Equal(read.GetData(), payload) {
+		t.Fatalf("read rewritten block: %v", err)
+	}
+}
+
+func TestOSTAtomicWrites(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	dataDir := filepath.Join(t.TempDir(), "ost-0")
+	svc := newTestOST(t, dataDir)
+	ref := &protogen.BlockRef{FileId: "inode-4", ChunkId: 0, OstId: "ost-0"}
+
+	// Each write copies the block before renaming over it, so concurrent
+	// writers to one block must still all land.
+	const writers, span = 16, 4096
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			data := bytes.Repeat([]byte{byte(i + 1)}, span)
+			if _, err := svc.WriteBlock(ctx, &protogen.WriteBlockRequest{Block: ref, Data: data, Offset: uint64(i * span)}); err != nil {
+				t.Errorf("writer %d: %v", i, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	read, err := svc.ReadBlock(ctx, &protogen.ReadBlockRequest{Block: ref})
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	for i := 0; i < writers; i++ {
+		if !bytes.Equal(read.GetData()[i*span:(i+1)*span], bytes.Repeat([]byte{byte(i + 1)}, span)) {
+			t.Fatalf("update from writer %d was lost", i)
+		}
+	}
+
+	// A stream whose checksum does not match is never renamed into place.
+	conn := serveInProcess(t, func(s *grpc.Server) { protogen.RegisterObjectStorageServiceServer(s, svc) })
+	stream, err := protogen.NewObjectStorageServiceClient(conn).WriteBlockStream(ctx)
+	if err != nil {
+		t.Fatalf("open stream: %v", err)
+	}
+	badSum := uint32(1)
+	if err := stream.Send(&protogen.WriteBlockStreamRequest{Block: ref, Data: []byte("overwrite"), Crc32C: &badSum}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if _, err := stream.CloseAndRecv(); status.Code(err) != codes.DataLoss {
+		t.Fatalf("expected DataLoss for bad stream checksum, got %v", err)
+	}
+	after, err := svc.ReadBlock(ctx, &protogen.ReadBlockRequest{Block: ref})
+	if err != nil || !bytes.Equal(after.GetData(), read.GetData()) {
+		t.Fatalf("rejected stream modified the block (err=%v)", err)
+	}
+
+	// Leftovers from a crash mid-write are cleared on the next start.
+	orphan := filepath.Join(dataDir, "inode-4", ".0.blk.tmp-123")
+	if err := os.WriteFile(orphan, []byte("half a write"), 0644); err != nil {
+		t.Fatalf("plant orphan: %v", err)
+	}
+	restarted, err := ost.NewServiceWithConfig(ost.Config{OSTID: "ost-0", DataDir: dataDir, SyncMode: ost.SyncData})
+	if err != nil {
+		t.Fatalf("restart: %v", err)
+	}
+	if _, err := os.Stat(orphan); !os.IsNotExist(err) {
+		t.Fatalf("orphaned temp file survived restart: %v", err)
+	}
+	entries, _ := os.ReadDir(filepath.Join(dataDir, "inode-4"))
+	if len(entries) != 2 {
+		t.Fatalf("expected only the block and its checksums, found %d entries", len(entries))
+	}
+	if _, err := restarted.ReadBlock(ctx, &protogen.ReadBlockRequest{Block: ref}); err != nil {
+		t.Fatalf("read after restart: %v", err)
+	}
+}
+
+func TestOSTSyncModeConfig(t *testing.T) {
+	t.Parallel()
+	dataDir := filepath.Join(t.TempDir(), "ost-0")
+	for _, mode := range []ost.SyncMode{"", " FULL", "Data", "none"} {
+		if _, err := ost.NewServiceWithConfig(ost.Config{OSTID: "ost-0", DataDir: dataDir, SyncMode: mode}); err != nil {
+			t.Fatalf("sync mode %q: %v", mode, err)
+		}
+	}
+	if _, err := ost.NewServiceWithConfig(ost.Config{OSTID: "ost-0", DataDir: dataDir, SyncMode: "fsync"}); err == nil {
+		t.Fatalf("expected an error for unknown sync mode")
+	}
+}
+
+func TestOSTInterruptedWritesKeepMatchingChecksums(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	dataDir := filepath.Join(t.TempDir(), "ost-0")
+	svc := newTestOST(t, dataDir)
+	ref := &protogen.BlockRef{FileId: "inode-5", ChunkId: 0, OstId: "ost-0"}
+	blk := filepath.Join(dataDir, "inode-5", "0.blk")
+	crc := filepath.Join(dataDir, "inode-5", "0.crc")
+
+	snapshot := func() (block, sums []byte) {
+		t.Helper()
+		block, err := os.ReadFile(blk)
+		if err != nil {
+			t.Fatalf("read block: %v", err)
+		}
+		sums, err = os.ReadFile(crc)
+		if err != nil {
+			t.Fatalf("read checksums: %v", err)
+		}
+		return block, sums
+	}
+	oldData, newData := randomPayload(5, 150*1024), randomPayload(6, 90*1024)
+	if _, err := svc.WriteBlock(ctx, &protogen.WriteBlockRequest{Block: ref, Data: oldData}); err != nil {
+		t.Fatalf("write old: %v", err)
+	}
+	oldBlock, oldSums := snapshot()
+	if _, err := svc.WriteBlock(ctx, &protogen.WriteBlockRequest{Block: ref, Data: newData, Truncate: true}); err != nil {
+		t.Fatalf("write new: %v", err)
+	}
+	newBlock, newSums := snapshot()
+
+	// A write parks its new checksums, renames the block, then moves the
+	// checksums into place. Stopping after either of the first two steps
+	// must leave a block that reads back whole, old or new.
+	for _, tc := range []struct {
+		name  string
+		block []byte
+		want  []byte
+	}{
+		{"stopped before the block rename", oldBlock, oldData},
+		{"stopped after the block rename", newBlock, newData},
+	} {
+		for path, data := range map[string][]byte{blk: tc.block, crc: oldSums, crc + ".next": newSums} {
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				t.Fatalf("%s: stage %s: %v", tc.name, path, err)
+			}
+		}
+		read, err := svc.ReadBlock(ctx, &protogen.ReadBlockRequest{Block: ref})
+		if err != nil || !bytes.Equal(read.GetData(), tc.want) {
+			t.Fatalf("%s: read before restart failed (err=%v)", tc.name, err)
+		}
+		stats, err := ost.NewScrubber(svc, ost.ScrubConfig{RateBytesPerSec: 1 << 30}).ScrubOnce(ctx)
+		if err != nil || stats.Errors != 0 {
+			t.Fatalf("%s: scrub found %d errors (err=%v)", tc.name, stats.Errors, err)
+		}
+
+		restarted := newTestOST(t, dataDir)
+		if _, err := os.Stat(crc + ".next"); !os.IsNotExist(err) {
+			t.Fatalf("%s: parked checksums survived restart: %v", tc.name, err)
+		}
+		read, err = restarted.ReadBlock(ctx, &protogen.ReadBlockRequest{Block: ref})
+		if err != nil || !bytes.Equal(read.GetData(), tc.want) {
+			t.Fatalf("%s: read after restart failed (err=%v)", tc.name, err)
+		}
+	}
+}
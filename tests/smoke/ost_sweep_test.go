@@ -0,0 +1,96 @@
+package smoke
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rachanaanugandula/kube-pfs/pkg/ost"
+	protogen "github.com/rachanaanugandula/kube-pfs/pkg/proto/gen"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestOSTSweeperRemovesOrphanedFiles(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	mdsSvc := newTestMDS(t, filepath.Join(t.TempDir(), "mds.db"))
+	t.Cleanup(func() { _ = mdsSvc.Close() })
+	mdsClient := protogen.NewMetadataServiceClient(serveInProcess(t, func(s *grpc.Server) { protogen.RegisterMetadataServiceServer(s, mdsSvc) }))
+	dataDir := t.TempDir()
+	ostSvc := newTestOST(t, dataDir)
+
+	live := mustCreate(t, mdsSvc, "root", "live", false).GetInodeId()
+	write := func(fileID string, chunks int) {
+		t.Helper()
+		for c := 0; c < chunks; c++ {
+			ref := &protogen.BlockRef{FileId: fileID, ChunkId: uint64(c), OstId: "ost-0"}
+			if _, err := ostSvc.WriteBlock(ctx, &protogen.WriteBlockRequest{Block: ref, Data: randomPayload(int64(c), 4096)}); err != nil {
+				t.Fatalf("write %s/%d: %v", fileID, c, err)
+			}
+		}
+	}
+	write(live, 2)
+	write("inode-gone", 3)
+	write("inode-fresh", 1)
+	old := time.Now().Add(-2 * time.Hour)
+	for _, fileID := range []string{live, "inode-gone"} {
+		if err := os.Chtimes(filepath.Join(dataDir, fileID), old, old); err != nil {
+			t.Fatalf("age %s: %v", fileID, err)
+		}
+	}
+	exists := func(fileID string) bool {
+		_, err := ostSvc.ReadBlock(ctx, &protogen.ReadBlockRequest{Block: &protogen.BlockRef{FileId: fileID, ChunkId: 0, OstId: "ost-0"}})
+		if err != nil && status.Code(err) != codes.NotFound {
+			t.Fatalf("read %s: %v", fileID, err)
+		}
+		return err == nil
+	}
+
+	// A dry run reports the orphan without touching it. The recently written
+	// orphan is too young to be judged.
+	trash := filepath.Join(dataDir, ".trash")
+	report, err := ost.NewSweeper(ostSvc, mdsClient, ost.SweepConfig{DryRun: true, TrashDir: trash}).SweepOnce(ctx)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if report.Scanned != 2 || len(report.Orphans) != 1 || report.Removed != 0 {
+		t.Fatalf("dry run report %+v", report)
+	}
+	if o := report.Orphans[0]; o.FileID != "inode-gone" || o.Blocks != 3 || o.Bytes != 3*4096 {
+		t.Fatalf("dry run orphan %+v", o)
+	}
+	if !exists("inode-gone") {
+		t.Fatalf("dry run removed the orphan")
+	}
+
+	// A real run moves it to the trash and leaves the live file alone.
+	sweeper := ost.NewSweeper(ostSvc, mdsClient, ost.SweepConfig{BatchSize: 1, TrashDir: trash, TrashRetention: time.Hour})
+	if report, err = sweeper.SweepOnce(ctx); err != nil {
+		t.Fatalf("sweep: %v", err)
+	}
+	if report.Removed != 1 || report.TrashPurged != 0 {
+		t.Fatalf("sweep report %+v", report)
+	}
+	if exists("inode-gone") || !exists(live) || !exists("inode-fresh") {
+		t.Fatalf("after sweep: orphan=%v live=%v fresh=%v", exists("inode-gone"), exists(live), exists("inode-fresh"))
+	}
+	trashed, err := os.ReadDir(trash)
+	if err != nil || len(trashed) != 1 {
+		t.Fatalf("trash holds %v, err %v", trashed, err)
+	}
+
+	// Once past its retention the trashed directory is purged.
+	if err := os.Chtimes(filepath.Join(trash, trashed[0].Name()), old, old); err != nil {
+		t.Fatalf("age trash: %v", err)
+	}
+	if report, err = sweeper.SweepOnce(ctx); err != nil {
+		t.Fatalf("second sweep: %v", err)
+	}
+	if report.TrashPurged != 1 || len(report.Orphans) != 0 {
+		t.Fatalf("second sweep report %+v", report)
+	}
+}